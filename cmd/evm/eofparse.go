@@ -0,0 +1,81 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/urfave/cli/v2"
+)
+
+var HexFlag = &cli.StringFlag{
+	Name:  "hex",
+	Usage: "single container data parse and validation",
+}
+
+var eofParseCommand = &cli.Command{
+	Name:   "eofparse",
+	Usage:  "parses and validates EOF containers",
+	Action: eofParseAction,
+	Flags: []cli.Flag{
+		HexFlag,
+	},
+	Description: `
+The eofparse command parses and validates hex encoded EOF containers, given
+with --hex or one per line on stdin. For each valid container it prints the
+code sections, and otherwise the validation error.`,
+}
+
+func eofParseAction(ctx *cli.Context) error {
+	if ctx.IsSet(HexFlag.Name) {
+		fmt.Println(parseEOF(ctx.String(HexFlag.Name)))
+		return nil
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fmt.Println(parseEOF(line))
+	}
+	return scanner.Err()
+}
+
+// parseEOF parses and validates a hex encoded EOF container, and returns the
+// result line: "OK" followed by the code sections, or the error.
+func parseEOF(input string) string {
+	b, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return fmt.Sprintf("err: unable to decode hex: %v", err)
+	}
+	c, err := vm.ParseEOF(b)
+	if err != nil {
+		return fmt.Sprintf("err: %v", err)
+	}
+	sections := make([]string, len(c.Code))
+	for i, code := range c.Code {
+		sections[i] = hex.EncodeToString(code)
+	}
+	return fmt.Sprintf("OK %s", strings.Join(sections, ","))
+}
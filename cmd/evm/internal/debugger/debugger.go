@@ -0,0 +1,395 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements an interactive step debugger for EVM execution.
+package debugger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// mode is the way the debugger resumes the execution after a pause.
+type mode int
+
+const (
+	modeStep     mode = iota // Pause at the next opcode, stepping into calls
+	modeNext                 // Pause at the next opcode of the same or an outer call
+	modeOut                  // Pause at the next opcode of an outer call
+	modeContinue             // Pause at the next breakpoint
+	modeDetached             // Never pause again
+)
+
+// breakpoint is a condition pausing the execution when met.
+type breakpoint struct {
+	kind  string // One of "pc", "op" or "depth"
+	pc    uint64
+	op    vm.OpCode
+	depth int
+}
+
+// matches returns whether the breakpoint triggers on the given step.
+func (b *breakpoint) matches(pc uint64, op vm.OpCode, depth int) bool {
+	switch b.kind {
+	case "pc":
+		return b.pc == pc
+	case "op":
+		return b.op == op
+	case "depth":
+		return b.depth == depth
+	}
+	return false
+}
+
+func (b *breakpoint) String() string {
+	switch b.kind {
+	case "pc":
+		return fmt.Sprintf("pc %d", b.pc)
+	case "op":
+		return fmt.Sprintf("op %v", b.op)
+	default:
+		return fmt.Sprintf("depth %d", b.depth)
+	}
+}
+
+// parseBreakpoint parses a breakpoint from its kind and value.
+func parseBreakpoint(kind, value string) (*breakpoint, error) {
+	switch kind {
+	case "pc":
+		pc, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pc %q", value)
+		}
+		return &breakpoint{kind: kind, pc: pc}, nil
+	case "op":
+		name := strings.ToUpper(value)
+		op := vm.StringToOp(name)
+		if op == vm.STOP && name != "STOP" {
+			return nil, fmt.Errorf("unknown opcode %q", value)
+		}
+		return &breakpoint{kind: kind, op: op}, nil
+	case "depth":
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			return nil, fmt.Errorf("invalid depth %q", value)
+		}
+		return &breakpoint{kind: kind, depth: depth}, nil
+	}
+	return nil, fmt.Errorf("unknown breakpoint kind %q (want pc, op or depth)", kind)
+}
+
+// Debugger is an EVMLogger pausing the execution according to the commands
+// read from its input, and allowing the state of the machine to be inspected
+// while paused. Since the EVM calls the logger synchronously, waiting for the
+// next command blocks the execution.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	env         *vm.EVM
+	mode        mode
+	depth       int // Call depth the last resume command was issued at
+	breakpoints []*breakpoint
+	storage     map[common.Address]map[common.Hash]struct{} // Storage slots accessed so far
+}
+
+// New creates a debugger reading commands from in, and writing its output
+// to out.
+func New(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		mode:    modeStep,
+		storage: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// CaptureTxStart implements the EVMLogger interface.
+func (d *Debugger) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements the EVMLogger interface.
+func (d *Debugger) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements the EVMLogger interface to initialize the debugging
+// session.
+func (d *Debugger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	d.env = env
+	if create {
+		fmt.Fprintf(d.out, "CREATE %v from %v (gas %d, value %v, %d bytes of code)\n", to, from, gas, value, len(input))
+	} else {
+		fmt.Fprintf(d.out, "CALL %v from %v (gas %d, value %v, input %#x)\n", to, from, gas, value, input)
+	}
+	fmt.Fprintln(d.out, `Type "help" for the list of commands.`)
+}
+
+// CaptureEnd implements the EVMLogger interface to report the outcome of the
+// execution.
+func (d *Debugger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	fmt.Fprintf(d.out, "Execution finished: gas used %d, output %#x\n", gasUsed, output)
+	if err != nil {
+		fmt.Fprintf(d.out, "Error: %v\n", err)
+	}
+}
+
+// CaptureEnter implements the EVMLogger interface to report nested calls while
+// stepping.
+func (d *Debugger) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if d.mode == modeStep {
+		fmt.Fprintf(d.out, "-> %v %v (gas %d, input %#x)\n", typ, to, gas, input)
+	}
+}
+
+// CaptureExit implements the EVMLogger interface to report returning calls
+// while stepping.
+func (d *Debugger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if d.mode == modeStep {
+		if err != nil {
+			fmt.Fprintf(d.out, "<- error %v (gas used %d, output %#x)\n", err, gasUsed, output)
+		} else {
+			fmt.Fprintf(d.out, "<- return (gas used %d, output %#x)\n", gasUsed, output)
+		}
+	}
+}
+
+// CaptureState implements the EVMLogger interface, pausing the execution if
+// needed before the opcode runs.
+func (d *Debugger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// Track the storage slots accessed, to be able to display them
+	if stack := scope.Stack.Data(); (op == vm.SLOAD || op == vm.SSTORE) && len(stack) >= 1 {
+		addr := scope.Contract.Address()
+		if d.storage[addr] == nil {
+			d.storage[addr] = make(map[common.Hash]struct{})
+		}
+		d.storage[addr][common.Hash(stack[len(stack)-1].Bytes32())] = struct{}{}
+	}
+	if !d.shouldPause(pc, op, depth) {
+		return
+	}
+	step := &step{pc: pc, op: op, gas: gas, cost: cost, scope: scope, rData: rData, depth: depth}
+	d.printStep(step)
+	d.prompt(step)
+}
+
+// CaptureFault implements the EVMLogger interface to report execution faults.
+func (d *Debugger) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if d.mode != modeDetached {
+		fmt.Fprintf(d.out, "Fault at [%d] pc=%d %v: %v\n", depth, pc, op, err)
+	}
+}
+
+// shouldPause returns whether the execution needs to be paused at the step.
+func (d *Debugger) shouldPause(pc uint64, op vm.OpCode, depth int) bool {
+	switch d.mode {
+	case modeDetached:
+		return false
+	case modeStep:
+		return true
+	case modeNext:
+		if depth <= d.depth {
+			return true
+		}
+	case modeOut:
+		if depth < d.depth {
+			return true
+		}
+	}
+	for _, b := range d.breakpoints {
+		if b.matches(pc, op, depth) {
+			fmt.Fprintf(d.out, "Breakpoint hit: %v\n", b)
+			return true
+		}
+	}
+	return false
+}
+
+// step is the machine state at a paused opcode.
+type step struct {
+	pc    uint64
+	op    vm.OpCode
+	gas   uint64
+	cost  uint64
+	scope *vm.ScopeContext
+	rData []byte
+	depth int
+}
+
+func (d *Debugger) printStep(s *step) {
+	fmt.Fprintf(d.out, "[%d] %v pc=%d %v gas=%d cost=%d\n", s.depth, s.scope.Contract.Address(), s.pc, s.op, s.gas, s.cost)
+}
+
+// prompt reads and runs commands until one resumes the execution. Running out
+// of input detaches the debugger.
+func (d *Debugger) prompt(s *step) {
+	for {
+		fmt.Fprint(d.out, "(evm) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.mode = modeDetached
+			return
+		}
+		fields := strings.Fields(d.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if d.run(s, fields[0], fields[1:]) {
+			d.depth = s.depth
+			return
+		}
+	}
+}
+
+// run executes a single command, returning whether the execution is resumed.
+func (d *Debugger) run(s *step, cmd string, args []string) bool {
+	switch cmd {
+	case "s", "step":
+		d.mode = modeStep
+		return true
+	case "n", "next":
+		d.mode = modeNext
+		return true
+	case "o", "out":
+		d.mode = modeOut
+		return true
+	case "c", "continue":
+		d.mode = modeContinue
+		return true
+	case "q", "quit":
+		d.mode = modeDetached
+		return true
+	case "b", "break":
+		if len(args) == 0 {
+			for i, b := range d.breakpoints {
+				fmt.Fprintf(d.out, "%d: %v\n", i, b)
+			}
+			return false
+		}
+		if len(args) != 2 {
+			fmt.Fprintln(d.out, "Usage: break <pc|op|depth> <value>")
+			return false
+		}
+		b, err := parseBreakpoint(args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(d.out, "Error: %v\n", err)
+			return false
+		}
+		d.breakpoints = append(d.breakpoints, b)
+		fmt.Fprintf(d.out, "Breakpoint %d: %v\n", len(d.breakpoints)-1, b)
+	case "d", "delete":
+		if len(args) != 1 {
+			fmt.Fprintln(d.out, "Usage: delete <index>")
+			return false
+		}
+		i, err := strconv.Atoi(args[0])
+		if err != nil || i < 0 || i >= len(d.breakpoints) {
+			fmt.Fprintf(d.out, "Error: no breakpoint %q\n", args[0])
+			return false
+		}
+		d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+	case "i", "info":
+		d.printStep(s)
+	case "st", "stack":
+		d.printStack(s)
+	case "m", "memory":
+		d.printMemory(s)
+	case "sto", "storage":
+		d.printStorage(s)
+	case "r", "returndata":
+		if len(s.rData) == 0 {
+			fmt.Fprintln(d.out, "<empty>")
+			return false
+		}
+		fmt.Fprintf(d.out, "%#x\n", s.rData)
+	case "h", "help":
+		fmt.Fprint(d.out, help)
+	default:
+		fmt.Fprintf(d.out, "Unknown command %q, see \"help\"\n", cmd)
+	}
+	return false
+}
+
+const help = `Execution:
+  s, step               run the next opcode, stepping into calls
+  n, next               run the next opcode, stepping over calls
+  o, out                run until the current call returns
+  c, continue           run until the next breakpoint
+  q, quit               detach the debugger and run to completion
+Breakpoints:
+  b, break              list the breakpoints
+  b, break pc <n>       pause before running the opcode at pc n
+  b, break op <name>    pause before running the named opcode
+  b, break depth <n>    pause at call depth n
+  d, delete <index>     delete a breakpoint
+Inspection:
+  i, info               show the current opcode
+  st, stack             show the stack, top first
+  m, memory             show the memory
+  sto, storage          show the storage slots accessed so far
+  r, returndata         show the return data of the last call
+`
+
+func (d *Debugger) printStack(s *step) {
+	stack := s.scope.Stack.Data()
+	if len(stack) == 0 {
+		fmt.Fprintln(d.out, "<empty>")
+		return
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		fmt.Fprintf(d.out, "%4d: %#x\n", len(stack)-1-i, stack[i].Bytes32())
+	}
+}
+
+func (d *Debugger) printMemory(s *step) {
+	mem := s.scope.Memory.Data()
+	if len(mem) == 0 {
+		fmt.Fprintln(d.out, "<empty>")
+		return
+	}
+	for offset := 0; offset < len(mem); offset += 32 {
+		end := offset + 32
+		if end > len(mem) {
+			end = len(mem)
+		}
+		fmt.Fprintf(d.out, "%#06x: %x\n", offset, mem[offset:end])
+	}
+}
+
+func (d *Debugger) printStorage(s *step) {
+	addr := s.scope.Contract.Address()
+	slots := make([]common.Hash, 0, len(d.storage[addr]))
+	for slot := range d.storage[addr] {
+		slots = append(slots, slot)
+	}
+	if len(slots) == 0 {
+		fmt.Fprintln(d.out, "<no slots accessed>")
+		return
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i][:], slots[j][:]) < 0
+	})
+	for _, slot := range slots {
+		fmt.Fprintf(d.out, "%v: %v\n", slot, d.env.StateDB.GetState(addr, slot))
+	}
+}
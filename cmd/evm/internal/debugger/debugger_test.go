@@ -0,0 +1,118 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+var (
+	// calleeCode is PUSH1 2, POP, STOP.
+	calleeCode = common.FromHex("60025000")
+
+	// callerCode calls the callee at 0xcc (CALL at pc 13), then stores 1 in
+	// slot 0 (SSTORE at pc 18) and stops.
+	callerCode = common.FromHex("6000600060006000600060cc5af1600160005500")
+)
+
+// debug runs the caller code in the debugger, driven by the given commands,
+// and returns the output of the debugger.
+func debug(t *testing.T, commands string) string {
+	t.Helper()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(common.HexToAddress("0xcc"), calleeCode)
+
+	var out bytes.Buffer
+	debugger := New(strings.NewReader(commands), &out)
+	if _, _, err := runtime.Execute(callerCode, nil, &runtime.Config{
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: debugger},
+	}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		commands string
+		want     []string
+		unwanted []string
+	}{
+		{
+			name:     "step over call",
+			commands: "b op CALL\nc\nn\nq\n",
+			want:     []string{"Breakpoint hit: op CALL", "pc=13 CALL", "pc=14 PUSH1"},
+			unwanted: []string{"[2]"},
+		},
+		{
+			name:     "step into and out of call",
+			commands: "b pc 13\nc\ns\no\nq\n",
+			want:     []string{"-> CALL", "[2] ", "pc=0 PUSH1", "[1] ", "pc=14 PUSH1"},
+		},
+		{
+			name:     "break on depth",
+			commands: "b depth 2\nc\nq\n",
+			want:     []string{"Breakpoint hit: depth 2", "[2] "},
+		},
+		{
+			name:     "inspect stack and storage",
+			commands: "b op SSTORE\nc\nstack\nstorage\nq\n",
+			want: []string{
+				"   0: 0x0000000000000000000000000000000000000000000000000000000000000000",
+				"   1: 0x0000000000000000000000000000000000000000000000000000000000000001",
+				"0x0000000000000000000000000000000000000000000000000000000000000000: 0x0000000000000000000000000000000000000000000000000000000000000000",
+			},
+		},
+		{
+			name:     "invalid breakpoints",
+			commands: "b op NOPE\nb pc x\nb foo 1\nq\n",
+			want:     []string{`unknown opcode "NOPE"`, `invalid pc "x"`, `unknown breakpoint kind "foo"`},
+		},
+		{
+			name:     "detach on end of input",
+			commands: "",
+			want:     []string{"pc=0 PUSH1", "Execution finished"},
+			unwanted: []string{"pc=2 PUSH1"},
+		},
+	}
+	for _, tt := range tests {
+		out := debug(t, tt.commands)
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: missing %q in output:\n%s", tt.name, want, out)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(out, unwanted) {
+				t.Errorf("%s: unexpected %q in output:\n%s", tt.name, unwanted, out)
+			}
+		}
+	}
+}
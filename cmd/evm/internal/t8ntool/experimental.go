@@ -0,0 +1,44 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ExperimentalConfig returns a copy of the chain config with the given comma
+// separated experimental VM extensions activated from genesis.
+func ExperimentalConfig(config *params.ChainConfig, names string) (*params.ChainConfig, error) {
+	cpy := *config
+	cpy.Experimental = make(map[string]*big.Int, len(config.Experimental))
+	for name, block := range config.Experimental {
+		cpy.Experimental[name] = block
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if !vm.ValidExtension(name) {
+			return nil, fmt.Errorf("unknown experimental extension %q (registered: %s)", name, strings.Join(vm.Extensions(), ", "))
+		}
+		cpy.Experimental[name] = new(big.Int)
+	}
+	return &cpy, nil
+}
@@ -161,4 +161,13 @@ var (
 		Usage: "sets the verbosity level",
 		Value: 3,
 	}
+	ExperimentalFlag = &cli.StringFlag{
+		Name: "experimental",
+		Usage: "Comma-separated list of experimental VM extensions to activate from genesis." +
+			"\n\tExtensions are registered by the Go packages compiled into the binary.",
+	}
+	GasScheduleFlag = &cli.StringFlag{
+		Name:  "gasschedule",
+		Usage: "File name of the JSON gas schedule overriding the gas costs of the fork",
+	}
 )
@@ -0,0 +1,50 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// GasScheduleConfig returns a copy of the chain config with the gas schedule
+// loaded from the given JSON file.
+func GasScheduleConfig(config *params.ChainConfig, file string) (*params.ChainConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading gas schedule: %v", err)
+	}
+	schedule := new(params.GasSchedule)
+	if err := json.Unmarshal(data, schedule); err != nil {
+		return nil, fmt.Errorf("failed unmarshaling gas schedule: %v", err)
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid gas schedule: %v", err)
+	}
+	for name := range schedule.Opcodes {
+		if vm.StringToOp(name) == 0 && name != vm.STOP.String() {
+			return nil, fmt.Errorf("invalid gas schedule: unknown opcode %s", name)
+		}
+	}
+	cpy := *config
+	cpy.GasSchedule = schedule
+	return &cpy, nil
+}
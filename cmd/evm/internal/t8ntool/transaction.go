@@ -86,6 +86,13 @@ func Transaction(ctx *cli.Context) error {
 	} else {
 		chainConfig = cConf
 	}
+	if file := ctx.String(GasScheduleFlag.Name); file != "" {
+		cConf, err := GasScheduleConfig(chainConfig, file)
+		if err != nil {
+			return NewError(ErrorConfig, err)
+		}
+		chainConfig = cConf
+	}
 	// Set the chain id
 	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
 	var body hexutil.Bytes
@@ -139,8 +146,8 @@ func Transaction(ctx *cli.Context) error {
 			r.Address = sender
 		}
 		// Check intrinsic gas
-		if gas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil,
-			chainConfig.IsHomestead(new(big.Int)), chainConfig.IsIstanbul(new(big.Int))); err != nil {
+		if gas, err := core.IntrinsicGasWithSchedule(tx.Data(), tx.AccessList(), tx.To() == nil,
+			chainConfig.IsHomestead(new(big.Int)), chainConfig.IsIstanbul(new(big.Int)), chainConfig.GasSchedule); err != nil {
 			r.Error = err
 			results = append(results, r)
 			continue
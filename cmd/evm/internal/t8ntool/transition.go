@@ -190,6 +190,20 @@ func Transition(ctx *cli.Context) error {
 		chainConfig = cConf
 		vmConfig.ExtraEips = extraEips
 	}
+	if names := ctx.String(ExperimentalFlag.Name); names != "" {
+		cConf, err := ExperimentalConfig(chainConfig, names)
+		if err != nil {
+			return NewError(ErrorConfig, err)
+		}
+		chainConfig = cConf
+	}
+	if file := ctx.String(GasScheduleFlag.Name); file != "" {
+		cConf, err := GasScheduleConfig(chainConfig, file)
+		if err != nil {
+			return NewError(ErrorConfig, err)
+		}
+		chainConfig = cConf
+	}
 	// Set the chain id
 	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
 
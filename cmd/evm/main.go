@@ -125,6 +125,10 @@ var (
 		Value: true,
 		Usage: "enable return data output",
 	}
+	InteractiveFlag = &cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "step through the execution in an interactive debugger",
+	}
 )
 
 var stateTransitionCommand = &cli.Command{
@@ -150,6 +154,8 @@ var stateTransitionCommand = &cli.Command{
 		t8ntool.ChainIDFlag,
 		t8ntool.RewardFlag,
 		t8ntool.VerbosityFlag,
+		t8ntool.ExperimentalFlag,
+		t8ntool.GasScheduleFlag,
 	},
 }
 
@@ -163,6 +169,7 @@ var transactionCommand = &cli.Command{
 		t8ntool.ChainIDFlag,
 		t8ntool.ForknameFlag,
 		t8ntool.VerbosityFlag,
+		t8ntool.GasScheduleFlag,
 	},
 }
 
@@ -212,6 +219,8 @@ func init() {
 		DisableStackFlag,
 		DisableStorageFlag,
 		DisableReturnDataFlag,
+		t8ntool.ExperimentalFlag,
+		t8ntool.GasScheduleFlag,
 	}
 	app.Commands = []*cli.Command{
 		compileCommand,
@@ -221,6 +230,7 @@ func init() {
 		stateTransitionCommand,
 		transactionCommand,
 		blockBuilderCommand,
+		eofParseCommand,
 	}
 }
 
@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -29,6 +30,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/evm/internal/compiler"
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/t8ntool"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -44,11 +47,17 @@ import (
 )
 
 var runCommand = &cli.Command{
-	Action:      runCmd,
-	Name:        "run",
-	Usage:       "run arbitrary evm binary",
-	ArgsUsage:   "<code>",
-	Description: `The run command runs arbitrary EVM code.`,
+	Action:    runCmd,
+	Name:      "run",
+	Usage:     "run arbitrary evm binary",
+	ArgsUsage: "<code>",
+	Flags:     []cli.Flag{InteractiveFlag},
+	Description: `The run command runs arbitrary EVM code.
+
+With --interactive, the execution is paused before the first opcode and driven
+from the terminal: stepping by opcode, into and out of nested calls, breaking on
+a pc, an opcode or a call depth, and inspecting the stack, memory, storage and
+return data along the way.`,
 }
 
 // readGenesis will read the given JSON format genesis file and return
@@ -126,7 +135,12 @@ func runCmd(ctx *cli.Context) error {
 		receiver      = common.BytesToAddress([]byte("receiver"))
 		genesisConfig *core.Genesis
 	)
-	if ctx.Bool(MachineFlag.Name) {
+	if ctx.Bool(InteractiveFlag.Name) {
+		if ctx.String(CodeFileFlag.Name) == "-" {
+			return errors.New("--interactive reads commands from stdin, code cannot be read from there too")
+		}
+		tracer = debugger.New(os.Stdin, os.Stdout)
+	} else if ctx.Bool(MachineFlag.Name) {
 		tracer = logger.NewJSONLogger(logconfig, os.Stdout)
 	} else if ctx.Bool(DebugFlag.Name) {
 		debugLogger = logger.NewStructLogger(logconfig)
@@ -214,7 +228,7 @@ func runCmd(ctx *cli.Context) error {
 		BlockNumber: new(big.Int).SetUint64(genesisConfig.Number),
 		EVMConfig: vm.Config{
 			Tracer: tracer,
-			Debug:  ctx.Bool(DebugFlag.Name) || ctx.Bool(MachineFlag.Name),
+			Debug:  ctx.Bool(DebugFlag.Name) || ctx.Bool(MachineFlag.Name) || ctx.Bool(InteractiveFlag.Name),
 		},
 	}
 
@@ -236,6 +250,20 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		runtimeConfig.ChainConfig = params.AllEthashProtocolChanges
 	}
+	if names := ctx.String(t8ntool.ExperimentalFlag.Name); names != "" {
+		config, err := t8ntool.ExperimentalConfig(runtimeConfig.ChainConfig, names)
+		if err != nil {
+			return err
+		}
+		runtimeConfig.ChainConfig = config
+	}
+	if file := ctx.String(t8ntool.GasScheduleFlag.Name); file != "" {
+		config, err := t8ntool.GasScheduleConfig(runtimeConfig.ChainConfig, file)
+		if err != nil {
+			return err
+		}
+		runtimeConfig.ChainConfig = config
+	}
 
 	var hexInput []byte
 	if inputFileFlag := ctx.String(InputFileFlag.Name); inputFileFlag != "" {
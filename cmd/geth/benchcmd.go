@@ -0,0 +1,413 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	benchOutputFlag = &cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the JSON report of the run into",
+	}
+	benchCompareFlag = &cli.StringFlag{
+		Name:  "compare",
+		Usage: "JSON report of a previous run to compare the results against",
+	}
+)
+
+var (
+	benchCommand = &cli.Command{
+		Name:  "bench",
+		Usage: "A set of commands for benchmarking the client",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "blocks",
+				Usage:     "Re-execute a range of stored blocks and report the execution performance",
+				ArgsUsage: "<from> <to>",
+				Action:    benchBlocks,
+				Flags: flags.Merge([]cli.Flag{
+					benchOutputFlag,
+					benchCompareFlag,
+					utils.CacheFlag,
+					utils.CacheTrieFlag,
+					utils.CacheNoPrefetchFlag,
+					utils.SnapshotFlag,
+					configFileFlag,
+				}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth bench blocks <from> <to>
+re-executes the stored blocks in the given range (inclusive) through the state
+processor, on top of a read-only copy of the state, and reports the gas per
+second processed by each block and transaction.
+
+The time spent on each block is split into:
+
+  read:  loading accounts and storage slots from the snapshot or the tries
+  exec:  running the transactions, everything else than reading and hashing
+  hash:  updating and hashing the tries, and validating the block
+
+The state of the parent of the first block must be available. The following
+blocks run on top of their committed parent state when available, and on the
+in-memory state left by their parent otherwise (reported as carried), whose
+cached objects make the state reads cheaper. Nothing is written to disk.
+
+The report of a run can be saved with --output, and compared with --compare
+against the report of a previous run over the same blocks, e.g. to measure the
+effect of an interpreter change on the same chain data. The trie caches start
+cold, so the first blocks of a run are slower.`,
+			},
+		},
+	}
+)
+
+// benchReport is the result of benchmarking a range of blocks.
+type benchReport struct {
+	Blocks []*benchBlock `json:"blocks"`
+}
+
+// benchBlock is the result of re-executing a single block.
+type benchBlock struct {
+	Number    uint64        `json:"number"`
+	Hash      common.Hash   `json:"hash"`
+	GasUsed   uint64        `json:"gasUsed"`
+	Elapsed   time.Duration `json:"elapsed"`   // Total processing time (ns)
+	StateRead time.Duration `json:"stateRead"` // Time spent reading the state (ns)
+	Execution time.Duration `json:"execution"` // Time spent executing the transactions (ns)
+	Hashing   time.Duration `json:"hashing"`   // Time spent hashing and validating (ns)
+	Carried   bool          `json:"carried,omitempty"`
+	Txs       []*benchTx    `json:"txs"`
+}
+
+// benchTx is the result of re-executing a single transaction.
+type benchTx struct {
+	Hash    common.Hash   `json:"hash"`
+	GasUsed uint64        `json:"gasUsed"`
+	Elapsed time.Duration `json:"elapsed"` // Processing time, including the state accesses (ns)
+}
+
+// benchTimer is a block tracer timing the execution of the transactions.
+type benchTimer struct {
+	starts []time.Time
+	end    time.Time
+}
+
+func (t *benchTimer) BlockStart(block *types.Block) {
+	t.starts = t.starts[:0]
+}
+
+func (t *benchTimer) TxTracer(index int, tx *types.Transaction) vm.EVMLogger {
+	t.starts = append(t.starts, time.Now())
+	return nil
+}
+
+func (t *benchTimer) BlockEnd(err error) {
+	t.end = time.Now()
+}
+
+// elapsed returns the processing time of the index'th transaction, or zero if
+// it wasn't processed.
+func (t *benchTimer) elapsed(index int) time.Duration {
+	if index >= len(t.starts) {
+		return 0
+	}
+	if index+1 < len(t.starts) {
+		return t.starts[index+1].Sub(t.starts[index])
+	}
+	return t.end.Sub(t.starts[index])
+}
+
+// mgasps returns the megagas per second processed.
+func mgasps(gas uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(gas) * 1000 / float64(elapsed)
+}
+
+// benchBlocks re-executes a range of blocks and reports the performance.
+func benchBlocks(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("need the first and last block numbers as arguments")
+	}
+	from, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid first block: %v", err)
+	}
+	to, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid last block: %v", err)
+	}
+	if from == 0 || from > to {
+		return fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	var baseline *benchReport
+	if file := ctx.String(benchCompareFlag.Name); file != "" {
+		if baseline, err = loadBenchReport(file); err != nil {
+			return err
+		}
+	}
+	// The state only measures the time spent reading and hashing with the
+	// expensive metrics enabled, force them on to split the processing time
+	metrics.EnabledExpensive = true
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer db.Close()
+	defer chain.Stop()
+
+	processor, ok := chain.Processor().(*core.StateProcessor)
+	if !ok {
+		return errors.New("unsupported block processor")
+	}
+	var (
+		report  = new(benchReport)
+		timer   = new(benchTimer)
+		statedb *state.StateDB
+	)
+	for number := from; number <= to; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("block %d not found", number)
+		}
+		parent := chain.GetHeader(block.ParentHash(), number-1)
+		if parent == nil {
+			return fmt.Errorf("parent of block %d not found", number)
+		}
+		// Run on the committed parent state, falling back to the in-memory
+		// state of the previous block
+		carried := false
+		if fresh, err := chain.StateAt(parent.Root); err == nil {
+			statedb = fresh
+		} else if statedb == nil {
+			return fmt.Errorf("missing state of block %d (historical state requires --gcmode=archive): %v", number-1, err)
+		} else {
+			carried = true
+		}
+		// Recover the senders upfront, as the import pipeline does
+		signer := types.MakeSigner(chain.Config(), block.Number())
+		for _, tx := range block.Transactions() {
+			if _, err := types.Sender(signer, tx); err != nil {
+				return fmt.Errorf("block %d: invalid transaction %v: %v", number, tx.Hash(), err)
+			}
+		}
+		result, err := runBenchBlock(chain, processor, timer, block, statedb)
+		if err != nil {
+			return err
+		}
+		result.Carried = carried
+		report.Blocks = append(report.Blocks, result)
+
+		log.Info("Benchmarked block", "number", number, "txs", len(result.Txs), "gas", result.GasUsed,
+			"mgasps", fmt.Sprintf("%.2f", mgasps(result.GasUsed, result.Elapsed)), "elapsed", common.PrettyDuration(result.Elapsed),
+			"read", common.PrettyDuration(result.StateRead), "exec", common.PrettyDuration(result.Execution),
+			"hash", common.PrettyDuration(result.Hashing), "carried", carried)
+		for i, tx := range result.Txs {
+			log.Debug("Benchmarked transaction", "number", number, "index", i, "hash", tx.Hash, "gas", tx.GasUsed,
+				"mgasps", fmt.Sprintf("%.2f", mgasps(tx.GasUsed, tx.Elapsed)), "elapsed", common.PrettyDuration(tx.Elapsed))
+		}
+	}
+	if file := ctx.String(benchOutputFlag.Name); file != "" {
+		blob, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, blob, 0644); err != nil {
+			return err
+		}
+		log.Info("Wrote benchmark report", "file", file)
+	}
+	if baseline != nil {
+		return compareBenchReports(baseline, report)
+	}
+	printBenchSummary(report)
+	return nil
+}
+
+// runBenchBlock processes and validates a block on top of its parent state.
+func runBenchBlock(chain *core.BlockChain, processor *core.StateProcessor, timer *benchTimer, block *types.Block, statedb *state.StateDB) (*benchBlock, error) {
+	var (
+		reads0  = stateReads(statedb)
+		hashes0 = stateHashes(statedb)
+	)
+	start := time.Now()
+	receipts, _, usedGas, err := processor.ProcessWithTracer(block, statedb, *chain.GetVMConfig(), timer)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: processing failed: %v", block.NumberU64(), err)
+	}
+	processed := time.Now()
+	var (
+		reads  = stateReads(statedb) - reads0
+		hashes = stateHashes(statedb) - hashes0
+	)
+	if err := chain.Validator().ValidateState(block, statedb, receipts, usedGas); err != nil {
+		return nil, fmt.Errorf("block %d: validation failed: %v", block.NumberU64(), err)
+	}
+	var (
+		execution = processed.Sub(start)
+		hashing   = time.Since(processed)
+	)
+	result := &benchBlock{
+		Number:    block.NumberU64(),
+		Hash:      block.Hash(),
+		GasUsed:   usedGas,
+		Elapsed:   execution + hashing,
+		StateRead: reads,
+		Execution: execution - reads - hashes,
+		Hashing:   hashing + hashes,
+	}
+	for i, tx := range block.Transactions() {
+		result.Txs = append(result.Txs, &benchTx{
+			Hash:    tx.Hash(),
+			GasUsed: receipts[i].GasUsed,
+			Elapsed: timer.elapsed(i),
+		})
+	}
+	return result, nil
+}
+
+// stateReads returns the time spent by the state reading accounts and slots.
+func stateReads(statedb *state.StateDB) time.Duration {
+	return statedb.AccountReads + statedb.StorageReads + statedb.SnapshotAccountReads + statedb.SnapshotStorageReads
+}
+
+// stateHashes returns the time spent by the state updating and hashing the tries.
+func stateHashes(statedb *state.StateDB) time.Duration {
+	return statedb.AccountUpdates + statedb.StorageUpdates + statedb.AccountHashes + statedb.StorageHashes
+}
+
+// loadBenchReport reads the JSON report of a previous run.
+func loadBenchReport(file string) (*benchReport, error) {
+	blob, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	report := new(benchReport)
+	if err := json.Unmarshal(blob, report); err != nil {
+		return nil, fmt.Errorf("invalid benchmark report %s: %v", file, err)
+	}
+	return report, nil
+}
+
+// benchSummary is the aggregated result of a run.
+type benchSummary struct {
+	blocks, txs uint64
+	gas         uint64
+	elapsed     time.Duration
+	read        time.Duration
+	exec        time.Duration
+	hash        time.Duration
+	txMgasps    float64 // Median gas per second of the transactions
+}
+
+// summarize aggregates the results of the given blocks.
+func summarize(blocks []*benchBlock) benchSummary {
+	var (
+		sum   benchSummary
+		rates []float64
+	)
+	for _, block := range blocks {
+		sum.blocks++
+		sum.gas += block.GasUsed
+		sum.elapsed += block.Elapsed
+		sum.read += block.StateRead
+		sum.exec += block.Execution
+		sum.hash += block.Hashing
+		for _, tx := range block.Txs {
+			sum.txs++
+			rates = append(rates, mgasps(tx.GasUsed, tx.Elapsed))
+		}
+	}
+	if len(rates) > 0 {
+		sort.Float64s(rates)
+		sum.txMgasps = rates[len(rates)/2]
+	}
+	return sum
+}
+
+// printBenchSummary prints the aggregated results of a run.
+func printBenchSummary(report *benchReport) {
+	sum := summarize(report.Blocks)
+	fmt.Printf("Blocks:          %d\n", sum.blocks)
+	fmt.Printf("Transactions:    %d\n", sum.txs)
+	fmt.Printf("Gas used:        %d\n", sum.gas)
+	fmt.Printf("Elapsed:         %v\n", common.PrettyDuration(sum.elapsed))
+	fmt.Printf("  State read:    %v\n", common.PrettyDuration(sum.read))
+	fmt.Printf("  Execution:     %v\n", common.PrettyDuration(sum.exec))
+	fmt.Printf("  Hashing:       %v\n", common.PrettyDuration(sum.hash))
+	fmt.Printf("Mgas/s:          %.2f\n", mgasps(sum.gas, sum.elapsed))
+	fmt.Printf("Tx Mgas/s (p50): %.2f\n", sum.txMgasps)
+}
+
+// compareBenchReports prints the results of a run against a previous one over
+// the same blocks.
+func compareBenchReports(baseline, report *benchReport) error {
+	if len(baseline.Blocks) != len(report.Blocks) {
+		return fmt.Errorf("block count mismatch: baseline %d, current %d", len(baseline.Blocks), len(report.Blocks))
+	}
+	for i, block := range report.Blocks {
+		if baseline.Blocks[i].Hash != block.Hash {
+			return fmt.Errorf("block %d mismatch: baseline %v, current %v", block.Number, baseline.Blocks[i].Hash, block.Hash)
+		}
+	}
+	var (
+		base = summarize(baseline.Blocks)
+		cur  = summarize(report.Blocks)
+	)
+	change := func(old, new float64) string {
+		if old == 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%+.2f%%", (new-old)*100/old)
+	}
+	fmt.Printf("%-16s %14s %14s %10s\n", "", "Baseline", "Current", "Change")
+	for _, row := range []struct {
+		name     string
+		old, new time.Duration
+	}{
+		{"Elapsed", base.elapsed, cur.elapsed},
+		{"  State read", base.read, cur.read},
+		{"  Execution", base.exec, cur.exec},
+		{"  Hashing", base.hash, cur.hash},
+	} {
+		fmt.Printf("%-16s %14v %14v %10s\n", row.name, common.PrettyDuration(row.old), common.PrettyDuration(row.new), change(float64(row.old), float64(row.new)))
+	}
+	oldRate, newRate := mgasps(base.gas, base.elapsed), mgasps(cur.gas, cur.elapsed)
+	fmt.Printf("%-16s %14.2f %14.2f %10s\n", "Mgas/s", oldRate, newRate, change(oldRate, newRate))
+	fmt.Printf("%-16s %14.2f %14.2f %10s\n", "Tx Mgas/s (p50)", base.txMgasps, cur.txMgasps, change(base.txMgasps, cur.txMgasps))
+	return nil
+}
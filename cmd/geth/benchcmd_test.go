@@ -0,0 +1,105 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBenchTimerElapsed(t *testing.T) {
+	var (
+		base  = time.Unix(0, 0)
+		timer = &benchTimer{
+			starts: []time.Time{base, base.Add(2 * time.Millisecond), base.Add(5 * time.Millisecond)},
+			end:    base.Add(9 * time.Millisecond),
+		}
+	)
+	for i, want := range []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond, 0} {
+		if have := timer.elapsed(i); have != want {
+			t.Errorf("tx %d: elapsed mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+func TestBenchSummarize(t *testing.T) {
+	blocks := []*benchBlock{
+		{
+			GasUsed:   3000000,
+			Elapsed:   30 * time.Millisecond,
+			StateRead: 10 * time.Millisecond,
+			Execution: 15 * time.Millisecond,
+			Hashing:   5 * time.Millisecond,
+			Txs: []*benchTx{
+				{GasUsed: 1000000, Elapsed: 10 * time.Millisecond}, // 100 Mgas/s
+				{GasUsed: 2000000, Elapsed: 10 * time.Millisecond}, // 200 Mgas/s
+			},
+		},
+		{
+			GasUsed:   1000000,
+			Elapsed:   10 * time.Millisecond,
+			StateRead: 2 * time.Millisecond,
+			Execution: 7 * time.Millisecond,
+			Hashing:   1 * time.Millisecond,
+			Txs: []*benchTx{
+				{GasUsed: 1000000, Elapsed: 20 * time.Millisecond}, // 50 Mgas/s
+			},
+		},
+	}
+	have := summarize(blocks)
+	want := benchSummary{
+		blocks:   2,
+		txs:      3,
+		gas:      4000000,
+		elapsed:  40 * time.Millisecond,
+		read:     12 * time.Millisecond,
+		exec:     22 * time.Millisecond,
+		hash:     6 * time.Millisecond,
+		txMgasps: 100,
+	}
+	if have != want {
+		t.Errorf("summary mismatch: have %+v, want %+v", have, want)
+	}
+	if have := summarize(nil); have != (benchSummary{}) {
+		t.Errorf("empty summary mismatch: have %+v", have)
+	}
+}
+
+func TestCompareBenchReports(t *testing.T) {
+	var (
+		block1 = &benchBlock{Number: 1, Hash: common.Hash{0x01}, GasUsed: 1000000, Elapsed: time.Millisecond}
+		block2 = &benchBlock{Number: 2, Hash: common.Hash{0x02}, GasUsed: 1000000, Elapsed: time.Millisecond}
+		other  = &benchBlock{Number: 2, Hash: common.Hash{0xff}, GasUsed: 1000000, Elapsed: time.Millisecond}
+	)
+	tests := []struct {
+		baseline, current []*benchBlock
+		fail              bool
+	}{
+		{[]*benchBlock{block1, block2}, []*benchBlock{block1, block2}, false},
+		{[]*benchBlock{block1, block2}, []*benchBlock{block1}, true},
+		{[]*benchBlock{block1, block2}, []*benchBlock{block1, other}, true},
+		{nil, nil, false},
+	}
+	for i, tt := range tests {
+		err := compareBenchReports(&benchReport{Blocks: tt.baseline}, &benchReport{Blocks: tt.current})
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
@@ -85,6 +85,10 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.TxPoolLifecycleFlag,
+		utils.TxPoolLifecycleLimitFlag,
 		utils.SyncModeFlag,
 		utils.SyncTargetFlag,
 		utils.ExitWhenSyncedFlag,
@@ -129,6 +133,8 @@ var (
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerifyFlag,
 		utils.MinerNewPayloadTimeout,
+		utils.MinerOrderingFlag,
+		utils.MinerOrderingSeedFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -140,6 +146,8 @@ var (
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperGasLimitFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMTraceFlag,
+		utils.VMTraceConfigFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
 		utils.FakePoWFlag,
@@ -180,6 +188,8 @@ var (
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.TraceCacheFlag,
+		utils.TraceCacheSizeFlag,
 		utils.AllowUnprotectedTxs,
 	}
 
@@ -237,8 +247,14 @@ func init() {
 		dbCommand,
 		// See cmd/utils/flags_legacy.go
 		utils.ShowDeprecated,
+		// See benchcmd.go
+		benchCommand,
 		// See snapshot.go
 		snapshotCommand,
+		// See tracecmd.go
+		traceCommand,
+		// See txpoolcmd.go
+		txpoolCommand,
 		// See verkle.go
 		verkleCommand,
 	}
@@ -0,0 +1,155 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	traceTracerFlag = &cli.StringFlag{
+		Name:  "tracer",
+		Usage: "Name of the tracer to trace the transactions with (default = struct logger)",
+	}
+	traceTracerConfigFlag = &cli.StringFlag{
+		Name:  "tracerconfig",
+		Usage: "Configuration of the tracer, as a JSON object",
+	}
+	traceFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the exported files (csv or parquet)",
+		Value: "csv",
+	}
+	traceOutputFlag = &cli.StringFlag{
+		Name:  "output",
+		Usage: "Directory to write the exported files into (default = new temporary directory)",
+	}
+	traceReexecFlag = &cli.Uint64Flag{
+		Name:  "reexec",
+		Usage: "Number of blocks to re-execute to regenerate missing historical state",
+		Value: 128,
+	}
+)
+
+var (
+	traceCommand = &cli.Command{
+		Name:  "trace",
+		Usage: "A set of commands for tracing the chain",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Trace a range of blocks and export the results into CSV or Parquet files",
+				ArgsUsage: "<start> <end>",
+				Action:    exportTraces,
+				Flags: flags.Merge([]cli.Flag{
+					traceTracerFlag,
+					traceTracerConfigFlag,
+					traceFormatFlag,
+					traceOutputFlag,
+					traceReexecFlag,
+					utils.CacheFlag,
+					configFileFlag,
+				}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth trace export <start> <end>
+traces all transactions of the blocks in the given range (inclusive), with the
+struct logger or the tracer given by --tracer, and writes the results into
+columnar files for offline analysis.
+
+The tables written depend on the tracer:
+
+  callTracer:     calls and logs (the latter requires {"withLog": true})
+  prestateTracer: state_diffs (with post values if {"diffMode": true})
+  others:         results, holding the JSON result of each transaction
+
+All tables start with the block_number, block_hash, tx_index and tx_hash
+columns. Addresses, hashes and binary data are hex encoded, wei amounts are
+decimal strings. See TraceExportConfig in eth/tracers for the full schema.
+
+The node must not be running, as the command opens its database. Networking
+is disabled for the duration of the export. The same functionality is also
+available on a running node via the debug_exportTraces RPC method, which always
+writes into a new temporary directory.`,
+			},
+		},
+	}
+)
+
+// exportTraces traces a range of blocks and writes the results into files.
+func exportTraces(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	start, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid start block: %v", err)
+	}
+	end, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid end block: %v", err)
+	}
+	config := &tracers.TraceExportConfig{
+		Dir:    ctx.String(traceOutputFlag.Name),
+		Format: ctx.String(traceFormatFlag.Name),
+	}
+	if ctx.IsSet(traceTracerFlag.Name) {
+		tracer := ctx.String(traceTracerFlag.Name)
+		config.Tracer = &tracer
+	}
+	if ctx.IsSet(traceTracerConfigFlag.Name) {
+		config.TracerConfig = json.RawMessage(ctx.String(traceTracerConfigFlag.Name))
+		if !json.Valid(config.TracerConfig) {
+			return fmt.Errorf("invalid tracer config: %s", config.TracerConfig)
+		}
+	}
+	reexec := ctx.Uint64(traceReexecFlag.Name)
+	config.Reexec = &reexec
+
+	// Start an offline node, so the chain is properly shut down afterwards
+	cfg := loadBaseConfig(ctx)
+	cfg.Node.P2P.MaxPeers = 0
+	cfg.Node.P2P.NoDiscovery = true
+	cfg.Node.P2P.ListenAddr = ""
+	cfg.Node.IPCPath = ""
+
+	stack, cfg := makeConfigNodeFrom(ctx, cfg)
+	defer stack.Close()
+
+	if cfg.Eth.SyncMode == downloader.LightSync {
+		return fmt.Errorf("trace export is not supported in light mode")
+	}
+	_, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	utils.StartNode(ctx, stack, false)
+
+	files, err := tracers.NewAPI(eth.APIBackend).ExportTraces(ctx.Context, rpc.BlockNumber(start), rpc.BlockNumber(end), config)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		log.Info("Exported traces", "file", file)
+	}
+	return nil
+}
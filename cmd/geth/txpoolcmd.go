@@ -0,0 +1,332 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	replayBlockFlag = &cli.StringFlag{
+		Name:  "block",
+		Usage: "Block number or hash of the chain state to replay against (default = head)",
+	}
+	replaySpeedFlag = &cli.Float64Flag{
+		Name:  "speed",
+		Usage: "Replay speed relative to the recorded timestamps (0 = as fast as possible)",
+	}
+)
+
+var (
+	txpoolCommand = &cli.Command{
+		Name:  "txpool",
+		Usage: "A set of commands operating on the transaction pool",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "replay",
+				Usage:     "Replay a recorded transaction stream into a fresh transaction pool",
+				ArgsUsage: "<file>",
+				Action:    replayTxPool,
+				Flags: flags.Merge([]cli.Flag{
+					replayBlockFlag,
+					replaySpeedFlag,
+					utils.TxPoolLocalsFlag,
+					utils.TxPoolNoLocalsFlag,
+					utils.TxPoolPriceLimitFlag,
+					utils.TxPoolPriceBumpFlag,
+					utils.TxPoolAccountSlotsFlag,
+					utils.TxPoolGlobalSlotsFlag,
+					utils.TxPoolAccountQueueFlag,
+					utils.TxPoolGlobalQueueFlag,
+					utils.TxPoolLifetimeFlag,
+					utils.TxPoolLifecycleFlag,
+					utils.TxPoolLifecycleLimitFlag,
+				}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth txpool replay <file>
+feeds a recorded stream of transactions into a fresh transaction pool on top
+of the chosen chain state, and reports the admissions, rejections, evictions
+and the final pending contents of the pool.
+
+The stream is a JSONL file, each line containing a single transaction either
+in its raw binary form or in its RPC representation (e.g. as delivered by the
+newPendingTransactions subscription), along with its relative arrival time in
+milliseconds:
+
+  {"time": 0, "raw": "0x02f8..."}
+  {"time": 15, "tx": {"type": "0x2", "nonce": "0x0", ...}, "local": true}
+
+The replay is deterministic: every transaction is fully processed by the pool
+before the next one is injected. The pool limits can be tuned through the
+usual --txpool.* flags.`,
+			},
+		},
+	}
+)
+
+// replayRecord is a single transaction of a recorded transaction stream.
+type replayRecord struct {
+	Time  uint64             `json:"time"`  // Arrival time relative to the start of the stream (ms)
+	Raw   hexutil.Bytes      `json:"raw"`   // Binary encoding of the transaction
+	Tx    *types.Transaction `json:"tx"`    // RPC encoding of the transaction, if no raw form is given
+	Local bool               `json:"local"` // Whether to inject the transaction as a local one
+}
+
+// replayChain pins the chain to a single block, hiding any head changes from
+// the transaction pool during a replay.
+type replayChain struct {
+	*core.BlockChain
+	head *types.Block
+}
+
+func (c *replayChain) CurrentBlock() *types.Block { return c.head }
+
+func (c *replayChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// loadReplayRecords parses a recorded transaction stream, ordering the records
+// by their arrival time.
+func loadReplayRecords(path string) ([]*replayRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		records []*replayRecord
+		scanner = bufio.NewScanner(file)
+		line    int
+	)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line++
+		blob := bytes.TrimSpace(scanner.Bytes())
+		if len(blob) == 0 {
+			continue
+		}
+		record := new(replayRecord)
+		if err := json.Unmarshal(blob, record); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(record.Raw) > 0 {
+			record.Tx = new(types.Transaction)
+			if err := record.Tx.UnmarshalBinary(record.Raw); err != nil {
+				return nil, fmt.Errorf("line %d: invalid raw transaction: %v", line, err)
+			}
+		}
+		if record.Tx == nil {
+			return nil, fmt.Errorf("line %d: missing transaction", line)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time < records[j].Time
+	})
+	return records, nil
+}
+
+// counted is a single bucket of a replay report.
+type counted struct {
+	name  string
+	count int
+}
+
+// sortCounts orders the buckets of a report by decreasing count.
+func sortCounts(counts map[string]int) []counted {
+	sorted := make([]counted, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, counted{name, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+func replayTxPool(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need the transaction stream file as the only argument")
+	}
+	records, err := loadReplayRecords(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer db.Close()
+	defer chain.Stop()
+
+	// Pin the chain to the requested state and create a fresh pool on top
+	head := chain.CurrentBlock()
+	if arg := ctx.String(replayBlockFlag.Name); arg != "" {
+		if hashish(arg) {
+			head = chain.GetBlockByHash(common.HexToHash(arg))
+		} else {
+			number, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid block number: %v", err)
+			}
+			head = chain.GetBlockByNumber(number)
+		}
+		if head == nil {
+			return fmt.Errorf("block %s not found", arg)
+		}
+	}
+	if _, err := chain.StateAt(head.Root()); err != nil {
+		return fmt.Errorf("state of block #%d unavailable: %v", head.NumberU64(), err)
+	}
+	config := cfg.Eth.TxPool
+	config.Journal, config.Snapshot = "", ""
+	if config.Lifecycle != "" {
+		config.Lifecycle = stack.ResolvePath(config.Lifecycle)
+	}
+	pool := txpool.NewTxPool(config, chain.Config(), &replayChain{BlockChain: chain, head: head})
+
+	// Track the pool transitions to count the evictions and replacements. The
+	// events themselves are discarded, the pool counts them synchronously.
+	var (
+		events = make(chan txpool.TxLifecycleEvent, 1024)
+		sub    = pool.SubscribeLifecycleEvent(events)
+	)
+	go func() {
+		for {
+			select {
+			case <-events:
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	log.Info("Replaying transaction stream", "transactions", len(records), "block", head.NumberU64(), "hash", head.Hash())
+
+	var (
+		start  = time.Now()
+		speed  = ctx.Float64(replaySpeedFlag.Name)
+		report = &replayReport{total: len(records), rejects: make(map[string]int)}
+		logged = time.Now()
+	)
+	for i, record := range records {
+		if speed > 0 {
+			offset := time.Duration(float64(record.Time) * float64(time.Millisecond) / speed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+		var err error
+		if record.Local {
+			err = pool.AddLocal(record.Tx)
+		} else {
+			err = pool.AddRemotesSync([]*types.Transaction{record.Tx})[0]
+		}
+		if err != nil {
+			report.rejects[err.Error()]++
+		} else {
+			report.admitted++
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Replaying transaction stream", "processed", i+1, "total", len(records), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	report.pending, _ = pool.Content()
+	report.npending, report.nqueued = pool.Stats()
+	pool.Stop()
+
+	stats := pool.LifecycleStats()
+	report.replaced, report.drops = stats.Kinds[txpool.TxLifecycleReplaced], stats.Drops
+
+	report.write(os.Stdout)
+	return nil
+}
+
+// replayReport is the outcome of a transaction stream replay.
+type replayReport struct {
+	total    int            // Number of transactions in the stream
+	admitted int            // Number of transactions accepted by the pool
+	rejects  map[string]int // Number of transactions refused, by error
+	replaced int            // Number of transactions replaced by a better paying one
+	drops    map[string]int // Number of transactions evicted, by reason
+
+	pending  map[common.Address]types.Transactions // Final pending contents of the pool
+	npending int                                   // Final number of pending transactions
+	nqueued  int                                   // Final number of queued transactions
+}
+
+// write prints the report in a human readable form.
+func (r *replayReport) write(w io.Writer) {
+	fmt.Fprintf(w, "Transactions:  %d\n", r.total)
+	fmt.Fprintf(w, "Admitted:      %d\n", r.admitted)
+	fmt.Fprintf(w, "Rejected:      %d\n", r.total-r.admitted)
+	for _, reject := range sortCounts(r.rejects) {
+		fmt.Fprintf(w, "  %-40s %d\n", reject.name, reject.count)
+	}
+	var dropped int
+	for _, count := range r.drops {
+		dropped += count
+	}
+	fmt.Fprintf(w, "Replaced:      %d\n", r.replaced)
+	fmt.Fprintf(w, "Evicted:       %d\n", dropped)
+	for _, drop := range sortCounts(r.drops) {
+		fmt.Fprintf(w, "  %-40s %d\n", drop.name, drop.count)
+	}
+	fmt.Fprintf(w, "Pending:       %d\n", r.npending)
+	fmt.Fprintf(w, "Queued:        %d\n", r.nqueued)
+
+	addrs := make([]common.Address, 0, len(r.pending))
+	for addr := range r.pending {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	for _, addr := range addrs {
+		for _, tx := range r.pending[addr] {
+			fmt.Fprintf(w, "  %s %6d %s\n", addr, tx.Nonce(), tx.Hash())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that recorded transaction streams are parsed from both the raw and
+// the RPC encodings, and are ordered by arrival time.
+func TestLoadReplayRecords(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.HomesteadSigner{}
+
+	var txs []*types.Transaction
+	for i := 0; i < 3; i++ {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+		txs = append(txs, tx)
+	}
+	raw0, _ := txs[0].MarshalBinary()
+	raw2, _ := txs[2].MarshalBinary()
+	rpc1, _ := json.Marshal(txs[1])
+
+	stream := fmt.Sprintf("{\"time\": 20, \"raw\": %q}\n\n{\"time\": 10, \"tx\": %s, \"local\": true}\n{\"time\": 20, \"raw\": %q}\n",
+		hexutil.Encode(raw0), rpc1, hexutil.Encode(raw2))
+
+	path := filepath.Join(t.TempDir(), "stream.jsonl")
+	if err := os.WriteFile(path, []byte(stream), 0644); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+	records, err := loadReplayRecords(path)
+	if err != nil {
+		t.Fatalf("failed to load stream: %v", err)
+	}
+	want := []struct {
+		time  uint64
+		hash  common.Hash
+		local bool
+	}{
+		{10, txs[1].Hash(), true},
+		{20, txs[0].Hash(), false},
+		{20, txs[2].Hash(), false},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("record count mismatch: have %d, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if record.Time != want[i].time || record.Tx.Hash() != want[i].hash || record.Local != want[i].local {
+			t.Errorf("record %d: mismatch: have {%d %x %v}, want {%d %x %v}", i, record.Time, record.Tx.Hash(), record.Local, want[i].time, want[i].hash, want[i].local)
+		}
+	}
+}
+
+// Tests that invalid recorded transaction streams are rejected with the line
+// number of the offending record.
+func TestLoadReplayRecordsInvalid(t *testing.T) {
+	for i, test := range []struct {
+		stream string
+		err    string
+	}{
+		{"{\"time\": 0, \"raw\": \"0x02\"}\n", "line 1: invalid raw transaction"},
+		{"\n{\"time\": 0}\n", "line 2: missing transaction"},
+		{"{\"time\": \"soon\"}\n", "line 1:"},
+	} {
+		path := filepath.Join(t.TempDir(), "stream.jsonl")
+		if err := os.WriteFile(path, []byte(test.stream), 0644); err != nil {
+			t.Fatalf("test %d: failed to write stream: %v", i, err)
+		}
+		if _, err := loadReplayRecords(path); err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want prefix %q", i, err, test.err)
+		}
+	}
+}
+
+// Tests that replay reports sum up the rejections and evictions, and list the
+// pending transactions in a stable order.
+func TestReplayReport(t *testing.T) {
+	var (
+		addr1 = common.Address{0x01}
+		addr2 = common.Address{0x02}
+		tx1   = types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		tx2   = types.NewTransaction(1, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	report := &replayReport{
+		total:    10,
+		admitted: 6,
+		rejects:  map[string]int{"nonce too low": 1, "underpriced": 3},
+		replaced: 2,
+		drops:    map[string]int{"underpriced": 1, "lifetime": 1, "queueLimit": 2},
+		pending: map[common.Address]types.Transactions{
+			addr2: {tx2},
+			addr1: {tx1},
+		},
+		npending: 2,
+		nqueued:  1,
+	}
+	var buf bytes.Buffer
+	report.write(&buf)
+
+	want := strings.Join([]string{
+		"Transactions:  10",
+		"Admitted:      6",
+		"Rejected:      4",
+		fmt.Sprintf("  %-40s %d", "underpriced", 3),
+		fmt.Sprintf("  %-40s %d", "nonce too low", 1),
+		"Replaced:      2",
+		"Evicted:       4",
+		fmt.Sprintf("  %-40s %d", "queueLimit", 2),
+		fmt.Sprintf("  %-40s %d", "lifetime", 1),
+		fmt.Sprintf("  %-40s %d", "underpriced", 1),
+		"Pending:       2",
+		"Queued:        1",
+		fmt.Sprintf("  %s %6d %s", addr1, 0, tx1.Hash()),
+		fmt.Sprintf("  %s %6d %s", addr2, 1, tx2.Hash()),
+	}, "\n") + "\n"
+
+	if have := buf.String(); have != want {
+		t.Errorf("report mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}
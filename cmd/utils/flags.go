@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -446,6 +447,28 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolPrivateLifetimeFlag = &cli.Uint64Flag{
+		Name:     "txpool.privatelifetime",
+		Usage:    "Number of blocks after which non-included private transactions are dropped",
+		Value:    ethconfig.Defaults.TxPool.PrivateLifetime,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolSnapshotFlag = &cli.StringFlag{
+		Name:     "txpool.snapshot",
+		Usage:    "Disk file to persist all pool transactions into across node restarts",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolLifecycleFlag = &cli.StringFlag{
+		Name:     "txpool.lifecycle",
+		Usage:    "Log file to record the lifecycle events of all pool transactions into (JSONL)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolLifecycleLimitFlag = &cli.Uint64Flag{
+		Name:     "txpool.lifecycle.limit",
+		Usage:    "Size in bytes after which the transaction lifecycle log is rotated",
+		Value:    ethconfig.Defaults.TxPool.LifecycleLimit,
+		Category: flags.TxPoolCategory,
+	}
 
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
@@ -574,6 +597,17 @@ var (
 		Value:    ethconfig.Defaults.Miner.NewPayloadTimeout,
 		Category: flags.MinerCategory,
 	}
+	MinerOrderingFlag = &cli.StringFlag{
+		Name:     "miner.ordering",
+		Usage:    "Transaction ordering policy used to fill blocks (" + strings.Join(miner.OrderingPolicies, ", ") + ")",
+		Value:    ethconfig.Defaults.Miner.Ordering,
+		Category: flags.MinerCategory,
+	}
+	MinerOrderingSeedFlag = &cli.Int64Flag{
+		Name:     "miner.ordering.seed",
+		Usage:    "Seed of the random transaction ordering policy",
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -606,6 +640,16 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMTraceFlag = &cli.StringFlag{
+		Name:     "vmtrace",
+		Usage:    "Name of the tracer to run on imported blocks, streaming the results via debug_subscribe(\"traces\")",
+		Category: flags.VMCategory,
+	}
+	VMTraceConfigFlag = &cli.StringFlag{
+		Name:     "vmtrace.config",
+		Usage:    "Tracer configuration of the live block tracer (JSON)",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -626,6 +670,17 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	TraceCacheFlag = &cli.StringFlag{
+		Name:     "trace.cache",
+		Usage:    "Directory to cache block trace results in across calls (relative to the data directory)",
+		Category: flags.APICategory,
+	}
+	TraceCacheSizeFlag = &cli.IntFlag{
+		Name:     "trace.cache.size",
+		Usage:    "Maximum size of the block trace result cache in megabytes",
+		Value:    ethconfig.Defaults.TraceCacheSize,
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -1626,6 +1681,18 @@ func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolPrivateLifetimeFlag.Name) {
+		cfg.PrivateLifetime = ctx.Uint64(TxPoolPrivateLifetimeFlag.Name)
+	}
+	if ctx.IsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.String(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.IsSet(TxPoolLifecycleFlag.Name) {
+		cfg.Lifecycle = ctx.String(TxPoolLifecycleFlag.Name)
+	}
+	if ctx.IsSet(TxPoolLifecycleLimitFlag.Name) {
+		cfg.LifecycleLimit = ctx.Uint64(TxPoolLifecycleLimitFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1678,6 +1745,15 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerOrderingFlag.Name) {
+		cfg.Ordering = ctx.String(MinerOrderingFlag.Name)
+		if _, err := miner.NewTxOrderingPolicy(cfg.Ordering, 0); err != nil {
+			Fatalf("Invalid --%s: %v", MinerOrderingFlag.Name, err)
+		}
+	}
+	if ctx.IsSet(MinerOrderingSeedFlag.Name) {
+		cfg.OrderingSeed = ctx.Int64(MinerOrderingSeedFlag.Name)
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1875,6 +1951,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(VMTraceFlag.Name) {
+		cfg.VMTrace = ctx.String(VMTraceFlag.Name)
+	}
+	if ctx.IsSet(VMTraceConfigFlag.Name) {
+		cfg.VMTraceConfig = ctx.String(VMTraceConfigFlag.Name)
+	}
+	if ctx.IsSet(TraceCacheFlag.Name) {
+		cfg.TraceCache = ctx.String(TraceCacheFlag.Name)
+	}
+	if ctx.IsSet(TraceCacheSizeFlag.Name) {
+		cfg.TraceCacheSize = ctx.Int(TraceCacheSizeFlag.Name)
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -2028,6 +2116,37 @@ func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
 	}
 }
 
+// newTraceCache opens the block trace result cache if configured.
+func newTraceCache(stack *node.Node, cfg *ethconfig.Config) *tracers.TraceCache {
+	if cfg.TraceCache == "" {
+		return nil
+	}
+	cache, err := tracers.NewTraceCache(stack.ResolvePath(cfg.TraceCache), uint64(cfg.TraceCacheSize)*1024*1024)
+	if err != nil {
+		Fatalf("Failed to open the trace cache: %v", err)
+	}
+	return cache
+}
+
+// newLiveTracer attaches the live block tracer to the chain if configured.
+func newLiveTracer(stack *node.Node, backend *eth.Ethereum, cfg *ethconfig.Config) *tracers.LiveTracer {
+	if cfg.VMTrace == "" {
+		return nil
+	}
+	var config json.RawMessage
+	if cfg.VMTraceConfig != "" {
+		config = json.RawMessage(cfg.VMTraceConfig)
+	}
+	tracer, err := tracers.NewLiveTracer(backend.BlockChain(), cfg.VMTrace, config)
+	if err != nil {
+		Fatalf("Failed to create the live block tracer: %v", err)
+	}
+	backend.BlockChain().SetBlockTracer(tracer)
+	stack.RegisterLifecycle(tracer)
+	log.Info("Enabled live block tracing", "tracer", cfg.VMTrace)
+	return tracer
+}
+
 // RegisterEthService adds an Ethereum client to the stack.
 // The second return value is the full node instance, which may be nil if the
 // node is running as a light client.
@@ -2037,7 +2156,10 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		if err != nil {
 			Fatalf("Failed to register the Ethereum service: %v", err)
 		}
-		stack.RegisterAPIs(tracers.APIs(backend.ApiBackend))
+		if cfg.VMTrace != "" {
+			Fatalf("Live block tracing is not supported by light clients")
+		}
+		stack.RegisterAPIs(tracers.APIs(backend.ApiBackend, newTraceCache(stack, cfg), nil))
 		if err := lescatalyst.Register(stack, backend); err != nil {
 			Fatalf("Failed to register the Engine API service: %v", err)
 		}
@@ -2056,7 +2178,7 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 	if err := ethcatalyst.Register(stack, backend); err != nil {
 		Fatalf("Failed to register the Engine API service: %v", err)
 	}
-	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend, newTraceCache(stack, cfg), newLiveTracer(stack, backend, cfg)))
 
 	// Register the auxiliary full-sync tester service in case the sync
 	// target is configured.
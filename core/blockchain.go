@@ -222,6 +222,7 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	forker     *ForkChoice
 	vmConfig   vm.Config
+	tracer     BlockTracer // Optional tracer of the blocks executed during import
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -1701,7 +1702,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 
 		// Process block using the parent state as reference point
 		substart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		receipts, logs, usedGas, err := bc.process(block, statedb)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
@@ -2433,3 +2434,21 @@ func (bc *BlockChain) SetBlockValidatorAndProcessorForTesting(v Validator, p Pro
 	bc.validator = v
 	bc.processor = p
 }
+
+// SetBlockTracer sets the tracer to run alongside the execution of the blocks
+// imported into the chain.
+// This method is unsafe and should only be used before block import starts.
+func (bc *BlockChain) SetBlockTracer(tracer BlockTracer) {
+	bc.tracer = tracer
+}
+
+// process executes the given block on top of the state, tracing the execution
+// with the block tracer if one is set and the processor supports it.
+func (bc *BlockChain) process(block *types.Block, statedb *state.StateDB) (types.Receipts, []*types.Log, uint64, error) {
+	if bc.tracer != nil {
+		if p, ok := bc.processor.(*StateProcessor); ok {
+			return p.ProcessWithTracer(block, statedb, bc.vmConfig, bc.tracer)
+		}
+	}
+	return bc.processor.Process(block, statedb, bc.vmConfig)
+}
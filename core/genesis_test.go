@@ -38,6 +38,64 @@ func TestInvalidCliqueConfig(t *testing.T) {
 	}
 }
 
+// Tests that gas schedules repricing unknown opcodes are rejected upfront, and
+// that the gas schedule can't be changed once blocks have been processed.
+func TestSetupGenesisGasSchedule(t *testing.T) {
+	schedule := func(opcodes map[string]uint64) *params.ChainConfig {
+		config := *params.TestChainConfig
+		config.GasSchedule = &params.GasSchedule{Opcodes: opcodes}
+		return &config
+	}
+	invalid := &Genesis{Config: schedule(map[string]uint64{"ADD": 10, "NOTANOPCODE": 1})}
+	if _, err := invalid.Commit(rawdb.NewMemoryDatabase()); err == nil {
+		t.Fatal("expected error committing genesis with unknown opcode")
+	}
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &Genesis{Config: schedule(map[string]uint64{"ADD": 10})}
+	)
+	genesis.MustCommit(db)
+	if _, _, err := SetupGenesisBlock(db, invalid); err == nil {
+		t.Fatal("expected error setting up genesis with unknown opcode")
+	}
+	// The gas schedule may still be changed at genesis
+	changed := &Genesis{Config: schedule(map[string]uint64{"ADD": 20})}
+	if _, _, err := SetupGenesisBlock(db, changed); err != nil {
+		t.Fatalf("failed to change gas schedule at genesis: %v", err)
+	}
+	bc, err := NewBlockChain(db, nil, changed, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	blocks, _ := GenerateChain(changed.Config, bc.Genesis(), ethash.NewFaker(), db, 2, nil)
+	if n, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	bc.Stop()
+
+	if _, _, err := SetupGenesisBlock(db, changed); err != nil {
+		t.Fatalf("failed to set up unchanged gas schedule: %v", err)
+	}
+	if _, _, err := SetupGenesisBlock(db, genesis); err != errGasScheduleChanged {
+		t.Fatalf("gas schedule change error mismatch: have %v, want %v", err, errGasScheduleChanged)
+	}
+}
+
+func TestSetupGenesisExperimental(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Experimental = map[string]*big.Int{"notanextension": big.NewInt(0)}
+
+	invalid := &Genesis{Config: &config}
+	if _, err := invalid.Commit(rawdb.NewMemoryDatabase()); err == nil {
+		t.Fatal("expected error committing genesis with unknown extension")
+	}
+	db := rawdb.NewMemoryDatabase()
+	(&Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	if _, _, err := SetupGenesisBlock(db, invalid); err == nil {
+		t.Fatal("expected error setting up genesis with unknown extension")
+	}
+}
+
 func TestSetupGenesis(t *testing.T) {
 	var (
 		customghash = common.HexToHash("0x89c99d90b79719238d2645c7642f2c9295246e80775b38cfd162b696817fbd50")
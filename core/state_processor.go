@@ -57,13 +57,21 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	return p.ProcessWithTracer(block, statedb, cfg, nil)
+}
+
+// ProcessWithTracer processes the block like Process, additionally tracing the
+// execution of the block with the given tracer, if any.
+func (p *StateProcessor) ProcessWithTracer(block *types.Block, statedb *state.StateDB, cfg vm.Config, tracer BlockTracer) (receipts types.Receipts, allLogs []*types.Log, gas uint64, err error) {
+	if tracer != nil {
+		tracer.BlockStart(block)
+		defer func() { tracer.BlockEnd(err) }()
+	}
 	var (
-		receipts    types.Receipts
 		usedGas     = new(uint64)
 		header      = block.Header()
 		blockHash   = block.Hash()
 		blockNumber = block.Number()
-		allLogs     []*types.Log
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
 	// Mutate the block and state according to any hard-fork specs
@@ -79,7 +87,18 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
 		statedb.SetTxContext(tx.Hash(), i)
-		receipt, err := applyTransaction(msg, p.config, nil, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
+
+		// Run traced transactions in a dedicated EVM, as the interpreter
+		// captures the config on creation
+		evm := vmenv
+		if tracer != nil {
+			if logger := tracer.TxTracer(i, tx); logger != nil {
+				tcfg := cfg
+				tcfg.Debug, tcfg.Tracer = true, logger
+				evm = vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, tcfg)
+			}
+		}
+		receipt, err := applyTransaction(msg, p.config, nil, gp, statedb, blockNumber, blockHash, tx, usedGas, evm)
 		if err != nil {
 			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
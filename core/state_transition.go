@@ -121,12 +121,42 @@ func (result *ExecutionResult) Revert() []byte {
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
 func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation bool, isHomestead, isEIP2028 bool) (uint64, error) {
+	return IntrinsicGasWithSchedule(data, accessList, isContractCreation, isHomestead, isEIP2028, nil)
+}
+
+// IntrinsicGasWithSchedule computes the 'intrinsic gas' for a message with the
+// given data, with the costs overridden by the gas schedule if non-nil.
+func IntrinsicGasWithSchedule(data []byte, accessList types.AccessList, isContractCreation bool, isHomestead, isEIP2028 bool, schedule *params.GasSchedule) (uint64, error) {
+	var (
+		txGas            = uint64(params.TxGas)
+		txGasCreation    = uint64(params.TxGasContractCreation)
+		zeroGas          = uint64(params.TxDataZeroGas)
+		nonZeroGas       = uint64(params.TxDataNonZeroGasFrontier)
+		accessAddressGas = uint64(params.TxAccessListAddressGas)
+		accessStorageGas = uint64(params.TxAccessListStorageKeyGas)
+	)
+	if isEIP2028 {
+		nonZeroGas = params.TxDataNonZeroGasEIP2028
+	}
+	if schedule != nil {
+		override := func(dst *uint64, src *uint64) {
+			if src != nil {
+				*dst = *src
+			}
+		}
+		override(&txGas, schedule.TxGas)
+		override(&txGasCreation, schedule.TxGasContractCreation)
+		override(&zeroGas, schedule.TxDataZeroGas)
+		override(&nonZeroGas, schedule.TxDataNonZeroGas)
+		override(&accessAddressGas, schedule.TxAccessListAddressGas)
+		override(&accessStorageGas, schedule.TxAccessListStorageKeyGas)
+	}
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if isContractCreation && isHomestead {
-		gas = params.TxGasContractCreation
+		gas = txGasCreation
 	} else {
-		gas = params.TxGas
+		gas = txGas
 	}
 	// Bump the required gas by the amount of transactional data
 	if len(data) > 0 {
@@ -138,24 +168,20 @@ func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation b
 			}
 		}
 		// Make sure we don't exceed uint64 for all data combinations
-		nonZeroGas := params.TxDataNonZeroGasFrontier
-		if isEIP2028 {
-			nonZeroGas = params.TxDataNonZeroGasEIP2028
-		}
-		if (math.MaxUint64-gas)/nonZeroGas < nz {
+		if nonZeroGas > 0 && (math.MaxUint64-gas)/nonZeroGas < nz {
 			return 0, ErrGasUintOverflow
 		}
 		gas += nz * nonZeroGas
 
 		z := uint64(len(data)) - nz
-		if (math.MaxUint64-gas)/params.TxDataZeroGas < z {
+		if zeroGas > 0 && (math.MaxUint64-gas)/zeroGas < z {
 			return 0, ErrGasUintOverflow
 		}
-		gas += z * params.TxDataZeroGas
+		gas += z * zeroGas
 	}
 	if accessList != nil {
-		gas += uint64(len(accessList)) * params.TxAccessListAddressGas
-		gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+		gas += uint64(len(accessList)) * accessAddressGas
+		gas += uint64(accessList.StorageKeys()) * accessStorageGas
 	}
 	return gas, nil
 }
@@ -305,7 +331,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	)
 
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
-	gas, err := IntrinsicGas(st.data, st.msg.AccessList(), contractCreation, rules.IsHomestead, rules.IsIstanbul)
+	gas, err := IntrinsicGasWithSchedule(st.data, st.msg.AccessList(), contractCreation, rules.IsHomestead, rules.IsIstanbul, st.evm.ChainConfig().GasSchedule)
 	if err != nil {
 		return nil, err
 	}
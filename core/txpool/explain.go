@@ -0,0 +1,164 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrNonceGap is reported for a transaction if a lower nonce of its sender is
+	// missing from the pool.
+	ErrNonceGap = errors.New("nonce gap")
+
+	// ErrNonceBlocked is reported for a transaction if a lower nonce of its sender
+	// is itself not executable or not includable.
+	ErrNonceBlocked = errors.New("blocked by lower nonce")
+
+	// ErrAccountSlots is reported for a transaction if its sender uses more than
+	// the guaranteed slots of the pool while the pool is over its limits, making
+	// it first in line for eviction.
+	ErrAccountSlots = errors.New("exceeds account slot limit")
+)
+
+// TxExplanation is the diagnosis of a single pooled transaction, detailing why it
+// is not executable or not being included.
+type TxExplanation struct {
+	Hash    common.Hash    `json:"hash"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	Status  string         `json:"status"`            // Either "pending" or "queued"
+	Reasons []string       `json:"reasons,omitempty"` // Empty if the transaction is includable
+}
+
+// AccountExplanation is the diagnosis of all the pooled transactions of a single
+// account.
+type AccountExplanation struct {
+	Nonce        hexutil.Uint64   `json:"nonce"`        // Nonce of the account in the current state
+	PendingNonce hexutil.Uint64   `json:"pendingNonce"` // Next nonce after the executable transactions
+	Balance      *hexutil.Big     `json:"balance"`      // Balance of the account in the current state
+	Local        bool             `json:"local"`        // Whether the account is exempt from pricing limits
+	Gaps         []hexutil.Uint64 `json:"gaps"`         // Nonces missing before the highest pooled one
+	Transactions []*TxExplanation `json:"transactions"` // Pooled transactions in nonce order
+}
+
+// Explain diagnoses the pooled transactions of an account, reporting any nonce
+// gaps and the reasons each transaction is not executable or not being included,
+// based on the same checks the pool uses to admit, promote and demote them.
+func (pool *TxPool) Explain(addr common.Address) *AccountExplanation {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	// The state database mutates even on reads, serialize concurrent explanations
+	pool.explainMu.Lock()
+	defer pool.explainMu.Unlock()
+
+	var (
+		nonce   = pool.currentState.GetNonce(addr)
+		baseFee = pool.priced.urgent.baseFee
+		local   = pool.locals.contains(addr)
+	)
+	explanation := &AccountExplanation{
+		Nonce:        hexutil.Uint64(nonce),
+		PendingNonce: hexutil.Uint64(pool.pendingNonces.get(addr)),
+		Balance:      (*hexutil.Big)(pool.currentState.GetBalance(addr)),
+		Local:        local,
+		Gaps:         []hexutil.Uint64{},
+		Transactions: []*TxExplanation{},
+	}
+	var txs types.Transactions
+	if list := pool.pending[addr]; list != nil {
+		txs = append(txs, list.Flatten()...)
+	}
+	pending := len(txs)
+	if list := pool.queue[addr]; list != nil {
+		txs = append(txs, list.Flatten()...)
+	}
+	// Pending transactions above the account slots are only evicted if the pool
+	// is over its global limit, see truncatePending
+	var pressure bool
+	if !local {
+		var total uint64
+		for _, list := range pool.pending {
+			total += uint64(list.Len())
+		}
+		pressure = total > pool.config.GlobalSlots
+	}
+	// Walk the transactions in nonce order, any stuck one blocks all above it
+	var (
+		next  = nonce
+		stuck = false
+	)
+	for i, tx := range txs {
+		entry := &TxExplanation{
+			Hash:   tx.Hash(),
+			Nonce:  hexutil.Uint64(tx.Nonce()),
+			Status: "pending",
+		}
+		if i >= pending {
+			entry.Status = "queued"
+		}
+		report := func(err error) {
+			for _, reason := range entry.Reasons {
+				if reason == err.Error() {
+					return
+				}
+			}
+			entry.Reasons = append(entry.Reasons, err.Error())
+		}
+		// Report the missing nonces before the transaction
+		if tx.Nonce() > next {
+			for n := next; n < tx.Nonce(); n++ {
+				explanation.Gaps = append(explanation.Gaps, hexutil.Uint64(n))
+			}
+			report(ErrNonceGap)
+		}
+		next = tx.Nonce() + 1
+
+		// Rerun the admission checks against the current state (funds, gas limit,
+		// minimum tip), then the checks applied by the miner against the base fee
+		if err := pool.validateTx(tx, local); err != nil {
+			report(err)
+		}
+		if baseFee != nil && tx.GasFeeCapIntCmp(baseFee) < 0 {
+			report(core.ErrFeeCapTooLow)
+		}
+		if !local && tx.EffectiveGasTipIntCmp(pool.gasPrice, baseFee) < 0 {
+			report(ErrUnderpriced)
+		}
+		if len(entry.Reasons) > 0 {
+			stuck = true
+		} else if stuck {
+			report(ErrNonceBlocked)
+		}
+		// Transactions above the account limits are discarded first on overflow
+		if !local {
+			if i < pending && uint64(i) >= pool.config.AccountSlots && pressure {
+				report(ErrAccountSlots)
+			}
+			if i >= pending && uint64(i-pending) >= pool.config.AccountQueue {
+				report(ErrAccountSlots)
+			}
+		}
+		explanation.Transactions = append(explanation.Transactions, entry)
+	}
+	return explanation
+}
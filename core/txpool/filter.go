@@ -0,0 +1,146 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrAdmissionDenied is returned if a transaction is refused by one of the
+// admission filters of the pool.
+var ErrAdmissionDenied = errors.New("denied by admission policy")
+
+// AdmissionFilter is a custom policy consulted when a transaction passed all the
+// basic validity checks of the pool, before it's inserted.
+type AdmissionFilter interface {
+	// Admit decides whether a transaction is accepted into the pool. A non-nil
+	// error rejects the transaction, otherwise the optional tag is recorded for
+	// the transaction and reported along its lifecycle events.
+	Admit(tx *types.Transaction, from common.Address, local bool) (tag string, err error)
+}
+
+// AdmissionConfig are the configuration parameters of the built-in admission
+// filters of the transaction pool.
+type AdmissionConfig struct {
+	Deny        []common.Address // Senders and recipients whose transactions are rejected
+	Allow       []common.Address // Senders whose transactions are exclusively accepted (empty = all)
+	MaxCalldata uint64           // Maximum size of the transaction input data (0 = unlimited)
+	NoCreate    bool             // Whether contract creation transactions are rejected
+}
+
+// filters assembles the built-in admission filters enabled by the config.
+func (config *AdmissionConfig) filters() []AdmissionFilter {
+	var filters []AdmissionFilter
+	if len(config.Deny) > 0 {
+		filters = append(filters, NewDenyListFilter(config.Deny))
+	}
+	if len(config.Allow) > 0 {
+		filters = append(filters, NewAllowListFilter(config.Allow))
+	}
+	if config.MaxCalldata > 0 {
+		filters = append(filters, NewCalldataFilter(config.MaxCalldata))
+	}
+	if config.NoCreate {
+		filters = append(filters, NewNoCreateFilter())
+	}
+	return filters
+}
+
+// denyListFilter rejects all transactions sent from or to a set of addresses.
+type denyListFilter map[common.Address]struct{}
+
+// NewDenyListFilter creates an admission filter rejecting all transactions sent
+// from or to any of the given addresses.
+func NewDenyListFilter(addrs []common.Address) AdmissionFilter {
+	filter := make(denyListFilter)
+	for _, addr := range addrs {
+		filter[addr] = struct{}{}
+	}
+	return filter
+}
+
+// Admit implements AdmissionFilter, rejecting transactions of denied accounts.
+func (f denyListFilter) Admit(tx *types.Transaction, from common.Address, local bool) (string, error) {
+	if _, ok := f[from]; ok {
+		return "", fmt.Errorf("%w: sender %v denied", ErrAdmissionDenied, from)
+	}
+	if to := tx.To(); to != nil {
+		if _, ok := f[*to]; ok {
+			return "", fmt.Errorf("%w: recipient %v denied", ErrAdmissionDenied, *to)
+		}
+	}
+	return "", nil
+}
+
+// allowListFilter rejects all transactions not sent from a set of addresses.
+type allowListFilter map[common.Address]struct{}
+
+// NewAllowListFilter creates an admission filter rejecting all transactions not
+// sent from any of the given addresses.
+func NewAllowListFilter(addrs []common.Address) AdmissionFilter {
+	filter := make(allowListFilter)
+	for _, addr := range addrs {
+		filter[addr] = struct{}{}
+	}
+	return filter
+}
+
+// Admit implements AdmissionFilter, rejecting transactions of unknown senders.
+func (f allowListFilter) Admit(tx *types.Transaction, from common.Address, local bool) (string, error) {
+	if _, ok := f[from]; !ok {
+		return "", fmt.Errorf("%w: sender %v not allowed", ErrAdmissionDenied, from)
+	}
+	return "", nil
+}
+
+// calldataFilter rejects all transactions with input data above a size limit.
+type calldataFilter uint64
+
+// NewCalldataFilter creates an admission filter rejecting all transactions with
+// more input data than the given number of bytes.
+func NewCalldataFilter(limit uint64) AdmissionFilter {
+	return calldataFilter(limit)
+}
+
+// Admit implements AdmissionFilter, rejecting transactions with oversized input.
+func (f calldataFilter) Admit(tx *types.Transaction, from common.Address, local bool) (string, error) {
+	if size := uint64(len(tx.Data())); size > uint64(f) {
+		return "", fmt.Errorf("%w: calldata size %d above limit %d", ErrAdmissionDenied, size, uint64(f))
+	}
+	return "", nil
+}
+
+// noCreateFilter rejects all contract creation transactions.
+type noCreateFilter struct{}
+
+// NewNoCreateFilter creates an admission filter rejecting all contract creation
+// transactions.
+func NewNoCreateFilter() AdmissionFilter {
+	return noCreateFilter{}
+}
+
+// Admit implements AdmissionFilter, rejecting contract creations.
+func (noCreateFilter) Admit(tx *types.Transaction, from common.Address, local bool) (string, error) {
+	if tx.To() == nil {
+		return "", fmt.Errorf("%w: contract creation", ErrAdmissionDenied)
+	}
+	return "", nil
+}
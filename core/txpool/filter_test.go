@@ -0,0 +1,107 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// tagFilter is an admission filter tagging all transactions with a fixed label.
+type tagFilter string
+
+func (f tagFilter) Admit(tx *types.Transaction, from common.Address, local bool) (string, error) {
+	return string(f), nil
+}
+
+// Tests that the built-in and custom admission filters reject or tag the pool
+// transactions as configured.
+func TestAdmissionFilters(t *testing.T) {
+	t.Parallel()
+
+	var (
+		denied, _  = crypto.GenerateKey()
+		allowed, _ = crypto.GenerateKey()
+		target     = common.Address{0xde, 0xad}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Admission = AdmissionConfig{
+		Deny:        []common.Address{crypto.PubkeyToAddress(denied.PublicKey), target},
+		MaxCalldata: 16,
+		NoCreate:    true,
+	}
+	config.Filters = []AdmissionFilter{tagFilter("checked")}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	for _, key := range []*ecdsa.PrivateKey{denied, allowed} {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	signer := types.HomesteadSigner{}
+	sign := func(key *ecdsa.PrivateKey, nonce uint64, to *common.Address, data []byte) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce, To: to, Gas: 100000, GasPrice: big.NewInt(1), Data: data}), signer, key)
+		return tx
+	}
+	tests := []struct {
+		tx     *types.Transaction
+		denied bool
+	}{
+		{sign(denied, 0, &common.Address{0x01}, nil), true},               // denied sender
+		{sign(allowed, 0, &target, nil), true},                            // denied recipient
+		{sign(allowed, 0, &common.Address{0x01}, make([]byte, 17)), true}, // oversized calldata
+		{sign(allowed, 0, nil, nil), true},                                // contract creation
+		{sign(allowed, 0, &common.Address{0x01}, make([]byte, 16)), false},
+	}
+	for i, tt := range tests {
+		err := pool.AddRemotesSync([]*types.Transaction{tt.tx})[0]
+		if tt.denied && !errors.Is(err, ErrAdmissionDenied) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrAdmissionDenied)
+		}
+		if !tt.denied && err != nil {
+			t.Errorf("test %d: failed to add transaction: %v", i, err)
+		}
+	}
+	tx := tests[len(tests)-1].tx
+	if tags := pool.all.Tags(tx.Hash()); len(tags) != 1 || tags[0] != "checked" {
+		t.Errorf("tags mismatch: have %v, want %v", tags, []string{"checked"})
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("pool contents mismatch: have %d/%d, want %d/%d", pending, queued, 1, 0)
+	}
+	// Ensure the allow list rejects everyone else
+	allow := NewAllowListFilter([]common.Address{crypto.PubkeyToAddress(allowed.PublicKey)})
+	if _, err := allow.Admit(tx, crypto.PubkeyToAddress(allowed.PublicKey), false); err != nil {
+		t.Errorf("allowed sender rejected: %v", err)
+	}
+	if _, err := allow.Admit(tx, crypto.PubkeyToAddress(denied.PublicKey), false); !errors.Is(err, ErrAdmissionDenied) {
+		t.Errorf("unknown sender error mismatch: have %v, want %v", err, ErrAdmissionDenied)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// lifecycleChanSize is the number of lifecycle events buffered between the
+	// pool and the background delivery loop before events start being dropped.
+	lifecycleChanSize = 8192
+
+	// lifecycleLogBackups is the number of rotated lifecycle log files kept
+	// besides the active one.
+	lifecycleLogBackups = 5
+
+	// lifecycleInclusionDepth is the maximum number of blocks walked on a pool
+	// reset to find out which transactions were included in the chain.
+	lifecycleInclusionDepth = 64
+)
+
+// Well known origins of pool transactions, besides remote peer identifiers.
+const (
+	OriginLocal   = "local"   // Transaction submitted through the local APIs
+	OriginReorg   = "reorg"   // Transaction reinjected from a block reorged out
+	OriginPrivate = "private" // Transaction submitted locally, never to be propagated
+)
+
+var (
+	// lifecycleDropMeter counts the lifecycle events dropped due to a subscriber
+	// or the log writer not keeping up with the pool.
+	lifecycleDropMeter = metrics.NewRegisteredMeter("txpool/lifecycle/drop", nil)
+)
+
+// TxLifecycleKind is the type of a transition a transaction went through in
+// the pool.
+type TxLifecycleKind string
+
+const (
+	TxLifecycleAddedLocal  TxLifecycleKind = "addedLocal"  // Accepted into the pool from the local APIs
+	TxLifecycleAddedRemote TxLifecycleKind = "addedRemote" // Accepted into the pool from the network
+	TxLifecycleRejected    TxLifecycleKind = "rejected"    // Refused admission into the pool
+	TxLifecycleQueued      TxLifecycleKind = "queued"      // Inserted into the non-executable queue
+	TxLifecyclePromoted    TxLifecycleKind = "promoted"    // Moved into the executable pending set
+	TxLifecycleDemoted     TxLifecycleKind = "demoted"     // Moved back from pending into the queue
+	TxLifecycleReplaced    TxLifecycleKind = "replaced"    // Replaced by a transaction with the same nonce
+	TxLifecycleDropped     TxLifecycleKind = "dropped"     // Removed from the pool without inclusion
+	TxLifecycleIncluded    TxLifecycleKind = "included"    // Removed from the pool due to block inclusion
+)
+
+// Reasons for transactions being dropped from the pool.
+const (
+	DropUnderpriced     = "underpriced"     // Evicted in favour of a better paying transaction
+	DropReplacementLost = "replacementLost" // Lost against an already pending transaction with the same nonce
+	DropNonceTooLow     = "nonceTooLow"     // Nonce already used by a transaction included in the chain
+	DropUnpayable       = "unpayable"       // Insufficient balance or gas above the block gas limit
+	DropAccountLimit    = "accountLimit"    // Exceeding the per account queue limit
+	DropPendingLimit    = "pendingLimit"    // Exceeding the global pending limit
+	DropQueueLimit      = "queueLimit"      // Exceeding the global queue limit
+	DropLifetime        = "lifetime"        // Queued for longer than the allowed lifetime
+	DropPriceLimit      = "priceLimit"      // Tip below the raised minimum gas price
+	DropPrivateExpired  = "privateExpired"  // Private transaction not included in time
+)
+
+// TxLifecycleEvent is a single transition of a transaction in the pool.
+type TxLifecycleEvent struct {
+	Kind       TxLifecycleKind `json:"kind"`
+	Time       time.Time       `json:"time"`
+	Hash       common.Hash     `json:"hash"`
+	From       common.Address  `json:"from"`
+	Nonce      hexutil.Uint64  `json:"nonce"`
+	Arrival    time.Time       `json:"arrival"`
+	Origin     string          `json:"origin,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	ReplacedBy *common.Hash    `json:"replacedBy,omitempty"`
+	Block      *hexutil.Uint64 `json:"block,omitempty"`
+}
+
+// TxLifecycleStats counts the lifecycle events recorded by the pool.
+type TxLifecycleStats struct {
+	Kinds map[TxLifecycleKind]int // Number of events by kind
+	Drops map[string]int          // Number of dropped transactions by reason
+}
+
+// txLifecycle delivers the lifecycle events of the pool to the subscribers and
+// the optional log file without ever blocking the pool itself.
+type txLifecycle struct {
+	feed   event.Feed
+	scope  event.SubscriptionScope
+	events chan *TxLifecycleEvent
+	log    *lifecycleLog // Optional log file to write all events into
+
+	stats     TxLifecycleStats // Events recorded, counted even if not delivered
+	statsLock sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newTxLifecycle creates a lifecycle event tracker, writing the events into a
+// rotating log file too if a path is specified.
+func newTxLifecycle(path string, limit uint64) *txLifecycle {
+	l := &txLifecycle{
+		events: make(chan *TxLifecycleEvent, lifecycleChanSize),
+		stats: TxLifecycleStats{
+			Kinds: make(map[TxLifecycleKind]int),
+			Drops: make(map[string]int),
+		},
+		quit: make(chan struct{}),
+	}
+	if path != "" {
+		l.log = &lifecycleLog{path: path, limit: limit}
+		if err := l.log.open(); err != nil {
+			log.Warn("Failed to open transaction lifecycle log", "path", path, "err", err)
+			l.log = nil
+		}
+	}
+	l.wg.Add(1)
+	go l.loop()
+	return l
+}
+
+// active reports whether anyone is interested in the lifecycle events. It is
+// used to avoid assembling events nobody would consume.
+func (l *txLifecycle) active() bool {
+	return l.log != nil || l.scope.Count() > 0
+}
+
+// record counts an event and queues it for delivery. If the delivery loop is
+// lagging behind, the event is dropped instead of blocking the pool, but it is
+// still accounted for in the stats.
+func (l *txLifecycle) record(ev *TxLifecycleEvent) {
+	l.statsLock.Lock()
+	l.stats.Kinds[ev.Kind]++
+	if ev.Kind == TxLifecycleDropped {
+		l.stats.Drops[ev.Reason]++
+	}
+	l.statsLock.Unlock()
+
+	select {
+	case l.events <- ev:
+	default:
+		lifecycleDropMeter.Mark(1)
+	}
+}
+
+// counts returns a copy of the event counters.
+func (l *txLifecycle) counts() TxLifecycleStats {
+	l.statsLock.Lock()
+	defer l.statsLock.Unlock()
+
+	stats := TxLifecycleStats{
+		Kinds: make(map[TxLifecycleKind]int, len(l.stats.Kinds)),
+		Drops: make(map[string]int, len(l.stats.Drops)),
+	}
+	for kind, count := range l.stats.Kinds {
+		stats.Kinds[kind] = count
+	}
+	for reason, count := range l.stats.Drops {
+		stats.Drops[reason] = count
+	}
+	return stats
+}
+
+// subscribe registers a subscription for the lifecycle events.
+func (l *txLifecycle) subscribe(ch chan<- TxLifecycleEvent) event.Subscription {
+	return l.scope.Track(l.feed.Subscribe(ch))
+}
+
+// loop delivers the recorded events to the subscribers and the log file. On
+// termination, the events still queued are delivered before returning.
+func (l *txLifecycle) loop() {
+	defer l.wg.Done()
+
+	for {
+		select {
+		case ev := <-l.events:
+			l.deliver(ev)
+		case <-l.quit:
+			for {
+				select {
+				case ev := <-l.events:
+					l.deliver(ev)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a single event to the subscribers and appends it to the log file.
+func (l *txLifecycle) deliver(ev *TxLifecycleEvent) {
+	l.feed.Send(*ev)
+	if l.log != nil {
+		if err := l.log.write(ev); err != nil {
+			log.Warn("Failed to write transaction lifecycle log", "err", err)
+		}
+		// Flush whenever the pool is idle to keep the file tail-able
+		if len(l.events) == 0 {
+			if err := l.log.flush(); err != nil {
+				log.Warn("Failed to flush transaction lifecycle log", "err", err)
+			}
+		}
+	}
+}
+
+// close terminates the delivery loop once the queued events are delivered, and
+// flushes the log file.
+func (l *txLifecycle) close() {
+	close(l.quit)
+	l.wg.Wait()
+	l.scope.Close()
+
+	if l.log != nil {
+		if err := l.log.close(); err != nil {
+			log.Warn("Failed to close transaction lifecycle log", "err", err)
+		}
+	}
+}
+
+// lifecycleLog is a size limited, rotating JSONL file of lifecycle events.
+type lifecycleLog struct {
+	path  string // Filesystem path to write the events into
+	limit uint64 // Size in bytes after which the file is rotated (0 = never)
+
+	file   *os.File
+	writer *bufio.Writer
+	size   uint64
+}
+
+// open opens (or creates) the active log file for appending.
+func (l *lifecycleLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.writer, l.size = file, bufio.NewWriter(file), uint64(stat.Size())
+	return nil
+}
+
+// write appends a single event to the log, rotating the files if the size
+// limit is exceeded.
+func (l *lifecycleLog) write(ev *TxLifecycleEvent) error {
+	if l.file == nil {
+		if err := l.open(); err != nil { // Previous rotation might have failed
+			return err
+		}
+	}
+	blob, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if l.limit > 0 && l.size+uint64(len(blob))+1 > l.limit && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err := l.writer.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	l.size += uint64(len(blob)) + 1
+	return nil
+}
+
+// flush writes any buffered events into the active log file.
+func (l *lifecycleLog) flush() error {
+	if l.writer == nil {
+		return nil
+	}
+	return l.writer.Flush()
+}
+
+// rotate closes the active log file, shifts the backups by one and opens a
+// fresh file to write into.
+func (l *lifecycleLog) rotate() error {
+	if err := l.close(); err != nil {
+		return err
+	}
+	for i := lifecycleLogBackups - 1; i > 0; i-- {
+		from, to := fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, to); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// close flushes and closes the active log file.
+func (l *lifecycleLog) close() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.writer.Flush()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file, l.writer = nil, nil
+	return err
+}
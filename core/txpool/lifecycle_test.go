@@ -0,0 +1,259 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// expectedEvent is the subset of a lifecycle event checked by the tests.
+type expectedEvent struct {
+	kind   TxLifecycleKind
+	tx     *types.Transaction
+	origin string
+}
+
+// checkLifecycleEvents waits for the given lifecycle events and verifies them.
+func checkLifecycleEvents(t *testing.T, events chan TxLifecycleEvent, want []expectedEvent) []TxLifecycleEvent {
+	t.Helper()
+
+	var have []TxLifecycleEvent
+	for i, exp := range want {
+		select {
+		case ev := <-events:
+			if ev.Kind != exp.kind || ev.Hash != exp.tx.Hash() || ev.Origin != exp.origin {
+				t.Fatalf("event %d: mismatch: have {%s %x %q}, want {%s %x %q}", i, ev.Kind, ev.Hash, ev.Origin, exp.kind, exp.tx.Hash(), exp.origin)
+			}
+			have = append(have, ev)
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout waiting for %s", i, exp.kind)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event: %s %x", ev.Kind, ev.Hash)
+	case <-time.After(50 * time.Millisecond):
+	}
+	return have
+}
+
+// Tests that the transitions of transactions in the pool are reported along
+// with the peer they originate from.
+func TestLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+
+	events := make(chan TxLifecycleEvent, 32)
+	sub := pool.SubscribeLifecycleEvent(events)
+	defer sub.Unsubscribe()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+
+	// Add a gapped transaction first, and fill the gap afterwards
+	tx0, tx1 := transaction(0, 100000, key), transaction(1, 100000, key)
+	if errs := pool.addTxs([]*types.Transaction{tx1}, "peer", false, true); errs[0] != nil {
+		t.Fatalf("failed to add gapped transaction: %v", errs[0])
+	}
+	checkLifecycleEvents(t, events, []expectedEvent{
+		{TxLifecycleAddedRemote, tx1, "peer"},
+		{TxLifecycleQueued, tx1, "peer"},
+	})
+	if errs := pool.addTxs([]*types.Transaction{tx0}, "peer", false, true); errs[0] != nil {
+		t.Fatalf("failed to add gap filling transaction: %v", errs[0])
+	}
+	checkLifecycleEvents(t, events, []expectedEvent{
+		{TxLifecycleAddedRemote, tx0, "peer"},
+		{TxLifecycleQueued, tx0, "peer"},
+		{TxLifecyclePromoted, tx0, "peer"},
+		{TxLifecyclePromoted, tx1, "peer"},
+	})
+	// Replace the first pending transaction with a local one
+	replacement := pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.AddLocal(replacement); err != nil {
+		t.Fatalf("failed to add replacement transaction: %v", err)
+	}
+	replaced := checkLifecycleEvents(t, events, []expectedEvent{
+		{TxLifecycleReplaced, tx0, "peer"},
+		{TxLifecycleAddedLocal, replacement, OriginLocal},
+		{TxLifecyclePromoted, replacement, OriginLocal},
+	})
+	if by := replaced[0].ReplacedBy; by == nil || *by != replacement.Hash() {
+		t.Errorf("replacement mismatch: have %v, want %x", by, replacement.Hash())
+	}
+	// Raise the minimum price, dropping the cheap remote transaction
+	pool.SetGasPrice(big.NewInt(10))
+	dropped := checkLifecycleEvents(t, events, []expectedEvent{
+		{TxLifecycleDropped, tx1, "peer"},
+	})
+	if dropped[0].Reason != DropPriceLimit {
+		t.Errorf("drop reason mismatch: have %q, want %q", dropped[0].Reason, DropPriceLimit)
+	}
+	// Try to add an underpriced transaction and ensure it's reported
+	underpriced := pricedTransaction(2, 100000, big.NewInt(1), key)
+	if errs := pool.addTxs([]*types.Transaction{underpriced}, "other", false, true); errs[0] == nil {
+		t.Fatalf("underpriced transaction accepted")
+	}
+	rejected := checkLifecycleEvents(t, events, []expectedEvent{
+		{TxLifecycleRejected, underpriced, "other"},
+	})
+	if rejected[0].Reason != ErrUnderpriced.Error() {
+		t.Errorf("rejection reason mismatch: have %q, want %q", rejected[0].Reason, ErrUnderpriced.Error())
+	}
+	// Ensure the stats account for all the events
+	stats := pool.LifecycleStats()
+	if have := stats.Kinds[TxLifecyclePromoted]; have != 3 {
+		t.Errorf("promotion count mismatch: have %d, want %d", have, 3)
+	}
+	if have := stats.Kinds[TxLifecycleReplaced]; have != 1 {
+		t.Errorf("replacement count mismatch: have %d, want %d", have, 1)
+	}
+	if have := stats.Drops[DropPriceLimit]; have != 1 {
+		t.Errorf("drop count mismatch: have %d, want %d", have, 1)
+	}
+}
+
+// Tests that the lifecycle stats count the events even if they are dropped due
+// to the delivery lagging behind.
+func TestLifecycleStatsLagging(t *testing.T) {
+	t.Parallel()
+
+	// Create a tracker without a delivery loop, buffering a single event
+	l := &txLifecycle{
+		events: make(chan *TxLifecycleEvent, 1),
+		stats: TxLifecycleStats{
+			Kinds: make(map[TxLifecycleKind]int),
+			Drops: make(map[string]int),
+		},
+	}
+	for i := 0; i < 10; i++ {
+		l.record(&TxLifecycleEvent{Kind: TxLifecycleAddedRemote, Hash: common.Hash{byte(i)}})
+		l.record(&TxLifecycleEvent{Kind: TxLifecycleDropped, Hash: common.Hash{byte(i)}, Reason: DropUnderpriced})
+	}
+	if len(l.events) != 1 {
+		t.Fatalf("buffered event count mismatch: have %d, want %d", len(l.events), 1)
+	}
+	stats := l.counts()
+	if have := stats.Kinds[TxLifecycleAddedRemote]; have != 10 {
+		t.Errorf("addition count mismatch: have %d, want %d", have, 10)
+	}
+	if have := stats.Kinds[TxLifecycleDropped]; have != 10 {
+		t.Errorf("drop count mismatch: have %d, want %d", have, 10)
+	}
+	if have := stats.Drops[DropUnderpriced]; have != 10 {
+		t.Errorf("underpriced count mismatch: have %d, want %d", have, 10)
+	}
+}
+
+// Tests that the lifecycle log is rotated after reaching its size limit.
+func TestLifecycleLogRotation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "lifecycle.jsonl")
+	l := &lifecycleLog{path: path, limit: 1024}
+	if err := l.open(); err != nil {
+		t.Fatalf("failed to open lifecycle log: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		ev := &TxLifecycleEvent{Kind: TxLifecycleAddedRemote, Hash: common.Hash{byte(i)}, Origin: "peer"}
+		if err := l.write(ev); err != nil {
+			t.Fatalf("failed to write event %d: %v", i, err)
+		}
+	}
+	if err := l.close(); err != nil {
+		t.Fatalf("failed to close lifecycle log: %v", err)
+	}
+	// Ensure all files are within limits and contain valid events
+	var total int
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		stat, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("missing log file %s: %v", file, err)
+		}
+		if stat.Size() > 1024 {
+			t.Errorf("log file %s too large: %d bytes", file, stat.Size())
+		}
+		f, _ := os.Open(file)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var ev TxLifecycleEvent
+			if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+				t.Errorf("invalid event in %s: %v", file, err)
+			}
+			total++
+		}
+		f.Close()
+	}
+	if total > 32 || total == 0 {
+		t.Errorf("event count mismatch: have %d, want at most 32", total)
+	}
+}
+
+// Tests that the events still queued when the tracker is closed are delivered
+// before the log file is closed.
+func TestLifecycleCloseDrains(t *testing.T) {
+	t.Parallel()
+
+	// Create a tracker without a delivery loop, queueing events up front
+	path := filepath.Join(t.TempDir(), "lifecycle.jsonl")
+	l := &txLifecycle{
+		events: make(chan *TxLifecycleEvent, 16),
+		stats: TxLifecycleStats{
+			Kinds: make(map[TxLifecycleKind]int),
+			Drops: make(map[string]int),
+		},
+		quit: make(chan struct{}),
+		log:  &lifecycleLog{path: path},
+	}
+	if err := l.log.open(); err != nil {
+		t.Fatalf("failed to open lifecycle log: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		l.record(&TxLifecycleEvent{Kind: TxLifecycleAddedRemote, Hash: common.Hash{byte(i)}})
+	}
+	l.wg.Add(1)
+	go l.loop()
+	l.close()
+
+	if l.log.file != nil || l.log.writer != nil {
+		t.Errorf("lifecycle log left open")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open lifecycle log: %v", err)
+	}
+	defer f.Close()
+
+	var count int
+	for scanner := bufio.NewScanner(f); scanner.Scan(); count++ {
+	}
+	if count != 16 {
+		t.Errorf("logged event count mismatch: have %d, want %d", count, 16)
+	}
+}
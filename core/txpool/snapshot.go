@@ -0,0 +1,130 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// snapshotEntry is a single transaction persisted in the pool snapshot, along
+// with the metadata needed to restore it the way it was originally received.
+type snapshotEntry struct {
+	Tx      *types.Transaction
+	Local   bool   // Whether the transaction was tracked as a local one
+	Arrival uint64 // Time the transaction was first seen, in nanoseconds
+	Origin  string // Where the transaction was received from
+}
+
+// snapshot is a full dump of the pool contents, pending and queued, local and
+// remote, with the aim of restoring the same pool across node restarts.
+type snapshot struct {
+	path string // Filesystem path to store the transactions at
+}
+
+// newTxSnapshot creates a new transaction pool snapshot.
+func newTxSnapshot(path string) *snapshot {
+	return &snapshot{
+		path: path,
+	}
+}
+
+// load parses a pool snapshot from disk, loading its contents into the pool in
+// small-ish batches.
+func (snap *snapshot) load(add func([]*snapshotEntry) []error) error {
+	// Open the snapshot for loading the previous pool contents
+	input, err := os.Open(snap.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Skip the parsing if the snapshot file doesn't exist at all
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream         = rlp.NewStream(bufio.NewReader(input), 0)
+		total, dropped = 0, 0
+		failure        error
+		batch          []*snapshotEntry
+	)
+	loadBatch := func(entries []*snapshotEntry) {
+		for _, err := range add(entries) {
+			if err != nil {
+				log.Debug("Failed to add snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for {
+		// Parse the next entry and terminate on error
+		entry := new(snapshotEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			if len(batch) > 0 {
+				loadBatch(batch)
+			}
+			break
+		}
+		total++
+
+		if batch = append(batch, entry); len(batch) > 1024 {
+			loadBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	log.Info("Loaded transaction pool snapshot", "transactions", total, "dropped", dropped)
+
+	return failure
+}
+
+// save replaces the snapshot on disk with the given pool contents.
+func (snap *snapshot) save(entries []*snapshotEntry) error {
+	output, err := os.OpenFile(snap.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(output)
+	for _, entry := range entries {
+		if err := rlp.Encode(writer, entry); err != nil {
+			output.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		output.Close()
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(snap.path+".new", snap.path); err != nil {
+		return err
+	}
+	log.Info("Saved transaction pool snapshot", "transactions", len(entries))
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
@@ -111,6 +112,7 @@ var (
 	knownTxMeter       = metrics.NewRegisteredMeter("txpool/known", nil)
 	validTxMeter       = metrics.NewRegisteredMeter("txpool/valid", nil)
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
+	filteredTxMeter    = metrics.NewRegisteredMeter("txpool/filtered", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 	overflowedTxMeter  = metrics.NewRegisteredMeter("txpool/overflowed", nil)
 
@@ -167,6 +169,16 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Lifecycle      string // File to log the lifecycle events of all transactions into (JSONL)
+	LifecycleLimit uint64 // Size in bytes after which the lifecycle log is rotated
+
+	Snapshot string // File to persist the full pool contents into across restarts
+
+	PrivateLifetime uint64 // Number of blocks after which private transactions are dropped
+
+	Admission AdmissionConfig   // Built-in admission filters to enforce on new transactions
+	Filters   []AdmissionFilter `toml:"-"` // Custom admission filters, consulted after the built-in ones
 }
 
 // DefaultConfig contains the default configurations for the transaction
@@ -184,6 +196,10 @@ var DefaultConfig = Config{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	LifecycleLimit: 128 * 1024 * 1024,
+
+	PrivateLifetime: 25,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -222,6 +238,10 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultConfig.Lifetime)
 		conf.Lifetime = DefaultConfig.Lifetime
 	}
+	if conf.PrivateLifetime < 1 {
+		log.Warn("Sanitizing invalid txpool private lifetime", "provided", conf.PrivateLifetime, "updated", DefaultConfig.PrivateLifetime)
+		conf.PrivateLifetime = DefaultConfig.PrivateLifetime
+	}
 	return conf
 }
 
@@ -241,11 +261,13 @@ type TxPool struct {
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
+	explainMu   sync.Mutex // Serializes the state reads of explanations under the read lock
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
 
+	currentHead   *types.Header  // Current head of the blockchain
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *noncer        // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
@@ -253,6 +275,12 @@ type TxPool struct {
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *journal    // Journal of local transaction to back up to disk
 
+	snapshot *snapshot         // Snapshot of all the pool transactions to restore on restart
+	filters  []AdmissionFilter // Admission policies enforced on new transactions
+
+	lifecycle  *txLifecycle           // Tracker of the transaction transitions in the pool
+	inclusions map[common.Hash]uint64 // Transactions included by the blocks of the running reset
+
 	pending map[common.Address]*list     // All currently processable transactions
 	queue   map[common.Address]*list     // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
@@ -306,7 +334,9 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	pool.filters = append(config.Admission.filters(), config.Filters...)
 	pool.priced = newPricedList(pool.all)
+	pool.lifecycle = newTxLifecycle(config.Lifecycle, config.LifecycleLimit)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
@@ -324,6 +354,14 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If the full pool snapshot is enabled, restore the previous pool contents
+	if config.Snapshot != "" {
+		pool.snapshot = newTxSnapshot(config.Snapshot)
+
+		if err := pool.snapshot.load(pool.addSnapshotted); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -392,6 +430,7 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
+						pool.traceTx(TxLifecycleDropped, tx, DropLifetime)
 						pool.removeTx(tx.Hash(), true)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
@@ -424,6 +463,16 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.snapshot != nil {
+		pool.mu.RLock()
+		entries := pool.snapshotEntries()
+		pool.mu.RUnlock()
+
+		if err := pool.snapshot.save(entries); err != nil {
+			log.Warn("Failed to save transaction pool snapshot", "err", err)
+		}
+	}
+	pool.lifecycle.close()
 	log.Info("Transaction pool stopped")
 }
 
@@ -433,6 +482,25 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subsc
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeLifecycleEvent registers a subscription of TxLifecycleEvent and
+// starts sending every transition of the pool transactions to the given channel.
+//
+// Note, events are dropped instead of stalling the pool if the subscriber does
+// not keep up with the event stream.
+func (pool *TxPool) SubscribeLifecycleEvent(ch chan<- TxLifecycleEvent) event.Subscription {
+	return pool.lifecycle.subscribe(ch)
+}
+
+// LifecycleStats returns the number of lifecycle events recorded by the pool.
+// The events are counted synchronously as they happen, so the stats are exact
+// even if the subscribers miss some events.
+//
+// Note, events are only recorded while the lifecycle is tracked, i.e. while
+// there are subscribers or a lifecycle log.
+func (pool *TxPool) LifecycleStats() TxLifecycleStats {
+	return pool.lifecycle.counts()
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -454,6 +522,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(price)
 		for _, tx := range drop {
+			pool.traceTx(TxLifecycleDropped, tx, DropPriceLimit)
 			pool.removeTx(tx.Hash(), false)
 		}
 		pool.priced.Removed(len(drop))
@@ -583,6 +652,61 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// snapshotEntries assembles the full contents of the pool, pending and queued,
+// along with the metadata of all transactions.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) snapshotEntries() []*snapshotEntry {
+	var entries []*snapshotEntry
+	for _, set := range []map[common.Address]*list{pool.pending, pool.queue} {
+		for addr, list := range set {
+			local := pool.locals.contains(addr)
+			for _, tx := range list.Flatten() {
+				// Private transactions are short lived, don't resurrect them as public ones
+				if pool.all.IsPrivate(tx.Hash()) {
+					continue
+				}
+				entries = append(entries, &snapshotEntry{
+					Tx:      tx,
+					Local:   local,
+					Arrival: uint64(pool.all.Arrival(tx.Hash()).UnixNano()),
+					Origin:  pool.all.Origin(tx.Hash()),
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// addSnapshotted injects a batch of transactions loaded from the pool snapshot,
+// restoring their arrival times and origins. The transactions are validated
+// against the current head like any other, and are promoted synchronously.
+func (pool *TxPool) addSnapshotted(entries []*snapshotEntry) []error {
+	var (
+		errs  = make([]error, len(entries))
+		dirty = newAccountSet(pool.signer)
+	)
+	pool.mu.Lock()
+	for i, entry := range entries {
+		local := entry.Local && !pool.config.NoLocals
+		replaced, err := pool.add(entry.Tx, local, entry.Origin)
+		if errors.Is(err, ErrAlreadyKnown) {
+			err = nil // Local transaction restored by the journal
+		}
+		if err == nil {
+			pool.all.SetArrival(entry.Tx.Hash(), time.Unix(0, int64(entry.Arrival)))
+		}
+		errs[i] = err
+		if err == nil && !replaced {
+			dirty.addTx(entry.Tx)
+		}
+	}
+	pool.mu.Unlock()
+
+	<-pool.requestPromoteExecutables(dirty)
+	return errs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -637,7 +761,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return core.ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := core.IntrinsicGasWithSchedule(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul, pool.chainconfig.GasSchedule)
 	if err != nil {
 		return err
 	}
@@ -654,7 +778,13 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 // If a newly added transaction is marked as local, its sending account will be
 // be added to the allowlist, preventing any associated transaction from being dropped
 // out of the pool due to pricing constraints.
-func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err error) {
+func (pool *TxPool) add(tx *types.Transaction, local bool, origin string) (replaced bool, err error) {
+	// Report any rejection of a transaction not yet known to the pool
+	defer func() {
+		if err != nil && !errors.Is(err, ErrAlreadyKnown) {
+			pool.traceRejected(tx, origin, err)
+		}
+	}()
 	// If the transaction is already known, discard it
 	hash := tx.Hash()
 	if pool.all.Get(hash) != nil {
@@ -672,6 +802,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// If the transaction is refused by any admission filter, discard it
+	tags, err := pool.admit(tx, isLocal)
+	if err != nil {
+		log.Trace("Discarding filtered transaction", "hash", hash, "err", err)
+		filteredTxMeter.Mark(1)
+		return false, err
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -706,6 +843,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
 			underpricedTxMeter.Mark(1)
+			pool.traceTx(TxLifecycleDropped, tx, DropUnderpriced)
 			pool.removeTx(tx.Hash(), false)
 		}
 	}
@@ -720,14 +858,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		}
 		// New transaction is better, replace old one
 		if old != nil {
+			pool.traceReplaced(old, tx)
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all.Add(tx, isLocal)
+		pool.track(hash, origin, tags)
 		pool.priced.Put(tx, isLocal)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
+		pool.traceAdded(tx, isLocal)
+		pool.traceTx(TxLifecyclePromoted, tx, "")
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
@@ -739,6 +881,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.track(hash, origin, tags)
+	pool.traceAdded(tx, isLocal)
+	pool.traceTx(TxLifecycleQueued, tx, "")
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -771,6 +916,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
+		pool.traceReplaced(old, tx)
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
@@ -794,6 +940,38 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 	return old != nil, nil
 }
 
+// admit runs a transaction through the admission filters of the pool, returning
+// the tags assigned by them or the reason for its rejection.
+func (pool *TxPool) admit(tx *types.Transaction, local bool) ([]string, error) {
+	if len(pool.filters) == 0 {
+		return nil, nil
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	var tags []string
+	for _, filter := range pool.filters {
+		tag, err := filter.Admit(tx, from, local)
+		if err != nil {
+			return nil, err
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// track tags a freshly added transaction with its origin and admission tags,
+// marking privately submitted ones with their inclusion deadline.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) track(hash common.Hash, origin string, tags []string) {
+	pool.all.SetOrigin(hash, origin)
+	pool.all.SetTags(hash, tags)
+	if origin == OriginPrivate {
+		pool.all.SetPrivate(hash, pool.currentHead.Number.Uint64()+pool.config.PrivateLifetime)
+	}
+}
+
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
@@ -801,6 +979,10 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	// Private transactions are short lived, don't resurrect them as public ones
+	if pool.all.IsPrivate(tx.Hash()) {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -820,6 +1002,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
+		pool.traceTx(TxLifecycleDropped, tx, DropReplacementLost)
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
@@ -827,6 +1010,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
+		pool.traceReplaced(old, tx)
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
@@ -836,6 +1020,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
+	pool.traceTx(TxLifecyclePromoted, tx, "")
 
 	// Successful promotion, bump the heartbeat
 	pool.beats[addr] = time.Now()
@@ -848,7 +1033,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 // This method is used to add transactions from the RPC API and performs synchronous pool
 // reorganization and event propagation.
 func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, !pool.config.NoLocals, true)
+	return pool.addTxs(txs, OriginLocal, !pool.config.NoLocals, true)
 }
 
 // AddLocal enqueues a single local transaction into the pool if it is valid. This is
@@ -858,18 +1043,38 @@ func (pool *TxPool) AddLocal(tx *types.Transaction) error {
 	return errs[0]
 }
 
+// AddPrivate enqueues a single local transaction into the pool, marking it private
+// so that it's never propagated to the network. The transaction is dropped if it
+// does not get included within the configured number of blocks.
+func (pool *TxPool) AddPrivate(tx *types.Transaction) error {
+	errs := pool.addTxs([]*types.Transaction{tx}, OriginPrivate, !pool.config.NoLocals, true)
+	return errs[0]
+}
+
+// IsPrivate returns whether a transaction tracked by the pool was submitted
+// privately and must not be propagated to the network.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	return pool.all.IsPrivate(hash)
+}
+
 // AddRemotes enqueues a batch of transactions into the pool if they are valid. If the
 // senders are not among the locally tracked ones, full pricing constraints will apply.
 //
 // This method is used to add transactions from the p2p network and does not wait for pool
 // reorganization and internal event propagation.
 func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, false, false)
+	return pool.addTxs(txs, "", false, false)
+}
+
+// AddRemotesFrom is like AddRemotes, but also records the peer the transactions
+// were received from as their origin.
+func (pool *TxPool) AddRemotesFrom(peer string, txs []*types.Transaction) []error {
+	return pool.addTxs(txs, peer, false, false)
 }
 
 // AddRemotesSync is like AddRemotes, but waits for pool reorganization. Tests use this method.
 func (pool *TxPool) AddRemotesSync(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, false, true)
+	return pool.addTxs(txs, "", false, true)
 }
 
 // This is like AddRemotes with a single transaction, but waits for pool reorganization. Tests use this method.
@@ -887,8 +1092,9 @@ func (pool *TxPool) AddRemote(tx *types.Transaction) error {
 	return errs[0]
 }
 
-// addTxs attempts to queue a batch of transactions if they are valid.
-func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
+// addTxs attempts to queue a batch of transactions if they are valid. The origin
+// is an arbitrary tag of where the transactions were received from.
+func (pool *TxPool) addTxs(txs []*types.Transaction, origin string, local, sync bool) []error {
 	// Filter out known ones without obtaining the pool lock or recovering signatures
 	var (
 		errs = make([]error, len(txs))
@@ -919,7 +1125,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 
 	// Process all the new transaction and merge any errors into the original slice
 	pool.mu.Lock()
-	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
+	newErrs, dirtyAddrs := pool.addTxsLocked(news, origin, local)
 	pool.mu.Unlock()
 
 	var nilSlot = 0
@@ -940,11 +1146,11 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 
 // addTxsLocked attempts to queue a batch of transactions if they are valid.
 // The transaction pool lock must be held.
-func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
+func (pool *TxPool) addTxsLocked(txs []*types.Transaction, origin string, local bool) ([]error, *accountSet) {
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
-		replaced, err := pool.add(tx, local)
+		replaced, err := pool.add(tx, local, origin)
 		errs[i] = err
 		if err == nil && !replaced {
 			dirty.addTx(tx)
@@ -988,6 +1194,13 @@ func (pool *TxPool) Has(hash common.Hash) bool {
 	return pool.all.Get(hash) != nil
 }
 
+// Arrival returns the time a transaction tracked by the pool was first seen,
+// retained across restarts for the transactions restored from the snapshot, or
+// the zero time if the transaction is unknown.
+func (pool *TxPool) Arrival(hash common.Hash) time.Time {
+	return pool.all.Arrival(hash)
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
@@ -1017,6 +1230,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 			for _, tx := range invalids {
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(tx.Hash(), tx, false, false)
+				pool.traceTx(TxLifecycleDemoted, tx, "")
 			}
 			// Update the account nonce if needed
 			pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1156,6 +1370,11 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	}
 	pool.mu.Lock()
 	if reset != nil {
+		// Gather the transactions included since the old head if anyone's
+		// interested in which pool transactions made it into the chain
+		if pool.lifecycle.active() {
+			pool.inclusions = pool.collectInclusions(reset.oldHead, reset.newHead)
+		}
 		// Reset from the old head to the new, rescheduling any reorged transactions
 		pool.reset(reset.oldHead, reset.newHead)
 
@@ -1198,6 +1417,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
+	pool.inclusions = nil
 	pool.mu.Unlock()
 
 	// Notify subsystems for newly added transactions
@@ -1292,14 +1512,50 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 		log.Error("Failed to reset txpool state", "err", err)
 		return
 	}
+	pool.currentHead = newHead
 	pool.currentState = statedb
 	pool.pendingNonces = newNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
-	// Inject any transactions discarded due to reorgs
-	log.Debug("Reinjecting stale transactions", "count", len(reinject))
+	// Drop any private transactions that weren't included in time
+	for _, tx := range pool.all.ExpiredPrivates(newHead.Number.Uint64()) {
+		pool.traceTx(TxLifecycleDropped, tx, DropPrivateExpired)
+		pool.removeTx(tx.Hash(), true)
+	}
+	// Forget the retired private transactions which can't be reorged back any
+	// more, as deep reorgs are not reinjected
+	if number := newHead.Number.Uint64(); number > 64 {
+		pool.all.PruneRetiredPrivates(number - 64)
+	}
+	// Inject any transactions discarded due to reorgs. Private ones are kept
+	// private with their original deadline, or dropped if it already passed.
+	var (
+		public    types.Transactions
+		private   types.Transactions
+		deadlines []uint64
+	)
+	for _, tx := range reinject {
+		deadline, ok := pool.all.RetiredPrivate(tx.Hash())
+		switch {
+		case !ok:
+			public = append(public, tx)
+		case deadline >= newHead.Number.Uint64():
+			private = append(private, tx)
+			deadlines = append(deadlines, deadline)
+		default:
+			log.Debug("Dropping expired private transaction", "hash", tx.Hash(), "deadline", deadline)
+		}
+	}
+	log.Debug("Reinjecting stale transactions", "count", len(public), "private", len(private))
 	core.SenderCacher.Recover(pool.signer, reinject)
-	pool.addTxsLocked(reinject, false)
+	pool.addTxsLocked(public, OriginReorg, false)
+
+	errs, _ := pool.addTxsLocked(private, OriginPrivate, false)
+	for i, err := range errs {
+		if err == nil {
+			pool.all.SetPrivate(private[i].Hash(), deadlines[i])
+		}
+	}
 
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
@@ -1325,6 +1581,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		forwards := list.Forward(pool.currentState.GetNonce(addr))
 		for _, tx := range forwards {
 			hash := tx.Hash()
+			pool.traceStale(tx)
 			pool.all.Remove(hash)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
@@ -1332,6 +1589,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
 			hash := tx.Hash()
+			pool.traceTx(TxLifecycleDropped, tx, DropUnpayable)
 			pool.all.Remove(hash)
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
@@ -1354,6 +1612,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			caps = list.Cap(int(pool.config.AccountQueue))
 			for _, tx := range caps {
 				hash := tx.Hash()
+				pool.traceTx(TxLifecycleDropped, tx, DropAccountLimit)
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -1416,6 +1675,7 @@ func (pool *TxPool) truncatePending() {
 					for _, tx := range caps {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
+						pool.traceTx(TxLifecycleDropped, tx, DropPendingLimit)
 						pool.all.Remove(hash)
 
 						// Update the account nonce to the dropped transaction
@@ -1443,6 +1703,7 @@ func (pool *TxPool) truncatePending() {
 				for _, tx := range caps {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
+					pool.traceTx(TxLifecycleDropped, tx, DropPendingLimit)
 					pool.all.Remove(hash)
 
 					// Update the account nonce to the dropped transaction
@@ -1490,6 +1751,7 @@ func (pool *TxPool) truncateQueue() {
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
+				pool.traceTx(TxLifecycleDropped, tx, DropQueueLimit)
 				pool.removeTx(tx.Hash(), true)
 			}
 			drop -= size
@@ -1499,6 +1761,7 @@ func (pool *TxPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+			pool.traceTx(TxLifecycleDropped, txs[i], DropQueueLimit)
 			pool.removeTx(txs[i].Hash(), true)
 			drop--
 			queuedRateLimitMeter.Mark(1)
@@ -1522,6 +1785,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		olds := list.Forward(nonce)
 		for _, tx := range olds {
 			hash := tx.Hash()
+			pool.traceStale(tx)
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
@@ -1530,6 +1794,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
+			pool.traceTx(TxLifecycleDropped, tx, DropUnpayable)
 			pool.all.Remove(hash)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
@@ -1540,6 +1805,7 @@ func (pool *TxPool) demoteUnexecutables() {
 
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
+			pool.traceTx(TxLifecycleDemoted, tx, "")
 		}
 		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
 		if pool.locals.contains(addr) {
@@ -1554,6 +1820,7 @@ func (pool *TxPool) demoteUnexecutables() {
 
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(hash, tx, false, false)
+				pool.traceTx(TxLifecycleDemoted, tx, "")
 			}
 			pendingGauge.Dec(int64(len(gapped)))
 		}
@@ -1564,6 +1831,102 @@ func (pool *TxPool) demoteUnexecutables() {
 	}
 }
 
+// newLifecycleEvent assembles a lifecycle event of a transaction tracked by
+// the pool.
+func (pool *TxPool) newLifecycleEvent(kind TxLifecycleKind, tx *types.Transaction, reason string) *TxLifecycleEvent {
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	return &TxLifecycleEvent{
+		Kind:    kind,
+		Time:    time.Now(),
+		Hash:    tx.Hash(),
+		From:    from,
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Arrival: pool.all.Arrival(tx.Hash()),
+		Origin:  pool.all.Origin(tx.Hash()),
+		Tags:    pool.all.Tags(tx.Hash()),
+		Reason:  reason,
+	}
+}
+
+// traceTx records a lifecycle event of a transaction if anyone's listening.
+//
+// Note, the transaction must still be tracked by the lookup set for its origin
+// to be reported.
+func (pool *TxPool) traceTx(kind TxLifecycleKind, tx *types.Transaction, reason string) {
+	if !pool.lifecycle.active() {
+		return
+	}
+	pool.lifecycle.record(pool.newLifecycleEvent(kind, tx, reason))
+}
+
+// traceAdded records the admission of a new transaction into the pool.
+func (pool *TxPool) traceAdded(tx *types.Transaction, local bool) {
+	if local {
+		pool.traceTx(TxLifecycleAddedLocal, tx, "")
+	} else {
+		pool.traceTx(TxLifecycleAddedRemote, tx, "")
+	}
+}
+
+// traceRejected records the refusal of a transaction entering the pool.
+func (pool *TxPool) traceRejected(tx *types.Transaction, origin string, err error) {
+	if !pool.lifecycle.active() {
+		return
+	}
+	ev := pool.newLifecycleEvent(TxLifecycleRejected, tx, err.Error())
+	ev.Origin = origin
+	pool.lifecycle.record(ev)
+}
+
+// traceReplaced records the replacement of a transaction by a new one with the
+// same nonce.
+func (pool *TxPool) traceReplaced(old *types.Transaction, tx *types.Transaction) {
+	if !pool.lifecycle.active() {
+		return
+	}
+	ev := pool.newLifecycleEvent(TxLifecycleReplaced, old, "")
+	hash := tx.Hash()
+	ev.ReplacedBy = &hash
+	pool.lifecycle.record(ev)
+}
+
+// traceStale records the removal of a transaction whose nonce was used up by
+// the chain, either by the transaction itself or by a different one.
+func (pool *TxPool) traceStale(tx *types.Transaction) {
+	if !pool.lifecycle.active() {
+		return
+	}
+	number, ok := pool.inclusions[tx.Hash()]
+	if !ok {
+		pool.lifecycle.record(pool.newLifecycleEvent(TxLifecycleDropped, tx, DropNonceTooLow))
+		return
+	}
+	ev := pool.newLifecycleEvent(TxLifecycleIncluded, tx, "")
+	ev.Block = (*hexutil.Uint64)(&number)
+	pool.lifecycle.record(ev)
+}
+
+// collectInclusions gathers the transactions included by the canonical blocks
+// leading from the old head to the new one. The search is capped to a limited
+// number of blocks to avoid excessive work on deep reorgs and initial syncs.
+func (pool *TxPool) collectInclusions(oldHead, newHead *types.Header) map[common.Hash]uint64 {
+	if oldHead == nil || newHead == nil {
+		return nil
+	}
+	inclusions := make(map[common.Hash]uint64)
+	block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
+	for i := 0; block != nil && i < lifecycleInclusionDepth; i++ {
+		if block.Hash() == oldHead.Hash() || block.NumberU64() == 0 {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			inclusions[tx.Hash()] = block.NumberU64()
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	return inclusions
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
@@ -1659,17 +2022,27 @@ func (as *accountSet) merge(other *accountSet) {
 // This lookup set combines the notion of "local transactions", which is useful
 // to build upper-level structure.
 type lookup struct {
-	slots   int
-	lock    sync.RWMutex
-	locals  map[common.Hash]*types.Transaction
-	remotes map[common.Hash]*types.Transaction
+	slots    int
+	lock     sync.RWMutex
+	locals   map[common.Hash]*types.Transaction
+	remotes  map[common.Hash]*types.Transaction
+	origins  map[common.Hash]string
+	arrivals map[common.Hash]time.Time // Arrival times of transactions restored from the snapshot
+	private  map[common.Hash]uint64    // Privately submitted transactions with their inclusion deadline
+	retired  map[common.Hash]uint64    // Private transactions removed before their deadline, e.g. included
+	tags     map[common.Hash][]string  // Tags assigned by the admission filters
 }
 
 // newLookup returns a new lookup structure.
 func newLookup() *lookup {
 	return &lookup{
-		locals:  make(map[common.Hash]*types.Transaction),
-		remotes: make(map[common.Hash]*types.Transaction),
+		locals:   make(map[common.Hash]*types.Transaction),
+		remotes:  make(map[common.Hash]*types.Transaction),
+		origins:  make(map[common.Hash]string),
+		arrivals: make(map[common.Hash]time.Time),
+		private:  make(map[common.Hash]uint64),
+		retired:  make(map[common.Hash]uint64),
+		tags:     make(map[common.Hash][]string),
 	}
 }
 
@@ -1786,8 +2159,164 @@ func (t *lookup) Remove(hash common.Hash) {
 	t.slots -= numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
 
+	// Remember the deadline of private transactions, to keep them private if
+	// they are reinjected by a reorg
+	if deadline, ok := t.private[hash]; ok {
+		t.retired[hash] = deadline
+	}
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
+	delete(t.origins, hash)
+	delete(t.arrivals, hash)
+	delete(t.private, hash)
+	delete(t.tags, hash)
+}
+
+// SetOrigin tags a tracked transaction with where it was received from.
+func (t *lookup) SetOrigin(hash common.Hash, origin string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if origin == "" {
+		return
+	}
+	if _, ok := t.locals[hash]; !ok {
+		if _, ok := t.remotes[hash]; !ok {
+			return
+		}
+	}
+	t.origins[hash] = origin
+}
+
+// Origin returns where a transaction was received from, or an empty string if
+// it's unknown.
+func (t *lookup) Origin(hash common.Hash) string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.origins[hash]
+}
+
+// SetArrival overrides the time a tracked transaction was first seen, used to
+// restore the arrival times of transactions loaded back from disk.
+func (t *lookup) SetArrival(hash common.Hash, arrival time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.locals[hash]; !ok {
+		if _, ok := t.remotes[hash]; !ok {
+			return
+		}
+	}
+	t.arrivals[hash] = arrival
+}
+
+// Arrival returns the time a tracked transaction was first seen, or the zero
+// time if it's not tracked.
+func (t *lookup) Arrival(hash common.Hash) time.Time {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if arrival, ok := t.arrivals[hash]; ok {
+		return arrival
+	}
+	if tx := t.locals[hash]; tx != nil {
+		return tx.Time()
+	}
+	if tx := t.remotes[hash]; tx != nil {
+		return tx.Time()
+	}
+	return time.Time{}
+}
+
+// SetTags records the admission tags of a tracked transaction.
+func (t *lookup) SetTags(hash common.Hash, tags []string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(tags) == 0 {
+		return
+	}
+	if _, ok := t.locals[hash]; !ok {
+		if _, ok := t.remotes[hash]; !ok {
+			return
+		}
+	}
+	t.tags[hash] = tags
+}
+
+// Tags returns the admission tags of a transaction.
+func (t *lookup) Tags(hash common.Hash) []string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.tags[hash]
+}
+
+// SetPrivate marks a tracked transaction private, to be dropped if not included
+// until the given block number.
+func (t *lookup) SetPrivate(hash common.Hash, deadline uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.locals[hash]; !ok {
+		if _, ok := t.remotes[hash]; !ok {
+			return
+		}
+	}
+	t.private[hash] = deadline
+	delete(t.retired, hash)
+}
+
+// RetiredPrivate returns the inclusion deadline of a private transaction no
+// longer tracked by the pool, if it's still remembered.
+func (t *lookup) RetiredPrivate(hash common.Hash) (uint64, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	deadline, ok := t.retired[hash]
+	return deadline, ok
+}
+
+// PruneRetiredPrivates forgets the private transactions no longer tracked by
+// the pool whose inclusion deadline is below the given block number.
+func (t *lookup) PruneRetiredPrivates(number uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for hash, deadline := range t.retired {
+		if deadline < number {
+			delete(t.retired, hash)
+		}
+	}
+}
+
+// IsPrivate returns whether a transaction was submitted privately.
+func (t *lookup) IsPrivate(hash common.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, ok := t.private[hash]
+	return ok
+}
+
+// ExpiredPrivates returns the private transactions whose inclusion deadline is
+// below the given block number, the deadline block itself may still include them.
+func (t *lookup) ExpiredPrivates(number uint64) types.Transactions {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var expired types.Transactions
+	for hash, deadline := range t.private {
+		if deadline < number {
+			if tx := t.locals[hash]; tx != nil {
+				expired = append(expired, tx)
+			} else if tx := t.remotes[hash]; tx != nil {
+				expired = append(expired, tx)
+			}
+		}
+	}
+	return expired
 }
 
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
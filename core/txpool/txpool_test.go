@@ -23,6 +23,8 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -431,14 +433,14 @@ func TestChainFork(t *testing.T) {
 	resetState()
 
 	tx := transaction(0, 100000, key)
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, ""); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	pool.removeTx(tx.Hash(), true)
 
 	// reset the pool's internal state
 	resetState()
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, ""); err != nil {
 		t.Error("didn't expect error", err)
 	}
 }
@@ -465,10 +467,10 @@ func TestDoubleNonce(t *testing.T) {
 	tx3, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 1000000, big.NewInt(1), nil), signer, key)
 
 	// Add the first two transaction, ensure higher priced stays only
-	if replace, err := pool.add(tx1, false); err != nil || replace {
+	if replace, err := pool.add(tx1, false, ""); err != nil || replace {
 		t.Errorf("first transaction insert failed (%v) or reported replacement (%v)", err, replace)
 	}
-	if replace, err := pool.add(tx2, false); err != nil || !replace {
+	if replace, err := pool.add(tx2, false, ""); err != nil || !replace {
 		t.Errorf("second transaction insert failed (%v) or not reported replacement (%v)", err, replace)
 	}
 	<-pool.requestPromoteExecutables(newAccountSet(signer, addr))
@@ -480,7 +482,7 @@ func TestDoubleNonce(t *testing.T) {
 	}
 
 	// Add the third transaction and ensure it's not saved (smaller price)
-	pool.add(tx3, false)
+	pool.add(tx3, false, "")
 	<-pool.requestPromoteExecutables(newAccountSet(signer, addr))
 	if pool.pending[addr].Len() != 1 {
 		t.Error("expected 1 pending transactions, got", pool.pending[addr].Len())
@@ -503,7 +505,7 @@ func TestMissingNonce(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	testAddBalance(pool, addr, big.NewInt(100000000000000))
 	tx := transaction(1, 100000, key)
-	if _, err := pool.add(tx, false); err != nil {
+	if _, err := pool.add(tx, false, ""); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	if len(pool.pending) != 0 {
@@ -2346,6 +2348,337 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the full pool snapshot restores pending and queued, local and remote
+// transactions along with their arrival times and origins, dropping those that
+// became invalid in the meantime.
+func TestSnapshotting(t *testing.T) {
+	t.Parallel()
+
+	snapshot := filepath.Join(t.TempDir(), "transactions.rlp")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Snapshot = snapshot
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add a pending local, and pending and queued remote transactions
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	remotes := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), remote),
+		pricedTransaction(1, 100000, big.NewInt(1), remote),
+		pricedTransaction(3, 100000, big.NewInt(1), remote),
+	}
+	for _, err := range pool.addTxs(remotes, "peer", false, true) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool contents mismatch: have %d/%d, want %d/%d", pending, queued, 3, 1)
+	}
+	arrival := pool.all.Arrival(remotes[1].Hash())
+
+	// Terminate the old pool, bump the remote nonce, create a new pool and ensure
+	// the still valid transactions survive
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+	blockchain = &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool contents mismatch: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	if !pool.locals.contains(crypto.PubkeyToAddress(local.PublicKey)) {
+		t.Errorf("local account not restored")
+	}
+	tx := pool.Get(remotes[1].Hash())
+	if tx == nil {
+		t.Fatalf("remote transaction not restored")
+	}
+	if have := pool.all.Arrival(tx.Hash()); !have.Equal(arrival) {
+		t.Errorf("arrival time mismatch: have %v, want %v", have, arrival)
+	}
+	if origin := pool.all.Origin(tx.Hash()); origin != "peer" {
+		t.Errorf("origin mismatch: have %q, want %q", origin, "peer")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that privately submitted transactions are flagged as such, are excluded
+// from the disk persistence and are dropped if not included in time.
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PrivateLifetime = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	private, public := transaction(0, 100000, key), transaction(1, 100000, key)
+	if err := pool.AddPrivate(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddLocal(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	if !pool.IsPrivate(private.Hash()) {
+		t.Errorf("private transaction not flagged private")
+	}
+	if pool.IsPrivate(public.Hash()) {
+		t.Errorf("public transaction flagged private")
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	pool.mu.RLock()
+	entries := pool.snapshotEntries()
+	pool.mu.RUnlock()
+	if len(entries) != 1 || entries[0].Tx.Hash() != public.Hash() {
+		t.Errorf("private transaction included in the snapshot")
+	}
+	// Advance the chain before the deadline and ensure the transaction is kept
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(1), GasLimit: 1000000, BaseFee: big.NewInt(1)})
+	if pool.Get(private.Hash()) == nil {
+		t.Fatalf("private transaction dropped before its deadline")
+	}
+	// Reach the deadline and ensure the transaction is still kept for it
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(2), GasLimit: 1000000, BaseFee: big.NewInt(1)})
+	if pool.Get(private.Hash()) == nil {
+		t.Fatalf("private transaction dropped at its deadline")
+	}
+	// Pass the deadline and ensure the transaction (and its dependent) is dropped
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(3), GasLimit: 1000000, BaseFee: big.NewInt(1)})
+	if pool.Get(private.Hash()) != nil {
+		t.Fatalf("private transaction not dropped after its deadline")
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 1 {
+		t.Fatalf("pool contents mismatch: have %d/%d, want %d/%d", pending, queued, 0, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// reorgBlockChain is a test chain serving a set of fixed blocks, used to reorg
+// the transaction pool between them.
+type reorgBlockChain struct {
+	*testBlockChain
+	blocks map[common.Hash]*types.Block
+}
+
+func (bc *reorgBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.blocks[hash]
+}
+
+// Tests that private transactions reinjected by a reorg stay private with their
+// original deadline, so they are neither announced nor kept for longer.
+func TestPrivateTransactionsReorg(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &reorgBlockChain{
+		testBlockChain: &testBlockChain{1000000, statedb, new(event.Feed)},
+		blocks:         make(map[common.Hash]*types.Block),
+	}
+	config := testTxPoolConfig
+	config.PrivateLifetime = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	privateKey, _ := crypto.GenerateKey()
+	publicKey, _ := crypto.GenerateKey()
+	privateAddr, publicAddr := crypto.PubkeyToAddress(privateKey.PublicKey), crypto.PubkeyToAddress(publicKey.PublicKey)
+	testAddBalance(pool, privateAddr, big.NewInt(1000000000))
+	testAddBalance(pool, publicAddr, big.NewInt(1000000000))
+
+	private, public := transaction(0, 100000, privateKey), transaction(0, 100000, publicKey)
+	if err := pool.AddPrivate(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddLocal(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	// Create a chain including both transactions, and a longer one without them
+	newBlock := func(parent *types.Header, txs types.Transactions) *types.Block {
+		block := types.NewBlock(&types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			GasLimit:   1000000,
+			BaseFee:    big.NewInt(1),
+		}, txs, nil, nil, trie.NewStackTrie(nil))
+		blockchain.blocks[block.Hash()] = block
+		return block
+	}
+	genesis := newBlock(&types.Header{Number: big.NewInt(-1)}, nil)
+	included := newBlock(genesis.Header(), types.Transactions{private, public})
+	reorged := newBlock(newBlock(genesis.Header(), nil).Header(), nil)
+
+	// Include the transactions and ensure they leave the pool
+	statedb.SetNonce(privateAddr, 1)
+	statedb.SetNonce(publicAddr, 1)
+	<-pool.requestReset(genesis.Header(), included.Header())
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool contents mismatch: have %d/%d, want %d/%d", pending, queued, 0, 0)
+	}
+	// Reorg the transactions out and ensure the private one stays private
+	statedb.SetNonce(privateAddr, 0)
+	statedb.SetNonce(publicAddr, 0)
+	<-pool.requestReset(included.Header(), reorged.Header())
+	if pool.Get(private.Hash()) == nil || pool.Get(public.Hash()) == nil {
+		t.Fatalf("reorged transactions not reinjected")
+	}
+	if !pool.IsPrivate(private.Hash()) {
+		t.Errorf("reinjected private transaction not flagged private")
+	}
+	if pool.IsPrivate(public.Hash()) {
+		t.Errorf("reinjected public transaction flagged private")
+	}
+	// Pass the original deadline and ensure the transaction is dropped
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(4), GasLimit: 1000000, BaseFee: big.NewInt(1)})
+	if pool.Get(private.Hash()) != nil {
+		t.Fatalf("reinjected private transaction not dropped after its deadline")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool explains why the transactions of an account are stuck,
+// reporting nonce gaps, unpayable transactions and the ones blocked by them.
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000))
+
+	txs := types.Transactions{
+		transaction(0, 30000, key),
+		transaction(1, 100000, key),
+		transaction(3, 100000, key),
+		transaction(4, 100000, key),
+	}
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	// Drain the balance so only the first transaction remains payable
+	pool.mu.Lock()
+	pool.currentState.SubBalance(addr, big.NewInt(950000))
+	pool.mu.Unlock()
+
+	explanation := pool.Explain(addr)
+	if explanation.Nonce != 0 || explanation.PendingNonce != 2 {
+		t.Errorf("nonce mismatch: have %d/%d, want %d/%d", explanation.Nonce, explanation.PendingNonce, 0, 2)
+	}
+	if len(explanation.Gaps) != 1 || explanation.Gaps[0] != 2 {
+		t.Errorf("nonce gaps mismatch: have %v, want %v", explanation.Gaps, []uint64{2})
+	}
+	want := []struct {
+		status  string
+		reasons []string
+	}{
+		{"pending", nil},
+		{"pending", []string{core.ErrInsufficientFunds.Error()}},
+		{"queued", []string{ErrNonceGap.Error(), core.ErrInsufficientFunds.Error()}},
+		{"queued", []string{core.ErrInsufficientFunds.Error()}},
+	}
+	if len(explanation.Transactions) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(explanation.Transactions), len(want))
+	}
+	for i, tx := range explanation.Transactions {
+		if tx.Hash != txs[i].Hash() || tx.Status != want[i].status || !reflect.DeepEqual(tx.Reasons, want[i].reasons) {
+			t.Errorf("tx %d: explanation mismatch: have %s %v, want %s %v", i, tx.Status, tx.Reasons, want[i].status, want[i].reasons)
+		}
+	}
+	// Restore the balance and ensure transactions above the gap are blocked
+	pool.mu.Lock()
+	pool.currentState.AddBalance(addr, big.NewInt(950000))
+	pool.mu.Unlock()
+
+	explanation = pool.Explain(addr)
+	if have := explanation.Transactions[3].Reasons; !reflect.DeepEqual(have, []string{ErrNonceBlocked.Error()}) {
+		t.Errorf("blocked transaction reasons mismatch: have %v, want %v", have, []string{ErrNonceBlocked.Error()})
+	}
+}
+
+// Tests that transactions above the account slots are only reported as eviction
+// candidates if the pool is over its global limits.
+func TestExplainAccountSlots(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+
+	txs := types.Transactions{
+		transaction(0, 100000, key),
+		transaction(1, 100000, key),
+		transaction(2, 100000, key),
+	}
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	// Ensure all transactions are includable while the pool has room
+	for i, tx := range pool.Explain(addr).Transactions {
+		if len(tx.Reasons) != 0 {
+			t.Errorf("tx %d: unexpected reasons without pool pressure: %v", i, tx.Reasons)
+		}
+	}
+	// Shrink the pool below its contents and ensure the excess is reported
+	pool.mu.Lock()
+	pool.config.GlobalSlots = 2
+	pool.mu.Unlock()
+
+	explanation := pool.Explain(addr)
+	for i, tx := range explanation.Transactions {
+		var want []string
+		if i >= 2 {
+			want = []string{ErrAccountSlots.Error()}
+		}
+		if !reflect.DeepEqual(tx.Reasons, want) {
+			t.Errorf("tx %d: reasons mismatch: have %v, want %v", i, tx.Reasons, want)
+		}
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
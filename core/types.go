@@ -49,3 +49,18 @@ type Processor interface {
 	// the processor (coinbase) and any included uncles.
 	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error)
 }
+
+// BlockTracer is an interface for tracing the execution of blocks as they are
+// imported into the chain.
+type BlockTracer interface {
+	// BlockStart is called before the transactions of a block are executed.
+	BlockStart(block *types.Block)
+
+	// TxTracer returns the EVM logger to trace the execution of the index'th
+	// transaction of the block with, or nil to leave it untraced.
+	TxTracer(index int, tx *types.Transaction) vm.EVMLogger
+
+	// BlockEnd is called after the block was processed, along with the error
+	// aborting its execution, if any.
+	BlockEnd(err error)
+}
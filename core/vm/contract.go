@@ -50,13 +50,15 @@ type Contract struct {
 	caller        ContractRef
 	self          ContractRef
 
-	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
-	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
+	jumpdests  map[common.Hash]bitvec           // Aggregated result of JUMPDEST analysis.
+	analysis   bitvec                           // Locally cached result of JUMPDEST analysis
+	containers map[common.Hash]*containerResult // Aggregated result of EOF container validation
 
-	Code     []byte
-	CodeHash common.Hash
-	CodeAddr *common.Address
-	Input    []byte
+	Code      []byte
+	CodeHash  common.Hash
+	CodeAddr  *common.Address
+	Input     []byte
+	Container *Container // Decoded code if it's an EOF container, nil for legacy code
 
 	Gas   uint64
 	value *big.Int
@@ -67,10 +69,12 @@ func NewContract(caller ContractRef, object ContractRef, value *big.Int, gas uin
 	c := &Contract{CallerAddress: caller.Address(), caller: caller, self: object}
 
 	if parent, ok := caller.(*Contract); ok {
-		// Reuse JUMPDEST analysis from parent context if available.
+		// Reuse JUMPDEST analysis and EOF validation from parent context if available.
 		c.jumpdests = parent.jumpdests
+		c.containers = parent.containers
 	} else {
 		c.jumpdests = make(map[common.Hash]bitvec)
+		c.containers = make(map[common.Hash]*containerResult)
 	}
 
 	// Gas should be a pointer so it can safely be reduced through the run
@@ -150,6 +154,23 @@ func (c *Contract) GetOp(n uint64) OpCode {
 	return STOP
 }
 
+// GetOpInSection returns the n'th element in the given EOF code section.
+func (c *Contract) GetOpInSection(section uint64, n uint64) OpCode {
+	if code := c.Container.Code[section]; n < uint64(len(code)) {
+		return OpCode(code[n])
+	}
+	return STOP
+}
+
+// CodeAt returns the code executed in the given section: the EOF code section,
+// or the whole code of legacy contracts.
+func (c *Contract) CodeAt(section uint64) []byte {
+	if c.Container == nil {
+		return c.Code
+	}
+	return c.Container.Code[section]
+}
+
 // Caller returns the caller of the contract.
 //
 // Caller will recursively call caller when the contract is a delegate
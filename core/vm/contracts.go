@@ -17,10 +17,12 @@
 package vm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -105,6 +107,29 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{18}): &bls12381MapG2{},
 }
 
+// precompiledContractNames are the names of the precompiled contracts, as
+// reported to tracers.
+var precompiledContractNames = map[common.Address]string{
+	common.BytesToAddress([]byte{1}):  "ecrecover",
+	common.BytesToAddress([]byte{2}):  "sha256",
+	common.BytesToAddress([]byte{3}):  "ripemd160",
+	common.BytesToAddress([]byte{4}):  "identity",
+	common.BytesToAddress([]byte{5}):  "modexp",
+	common.BytesToAddress([]byte{6}):  "bn256Add",
+	common.BytesToAddress([]byte{7}):  "bn256ScalarMul",
+	common.BytesToAddress([]byte{8}):  "bn256Pairing",
+	common.BytesToAddress([]byte{9}):  "blake2f",
+	common.BytesToAddress([]byte{10}): "bls12381G1Add",
+	common.BytesToAddress([]byte{11}): "bls12381G1Mul",
+	common.BytesToAddress([]byte{12}): "bls12381G1MultiExp",
+	common.BytesToAddress([]byte{13}): "bls12381G2Add",
+	common.BytesToAddress([]byte{14}): "bls12381G2Mul",
+	common.BytesToAddress([]byte{15}): "bls12381G2MultiExp",
+	common.BytesToAddress([]byte{16}): "bls12381Pairing",
+	common.BytesToAddress([]byte{17}): "bls12381MapG1",
+	common.BytesToAddress([]byte{18}): "bls12381MapG2",
+}
+
 var (
 	PrecompiledAddressesBerlin    []common.Address
 	PrecompiledAddressesIstanbul  []common.Address
@@ -129,16 +154,41 @@ func init() {
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	var addrs []common.Address
 	switch {
 	case rules.IsBerlin:
-		return PrecompiledAddressesBerlin
+		addrs = PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
-		return PrecompiledAddressesIstanbul
+		addrs = PrecompiledAddressesIstanbul
 	case rules.IsByzantium:
-		return PrecompiledAddressesByzantium
+		addrs = PrecompiledAddressesByzantium
 	default:
-		return PrecompiledAddressesHomestead
+		addrs = PrecompiledAddressesHomestead
+	}
+	if len(rules.Experimental) == 0 {
+		return addrs
+	}
+	// Add the precompiles of the experimental extensions, without touching
+	// the shared address lists
+	addrs = append([]common.Address{}, addrs...)
+	known := make(map[common.Address]bool, len(addrs))
+	for _, addr := range addrs {
+		known[addr] = true
+	}
+	for _, name := range rules.Experimental {
+		if ext, ok := extensions[name]; ok {
+			for addr := range ext.Precompiles {
+				if !known[addr] {
+					known[addr] = true
+					addrs = append(addrs, addr)
+				}
+			}
+		}
 	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
 }
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
 
@@ -233,3 +234,140 @@ func opPush0(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	scope.Stack.push(new(uint256.Int))
 	return nil, nil
 }
+
+// enable4200 applies EIP-4200 (static relative jumps)
+// - Adds RJUMP, jumping to a fixed offset
+// - Adds RJUMPI, jumping to a fixed offset if the condition is set
+// - Adds RJUMPV, jumping to the offset selected by the case in a jump table
+func enable4200(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+}
+
+// parseInt16 returns the int16 immediate at the given position of the code.
+func parseInt16(code []byte, pos uint64) int64 {
+	return int64(int16(binary.BigEndian.Uint16(code[pos:])))
+}
+
+// opRjump implements the RJUMP opcode
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if scope.Contract.Container == nil {
+		return nil, &ErrInvalidOpCode{opcode: RJUMP} // EOF only
+	}
+	code := scope.Contract.Container.Code[scope.CodeSection]
+	offset := parseInt16(code, *pc+1)
+
+	// The offset is relative to the end of the instruction, and pc will be
+	// increased by the interpreter loop
+	*pc = uint64(int64(*pc) + 3 + offset - 1)
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if scope.Contract.Container == nil {
+		return nil, &ErrInvalidOpCode{opcode: RJUMPI} // EOF only
+	}
+	cond := scope.Stack.pop()
+	if cond.IsZero() {
+		*pc += 2 // Skip the immediate
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if scope.Contract.Container == nil {
+		return nil, &ErrInvalidOpCode{opcode: RJUMPV} // EOF only
+	}
+	var (
+		code     = scope.Contract.Container.Code[scope.CodeSection]
+		branches = uint64(code[*pc+1])
+		idx      = scope.Stack.pop()
+	)
+	if idx, overflow := idx.Uint64WithOverflow(); !overflow && idx < branches {
+		offset := parseInt16(code, *pc+2+2*idx)
+		*pc = uint64(int64(*pc) + 2 + 2*int64(branches) + offset - 1)
+		return nil, nil
+	}
+	*pc += 1 + 2*branches // Skip the immediates
+	return nil, nil
+}
+
+// enable4750 applies EIP-4750 (EOF functions)
+// - Adds CALLF, calling the code section given as immediate
+// - Adds RETF, returning to the calling code section
+func enable4750(jt *JumpTable) {
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+}
+
+// opCallf implements the CALLF opcode
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if scope.Contract.Container == nil {
+		return nil, &ErrInvalidOpCode{opcode: CALLF} // EOF only
+	}
+	var (
+		container = scope.Contract.Container
+		idx       = binary.BigEndian.Uint16(container.Code[scope.CodeSection][*pc+1:])
+		typ       = container.Types[idx]
+	)
+	if len(scope.ReturnStack) >= maxReturnStackLen {
+		return nil, ErrReturnStackExceeded
+	}
+	// The stack is shared by the sections, check that the callee can't overflow it
+	if limit := int(params.StackLimit) - int(typ.MaxStackHeight) + int(typ.Input); scope.Stack.len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: limit}
+	}
+	scope.ReturnStack = append(scope.ReturnStack, &ReturnContext{
+		Section: scope.CodeSection,
+		Pc:      *pc + 3,
+	})
+	scope.CodeSection = uint64(idx)
+	*pc = ^uint64(0) // pc will be increased to 0 by the interpreter loop
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if scope.Contract.Container == nil {
+		return nil, &ErrInvalidOpCode{opcode: RETF} // EOF only
+	}
+	// Returning from the first section halts the execution, like STOP
+	if len(scope.ReturnStack) == 0 {
+		return nil, errStopToken
+	}
+	ctx := scope.ReturnStack[len(scope.ReturnStack)-1]
+	scope.ReturnStack = scope.ReturnStack[:len(scope.ReturnStack)-1]
+
+	scope.CodeSection = ctx.Section
+	*pc = ctx.Pc - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
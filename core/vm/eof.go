@@ -0,0 +1,270 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	eofFormatByte = 0xef
+	eofMagicByte  = 0x00
+	eof1Version   = 1
+
+	kindTypes      = 1
+	kindCode       = 2
+	kindData       = 3
+	kindTerminator = 0
+
+	maxCodeSections   = 1024
+	maxInputItems     = 127
+	maxOutputItems    = 127
+	maxStackHeight    = 1023
+	maxReturnStackLen = 1024
+)
+
+var (
+	ErrInvalidMagic           = errors.New("invalid magic")
+	ErrInvalidVersion         = errors.New("invalid version")
+	ErrMissingTypeHeader      = errors.New("missing type header")
+	ErrInvalidTypeSize        = errors.New("invalid type section size")
+	ErrMissingCodeHeader      = errors.New("missing code header")
+	ErrInvalidCodeHeader      = errors.New("invalid code header")
+	ErrInvalidCodeSize        = errors.New("invalid code size")
+	ErrMissingDataHeader      = errors.New("missing data header")
+	ErrMissingTerminator      = errors.New("missing header terminator")
+	ErrTooManyInputs          = errors.New("invalid type content, too many inputs")
+	ErrTooManyOutputs         = errors.New("invalid type content, too many outputs")
+	ErrInvalidSection0Type    = errors.New("invalid section 0 type, input and output should be zero")
+	ErrTooLargeMaxStackHeight = errors.New("invalid type content, max stack height exceeds limit")
+	ErrInvalidContainerSize   = errors.New("invalid container size")
+
+	ErrUndefinedInstruction   = errors.New("undefined instruction")
+	ErrTruncatedImmediate     = errors.New("truncated immediate")
+	ErrInvalidSectionArgument = errors.New("invalid section argument")
+	ErrInvalidJumpDest        = errors.New("invalid jump destination")
+	ErrConflictingStack       = errors.New("conflicting stack height")
+	ErrInvalidBranchCount     = errors.New("invalid number of branches in jump table")
+	ErrInvalidOutputs         = errors.New("invalid number of outputs")
+	ErrInvalidMaxStackHeight  = errors.New("invalid max stack height")
+	ErrInvalidCodeTermination = errors.New("invalid code termination")
+	ErrUnreachableCode        = errors.New("unreachable code")
+)
+
+// FunctionMetadata is the type of an EOF code section, as declared in the types
+// section of the container.
+type FunctionMetadata struct {
+	Input          uint8  // Number of stack items consumed by the section
+	Output         uint8  // Number of stack items returned by the section
+	MaxStackHeight uint16 // Maximum stack height reached by the section
+}
+
+// Container is an EOF container object (EIP-3540), holding the code sections
+// and the data section of a contract. The code sections are executed, while the
+// data section is only accessible as part of the whole container, e.g. through
+// CODECOPY.
+type Container struct {
+	Types []*FunctionMetadata
+	Code  [][]byte
+	Data  []byte
+}
+
+// hasEOFByte returns whether the code starts with the EOF format byte, which is
+// reserved for EOF containers by EIP-3541.
+func hasEOFByte(code []byte) bool {
+	return len(code) != 0 && code[0] == eofFormatByte
+}
+
+// hasEOFMagic returns whether the code starts with the EOF magic.
+func hasEOFMagic(code []byte) bool {
+	return len(code) >= 2 && code[0] == eofFormatByte && code[1] == eofMagicByte
+}
+
+// MarshalBinary encodes an EOF container into binary format.
+func (c *Container) MarshalBinary() []byte {
+	// Build the header
+	b := []byte{eofFormatByte, eofMagicByte, eof1Version}
+	b = append(b, kindTypes)
+	b = appendUint16(b, uint16(len(c.Types)*4))
+	b = append(b, kindCode)
+	b = appendUint16(b, uint16(len(c.Code)))
+	for _, code := range c.Code {
+		b = appendUint16(b, uint16(len(code)))
+	}
+	b = append(b, kindData)
+	b = appendUint16(b, uint16(len(c.Data)))
+	b = append(b, kindTerminator)
+
+	// Write the sections
+	for _, ty := range c.Types {
+		b = append(b, ty.Input, ty.Output)
+		b = appendUint16(b, ty.MaxStackHeight)
+	}
+	for _, code := range c.Code {
+		b = append(b, code...)
+	}
+	return append(b, c.Data...)
+}
+
+// UnmarshalBinary decodes an EOF container, checking the structure of the
+// header and the types, but not the code (see validateCode).
+func (c *Container) UnmarshalBinary(b []byte) error {
+	if !hasEOFMagic(b) {
+		return fmt.Errorf("%w: want %x", ErrInvalidMagic, []byte{eofFormatByte, eofMagicByte})
+	}
+	if len(b) < 3 || b[2] != eof1Version {
+		return ErrInvalidVersion
+	}
+	// Parse the type section header
+	kind, typesSize, err := parseSection(b, 3)
+	if err != nil || kind != kindTypes {
+		return ErrMissingTypeHeader
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return fmt.Errorf("%w: type section size must be divisible by 4, have %d", ErrInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return fmt.Errorf("%w: type section size %d exceeds limit", ErrInvalidTypeSize, typesSize)
+	}
+	// Parse the code section header
+	kind, codeSizes, err := parseSectionList(b, 6)
+	if errors.Is(err, ErrInvalidCodeHeader) {
+		return err
+	}
+	if err != nil || kind != kindCode {
+		return ErrMissingCodeHeader
+	}
+	if len(codeSizes) != typesSize/4 {
+		return fmt.Errorf("%w: mismatch of code sections count and type signatures, types %d, code %d", ErrInvalidCodeHeader, typesSize/4, len(codeSizes))
+	}
+	// Parse the data section header
+	offset := 6 + 3 + 2*len(codeSizes)
+	kind, dataSize, err := parseSection(b, offset)
+	if err != nil || kind != kindData {
+		return ErrMissingDataHeader
+	}
+	offset += 3
+	if len(b) <= offset || b[offset] != kindTerminator {
+		return ErrMissingTerminator
+	}
+	offset++
+
+	// Check the total size before reading the sections
+	expected := offset + typesSize + dataSize
+	for _, size := range codeSizes {
+		expected += size
+	}
+	if len(b) != expected {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidContainerSize, len(b), expected)
+	}
+	// Parse the types section
+	types := make([]*FunctionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &FunctionMetadata{
+			Input:          b[offset+i*4],
+			Output:         b[offset+i*4+1],
+			MaxStackHeight: binary.BigEndian.Uint16(b[offset+i*4+2:]),
+		}
+		if sig.Input > maxInputItems {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyInputs, i, sig.Input)
+		}
+		if sig.Output > maxOutputItems {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyOutputs, i, sig.Output)
+		}
+		if sig.MaxStackHeight > maxStackHeight {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooLargeMaxStackHeight, i, sig.MaxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].Input != 0 || types[0].Output != 0 {
+		return fmt.Errorf("%w: have %d, %d", ErrInvalidSection0Type, types[0].Input, types[0].Output)
+	}
+	offset += typesSize
+
+	// Parse the code sections
+	code := make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		if size == 0 {
+			return fmt.Errorf("%w for section %d: size must not be 0", ErrInvalidCodeSize, i)
+		}
+		code[i] = b[offset : offset+size]
+		offset += size
+	}
+	c.Types, c.Code, c.Data = types, code, b[offset:offset+dataSize]
+	return nil
+}
+
+// validateCode validates the code sections of the container against the given
+// jump table, per EIP-3670, EIP-4200, EIP-4750 and EIP-5450.
+func (c *Container) validateCode(jt *JumpTable) error {
+	for i, code := range c.Code {
+		if err := validateCode(code, i, c.Types, jt); err != nil {
+			return fmt.Errorf("section %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ParseEOF decodes an EOF container and validates its code against the EOF
+// instruction set.
+func ParseEOF(code []byte) (*Container, error) {
+	c := new(Container)
+	if err := c.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := c.validateCode(&eofInstructionSet); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:])), nil
+}
+
+// parseSectionList decodes a (kind, len, []codeSize) section list from an EOF
+// header.
+func parseSectionList(b []byte, idx int) (kind int, list []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	kind, count := int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:]))
+	if count == 0 || count > maxCodeSections {
+		return 0, nil, fmt.Errorf("%w: invalid number of code sections %d", ErrInvalidCodeHeader, count)
+	}
+	idx += 3
+	if idx+2*count > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	list = make([]int, count)
+	for i := range list {
+		list[i] = int(binary.BigEndian.Uint16(b[idx+2*i:]))
+	}
+	return kind, list, nil
+}
+
+// appendUint16 appends the big endian encoding of v to b.
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
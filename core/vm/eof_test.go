@@ -0,0 +1,260 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, test := range []Container{
+		{
+			Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			Code:  [][]byte{common.Hex2Bytes("604200")},
+			Data:  []byte{0x01, 0x02, 0x03},
+		},
+		{
+			Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			Code:  [][]byte{common.Hex2Bytes("604200")},
+			Data:  []byte{},
+		},
+		{
+			Types: []*FunctionMetadata{
+				{Input: 0, Output: 0, MaxStackHeight: 1},
+				{Input: 2, Output: 3, MaxStackHeight: 4},
+				{Input: 1, Output: 1, MaxStackHeight: 1},
+			},
+			Code: [][]byte{
+				common.Hex2Bytes("604200"),
+				common.Hex2Bytes("6042604200"),
+				common.Hex2Bytes("00"),
+			},
+			Data: []byte{},
+		},
+	} {
+		var (
+			b   = test.MarshalBinary()
+			got Container
+		)
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("test %d: failed to unmarshal binary: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test) {
+			t.Fatalf("test %d: round-trip mismatch: have %+v, want %+v", i, got, test)
+		}
+	}
+}
+
+func TestEOFUnmarshalInvalid(t *testing.T) {
+	for i, test := range []struct {
+		code string
+		err  error
+	}{
+		{"", ErrInvalidMagic},
+		{"ef01", ErrInvalidMagic},
+		{"ef0002", ErrInvalidVersion},
+		{"ef0001", ErrMissingTypeHeader},
+		{"ef0001020004", ErrMissingTypeHeader},
+		{"ef0001010003", ErrInvalidTypeSize},
+		{"ef000101000403000100", ErrMissingCodeHeader},
+		{"ef00010100040200000300000000000000", ErrInvalidCodeHeader},
+		{"ef000101000802000100010300000000000000", ErrInvalidCodeHeader},
+		{"ef000101000402000100010400000000000000", ErrMissingDataHeader},
+		{"ef000101000402000100010300000100000000", ErrMissingTerminator},
+		{"ef000101000402000100010300000000000000", ErrInvalidContainerSize},
+		{"ef0001010004020001000103000000000000000000", ErrInvalidContainerSize},
+		{"ef00010100040200010001030000000100000000", ErrInvalidSection0Type},
+		{"ef00010100040200010001030000000000040000", ErrTooLargeMaxStackHeight},
+		{"ef00010100080200020001000003000000000000000000000000", ErrInvalidCodeSize},
+	} {
+		var c Container
+		if err := c.UnmarshalBinary(common.FromHex(test.code)); !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+// newEOFEVM creates an EVM with EOF activated.
+func newEOFEVM() *EVM {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	config := *params.AllEthashProtocolChanges
+	config.EOFBlock = big.NewInt(0)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	return NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+}
+
+func TestEOFExecution(t *testing.T) {
+	// The runtime code doubles 5 in a function and returns the result
+	runtime := &Container{
+		Types: []*FunctionMetadata{
+			{Input: 0, Output: 0, MaxStackHeight: 2},
+			{Input: 1, Output: 1, MaxStackHeight: 2},
+		},
+		Code: [][]byte{
+			common.Hex2Bytes("6005b0000160005260206000f3"), // PUSH1 5, CALLF 1, PUSH1 0, MSTORE, PUSH1 32, PUSH1 0, RETURN
+			common.Hex2Bytes("805d0001b18001b1"),           // DUP1, RJUMPI 1, RETF, DUP1, ADD, RETF
+		},
+		Data: []byte{},
+	}
+	code := runtime.MarshalBinary()
+
+	// The initcode copies the runtime code from its data section, which starts
+	// after the 15 bytes of header, 4 bytes of types and 15 bytes of code
+	size := []byte{byte(len(code) >> 8), byte(len(code))}
+	initcode := &Container{
+		Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 3}},
+		Code: [][]byte{{
+			byte(PUSH2), size[0], size[1], byte(PUSH2), 0, 34, byte(PUSH1), 0, byte(CODECOPY),
+			byte(PUSH2), size[0], size[1], byte(PUSH1), 0, byte(RETURN),
+		}},
+		Data: code,
+	}
+	evm := newEOFEVM()
+	ret, addr, _, err := evm.Create(AccountRef(common.Address{}), initcode.MarshalBinary(), 1000000, new(big.Int))
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !reflect.DeepEqual(ret, code) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", ret, code)
+	}
+	ret, _, err = evm.Call(AccountRef(common.Address{}), addr, nil, 1000000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := new(big.Int).SetBytes(ret); have.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("result mismatch: have %v, want 10", have)
+	}
+}
+
+func TestEOFCreationInvalid(t *testing.T) {
+	for i, test := range []struct {
+		initcode string
+		err      error
+	}{
+		// EOF initcode with an undefined instruction
+		{"ef00010100040200010002030000000000000000" + "0c00", ErrInvalidEOF},
+		// Legacy initcode deploying invalid EOF code: RETURN(0, 2) of ef00
+		{"61ef0060f01b60005260026000f3", ErrInvalidEOF},
+		// EOF initcode deploying legacy code: RETURN(0, 1) of 00
+		{"ef00010100040200010005030000000000000260016000f3", ErrLegacyCode},
+		// EOF opcodes are not defined in legacy code
+		{"5c000000", &ErrInvalidOpCode{}},
+	} {
+		_, _, _, err := newEOFEVM().Create(AccountRef(common.Address{}), common.FromHex(test.initcode), 1000000, new(big.Int))
+		if target := new(*ErrInvalidOpCode); errors.As(test.err, target) {
+			if !errors.As(err, target) {
+				t.Errorf("test %d: error mismatch: have %v, want invalid opcode", i, err)
+			}
+			continue
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestEOFCallInvalid(t *testing.T) {
+	for i, code := range [][]byte{
+		// Truncated RJUMP immediate
+		(&Container{
+			Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			Code:  [][]byte{common.Hex2Bytes("5c00")},
+			Data:  []byte{},
+		}).MarshalBinary(),
+		// CALLF to a missing code section
+		(&Container{
+			Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			Code:  [][]byte{common.Hex2Bytes("b0000100")},
+			Data:  []byte{},
+		}).MarshalBinary(),
+	} {
+		// Invalid containers can't be deployed, but may be in the genesis
+		addr := common.BytesToAddress([]byte("contract"))
+		evm := newEOFEVM()
+		evm.StateDB.CreateAccount(addr)
+		evm.StateDB.SetCode(addr, code)
+
+		if _, _, err := evm.Call(AccountRef(common.Address{}), addr, nil, 1000000, new(big.Int)); !errors.Is(err, ErrInvalidEOF) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrInvalidEOF)
+		}
+	}
+}
+
+func TestEOFInactive(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	config := *params.AllEthashProtocolChanges
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	// Without the EOF fork, EOF initcode is legacy code starting with 0xef
+	initcode := (&Container{
+		Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		Code:  [][]byte{common.Hex2Bytes("00")},
+		Data:  []byte{},
+	}).MarshalBinary()
+
+	evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+	_, _, _, err := evm.Create(AccountRef(common.Address{}), initcode, 1000000, new(big.Int))
+	if target := new(*ErrInvalidOpCode); !errors.As(err, target) {
+		t.Errorf("error mismatch: have %v, want invalid opcode", err)
+	}
+}
+
+func TestEOFContainerCache(t *testing.T) {
+	code := (&Container{
+		Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		Code:  [][]byte{common.Hex2Bytes("00")},
+		Data:  []byte{},
+	}).MarshalBinary()
+
+	evm := newEOFEVM()
+	parent := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{1}), new(big.Int), 0)
+	parent.SetCallCode(&common.Address{1}, crypto.Keccak256Hash(code), code)
+
+	first, err := evm.contractContainer(parent)
+	if err != nil {
+		t.Fatalf("failed to validate container: %v", err)
+	}
+	// Nested calls into the same code reuse the validated container
+	child := NewContract(parent, AccountRef(common.Address{2}), new(big.Int), 0)
+	child.SetCallCode(&common.Address{2}, crypto.Keccak256Hash(code), code)
+
+	second, err := evm.contractContainer(child)
+	if err != nil {
+		t.Fatalf("failed to validate container: %v", err)
+	}
+	if first != second || len(parent.containers) != 1 {
+		t.Errorf("container not reused: %p != %p, %d cached", first, second, len(parent.containers))
+	}
+}
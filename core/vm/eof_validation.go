@@ -0,0 +1,198 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// validateCode validates the code of an EOF code section:
+//   - all instructions are defined, and not deprecated by EOF (EIP-3670)
+//   - the immediates of the instructions are not truncated (EIP-3670)
+//   - the code ends with a terminating instruction (EIP-3670)
+//   - relative jumps target instructions within the section (EIP-4200)
+//   - CALLF targets an existing section (EIP-4750)
+//   - the stack heights are consistent and match the type (EIP-5450)
+func validateCode(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable) error {
+	var (
+		i        = 0
+		count    = 0 // Number of instructions
+		op       OpCode
+		analysis = make(bitvec, len(code)/8+1+4) // Immediate bytes, see codeSegment
+		targets  []int
+	)
+	for i < len(code) {
+		count++
+		op = OpCode(code[i])
+		if jt[op].undefined && op != INVALID {
+			return fmt.Errorf("%w: op %s, pos %d", ErrUndefinedInstruction, op, i)
+		}
+		switch op {
+		case CALLCODE, SELFDESTRUCT, JUMP, JUMPI, PC:
+			return fmt.Errorf("%w: op %s is deprecated in EOF, pos %d", ErrUndefinedInstruction, op, i)
+		}
+		size := immediateSize(code, i)
+		if i+size >= len(code) {
+			return fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+		}
+		switch op {
+		case RJUMP, RJUMPI:
+			targets = append(targets, i+3+int(int16(binary.BigEndian.Uint16(code[i+1:]))))
+		case RJUMPV:
+			branches := int(code[i+1])
+			if branches == 0 {
+				return fmt.Errorf("%w: pos %d", ErrInvalidBranchCount, i)
+			}
+			for j := 0; j < branches; j++ {
+				targets = append(targets, i+size+1+int(int16(binary.BigEndian.Uint16(code[i+2+2*j:]))))
+			}
+		case CALLF:
+			if arg := int(binary.BigEndian.Uint16(code[i+1:])); arg >= len(metadata) {
+				return fmt.Errorf("%w: arg %d, last %d, pos %d", ErrInvalidSectionArgument, arg, len(metadata), i)
+			}
+		}
+		for j := 1; j <= size; j++ {
+			analysis.set1(uint64(i + j))
+		}
+		i += size + 1
+	}
+	// The code must end with a terminating instruction (or an unconditional jump)
+	if !terminalOp(op) && op != RJUMP {
+		return fmt.Errorf("%w: end with %s, pos %d", ErrInvalidCodeTermination, op, i)
+	}
+	// Relative jumps must target an instruction of the section
+	for _, target := range targets {
+		if target < 0 || target >= len(code) || !analysis.codeSegment(uint64(target)) {
+			return fmt.Errorf("%w: target %d", ErrInvalidJumpDest, target)
+		}
+	}
+	return validateControlFlow(code, section, metadata, jt, count)
+}
+
+// validateControlFlow checks that the stack height is the same at each
+// instruction whichever path reaches it, that it never underflows, that the
+// maximum height matches the declared one, and that every instruction is
+// reachable (EIP-5450).
+func validateControlFlow(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable, count int) error {
+	type item struct {
+		pos    int
+		height int
+	}
+	var (
+		heights        = make(map[int]int)
+		worklist       = []item{{0, int(metadata[section].Input)}}
+		maxStackHeight = int(metadata[section].Input)
+	)
+	for 0 < len(worklist) {
+		pos, height := worklist[len(worklist)-1].pos, worklist[len(worklist)-1].height
+		worklist = worklist[:len(worklist)-1]
+
+	outer:
+		for pos < len(code) {
+			op := OpCode(code[pos])
+
+			// Stop tracing the path if the instruction was already visited
+			if want, ok := heights[pos]; ok {
+				if height != want {
+					return fmt.Errorf("%w: have %d, want %d", ErrConflictingStack, height, want)
+				}
+				break
+			}
+			heights[pos] = height
+
+			// Check the stack requirements of the instruction
+			switch op {
+			case CALLF:
+				arg := binary.BigEndian.Uint16(code[pos+1:])
+				if want := int(metadata[arg].Input); height < want {
+					return fmt.Errorf("%w: at pos %d", &ErrStackUnderflow{stackLen: height, required: want}, pos)
+				}
+				height += int(metadata[arg].Output) - int(metadata[arg].Input)
+			case RETF:
+				if want := int(metadata[section].Output); height != want {
+					return fmt.Errorf("%w: have %d, want %d, at pos %d", ErrInvalidOutputs, height, want, pos)
+				}
+			default:
+				if want := jt[op].minStack; height < want {
+					return fmt.Errorf("%w: at pos %d", &ErrStackUnderflow{stackLen: height, required: want}, pos)
+				}
+				// maxStack is StackLimit + pops - pushes, see maxStack
+				height += int(params.StackLimit) - jt[op].maxStack
+			}
+			if height > maxStackHeight {
+				maxStackHeight = height
+			}
+			// Follow the control flow of the instruction
+			switch {
+			case op == RJUMP:
+				pos += 3 + int(int16(binary.BigEndian.Uint16(code[pos+1:])))
+			case op == RJUMPI:
+				target := pos + 3 + int(int16(binary.BigEndian.Uint16(code[pos+1:])))
+				worklist = append(worklist, item{target, height})
+				pos += 3
+			case op == RJUMPV:
+				branches := int(code[pos+1])
+				next := pos + 2 + 2*branches
+				for i := 0; i < branches; i++ {
+					target := next + int(int16(binary.BigEndian.Uint16(code[pos+2+2*i:])))
+					worklist = append(worklist, item{target, height})
+				}
+				pos = next
+			case terminalOp(op):
+				break outer
+			default:
+				pos += immediateSize(code, pos) + 1
+			}
+		}
+	}
+	if maxStackHeight != int(metadata[section].MaxStackHeight) {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidMaxStackHeight, maxStackHeight, metadata[section].MaxStackHeight)
+	}
+	if len(heights) != count {
+		return fmt.Errorf("%w: %d of %d instructions reachable", ErrUnreachableCode, len(heights), count)
+	}
+	return nil
+}
+
+// immediateSize returns the size of the immediate data of the instruction at
+// the given position.
+func immediateSize(code []byte, pos int) int {
+	switch op := OpCode(code[pos]); {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op-PUSH1) + 1
+	case op == RJUMP, op == RJUMPI, op == CALLF:
+		return 2
+	case op == RJUMPV:
+		if pos+1 < len(code) {
+			return 1 + 2*int(code[pos+1])
+		}
+		return 1
+	}
+	return 0
+}
+
+// terminalOp returns whether the instruction ends the execution of a section.
+func terminalOp(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, RETF:
+		return true
+	}
+	return false
+}
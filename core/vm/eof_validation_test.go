@@ -0,0 +1,141 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCode(t *testing.T) {
+	for i, test := range []struct {
+		code     []byte
+		section  int
+		metadata []*FunctionMetadata
+		err      error
+	}{
+		{
+			code:     []byte{byte(CALLER), byte(POP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code:     []byte{byte(CALLF), 0x00, 0x00, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		},
+		{
+			code:     []byte{byte(ADDRESS), byte(CALLF), 0x00, 0x00, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code:     []byte{byte(CALLER), byte(POP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidCodeTermination,
+		},
+		{
+			code:     []byte{byte(RJUMP), 0x00, 0x01, byte(CALLER), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrUnreachableCode,
+		},
+		{
+			code:     []byte{byte(PUSH1), 0x42, byte(ADD), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      &ErrStackUnderflow{},
+		},
+		{
+			code:     []byte{byte(PUSH1), 0x42, byte(POP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+			err:      ErrInvalidMaxStackHeight,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(RJUMPI), 0x00, 0x01, byte(PUSH1), 0x42, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidJumpDest,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(RJUMPI), 0x00, 0x01, byte(PUSH0), byte(PUSH0), byte(RJUMP), 0xff, 0xf7},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+			err:      ErrConflictingStack,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(RJUMPV), 0x00, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidBranchCount,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(RJUMPV), 0x02, 0x00, 0x01, 0x00, 0x02, byte(PUSH0), byte(STOP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+			err:      ErrConflictingStack,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(RJUMPV), 0x02, 0x00, 0x01, 0x00, 0x02, byte(STOP), byte(STOP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code:     []byte{byte(PUSH2), 0x42},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrTruncatedImmediate,
+		},
+		{
+			code:     []byte{byte(PUSH0), byte(JUMP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrUndefinedInstruction,
+		},
+		{
+			code:     []byte{0x0c, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrUndefinedInstruction,
+		},
+		{
+			code:     []byte{byte(CALLF), 0x00, 0x01, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrInvalidSectionArgument,
+		},
+		{
+			code:    []byte{byte(ADD), byte(RETF)},
+			section: 1,
+			metadata: []*FunctionMetadata{
+				{Input: 0, Output: 0, MaxStackHeight: 0},
+				{Input: 2, Output: 1, MaxStackHeight: 2},
+			},
+		},
+		{
+			code:    []byte{byte(RETF)},
+			section: 1,
+			metadata: []*FunctionMetadata{
+				{Input: 0, Output: 0, MaxStackHeight: 0},
+				{Input: 2, Output: 1, MaxStackHeight: 2},
+			},
+			err: ErrInvalidOutputs,
+		},
+	} {
+		err := validateCode(test.code, test.section, test.metadata, &eofInstructionSet)
+		switch want := test.err.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+		case *ErrStackUnderflow:
+			if !errors.As(err, &want) {
+				t.Errorf("test %d: have error %v, want %T", i, err, want)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("test %d: have error %v, want %v", i, err, want)
+			}
+		}
+	}
+}
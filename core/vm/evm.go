@@ -19,6 +19,7 @@ package vm
 //Read https://ethereum.org/en/developers/docs/evm/ for more info on EVM #CyberSecLab
 
 import (
+	"fmt"
 	"math/big"
 	"sync/atomic"
 	"time"
@@ -44,6 +45,11 @@ type (
 )
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
+	if len(evm.chainRules.Experimental) > 0 {
+		if p, _, ok := extensionPrecompile(evm.chainRules.Experimental, addr); ok {
+			return p, true
+		}
+	}
 	var precompiles map[common.Address]PrecompiledContract
 	switch {
 	case evm.chainRules.IsBerlin:
@@ -56,9 +62,40 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 		precompiles = PrecompiledContractsHomestead
 	}
 	p, ok := precompiles[addr]
+	if ok && evm.chainConfig.GasSchedule != nil {
+		if gas := evm.chainConfig.GasSchedule.Precompiles[addr]; gas != nil {
+			p = &repricedPrecompile{PrecompiledContract: p, gas: gas}
+		}
+	}
 	return p, ok
 }
 
+// runPrecompiledContract runs a precompiled contract, notifying the tracer of the
+// invocation if it's interested in precompiles.
+func (evm *EVM) runPrecompiledContract(addr common.Address, p PrecompiledContract, input []byte, suppliedGas uint64) (ret []byte, remainingGas uint64, err error) {
+	ret, remainingGas, err = RunPrecompiledContract(p, input, suppliedGas)
+	if evm.Config.Debug {
+		if tracer, ok := evm.Config.Tracer.(PrecompileLogger); ok {
+			name := precompiledContractNames[addr]
+			if _, ext, ok := extensionPrecompile(evm.chainRules.Experimental, addr); ok {
+				name = ext
+			}
+			tracer.CapturePrecompile(addr, name, input, ret, p.RequiredGas(input), err)
+		}
+	}
+	return ret, remainingGas, err
+}
+
+// captureTransfer notifies the tracer of a value transfer if it's interested in
+// transfers.
+func (evm *EVM) captureTransfer(from common.Address, to common.Address, value *big.Int) {
+	if evm.Config.Debug && value.Sign() != 0 {
+		if tracer, ok := evm.Config.Tracer.(TransferLogger); ok {
+			tracer.CaptureTransfer(from, to, value, evm.depth)
+		}
+	}
+}
+
 // BlockContext provides the EVM with auxiliary information. Once provided
 // it shouldn't be modified.
 type BlockContext struct {
@@ -110,6 +147,8 @@ type EVM struct {
 	chainConfig *params.ChainConfig
 	// chain rules contains the chain rules for the current epoch
 	chainRules params.Rules
+	// gasCosts contains the gas costs charged, overridable by the chain config
+	gasCosts GasCosts
 	// virtual machine configuration options used to initialise the
 	// evm.
 	Config Config
@@ -135,6 +174,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		Config:      config,
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil),
+		gasCosts:    newGasCosts(chainConfig.GasSchedule),
 	}
 	evm.interpreter = NewEVMInterpreter(evm, config)
 	return evm
@@ -196,6 +236,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		evm.StateDB.CreateAccount(addr)
 	}
 	evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value)
+	evm.captureTransfer(caller.Address(), addr, value)
 
 	// Capture the tracer start/end events in debug mode
 	if evm.Config.Debug {
@@ -214,7 +255,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	}
 
 	if isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(addr, p, input, gas)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -277,7 +318,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(addr, p, input, gas)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
@@ -318,7 +359,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(addr, p, input, gas)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and make initialise the delegate values
@@ -367,7 +408,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(addr, p, input, gas)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
 		// leak the 'contract' to the outer scope, and make allocation for 'contract'
@@ -436,6 +477,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		evm.StateDB.SetNonce(address, 1)
 	}
 	evm.Context.Transfer(evm.StateDB, caller.Address(), address, value)
+	evm.captureTransfer(caller.Address(), address, value)
 
 	// Initialise a new contract and set the code that is to be used by the EVM.
 	// The contract is a scoped environment for this execution context only.
@@ -452,16 +494,36 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 	start := time.Now()
 
-	ret, err := evm.interpreter.Run(contract, nil, false)
+	// Validate the initcode if it's an EOF container (EIP-3540, EIP-3670).
+	var (
+		ret []byte
+		err error
+
+		isInitcodeEOF = evm.chainRules.IsEOF && hasEOFMagic(codeAndHash.code)
+	)
+	if isInitcodeEOF {
+		contract.Container, err = evm.parseContainer(codeAndHash.code)
+	}
+	if err == nil {
+		ret, err = evm.interpreter.Run(contract, nil, false)
+	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
 	if err == nil && evm.chainRules.IsEIP158 && len(ret) > params.MaxCodeSize {
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
-		err = ErrInvalidCode
+	// Reject code starting with 0xEF if EIP-3541 is enabled, unless it's a
+	// valid EOF container. EOF initcode must deploy EOF code.
+	if err == nil && hasEOFByte(ret) && evm.chainRules.IsLondon {
+		if !evm.chainRules.IsEOF {
+			err = ErrInvalidCode
+		} else {
+			_, err = evm.parseContainer(ret)
+		}
+	}
+	if err == nil && isInitcodeEOF && !hasEOFMagic(ret) {
+		err = ErrLegacyCode
 	}
 
 	// if the contract creation ran successfully and no errors were returned
@@ -497,6 +559,40 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	return ret, address, contract.Gas, err
 }
 
+// parseContainer decodes and validates an EOF container against the active
+// instruction set.
+func (evm *EVM) parseContainer(code []byte) (*Container, error) {
+	container := new(Container)
+	if err := container.UnmarshalBinary(code); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEOF, err)
+	}
+	if err := container.validateCode(evm.interpreter.cfg.JumpTable); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEOF, err)
+	}
+	return container, nil
+}
+
+// containerResult is the outcome of decoding and validating an EOF container.
+type containerResult struct {
+	container *Container
+	err       error
+}
+
+// contractContainer returns the validated EOF container of the code of a
+// contract, reusing the validation of the same code within the call tree.
+func (evm *EVM) contractContainer(contract *Contract) (*Container, error) {
+	if contract.CodeHash == (common.Hash{}) || contract.containers == nil {
+		return evm.parseContainer(contract.Code)
+	}
+	res, ok := contract.containers[contract.CodeHash]
+	if !ok {
+		res = new(containerResult)
+		res.container, res.err = evm.parseContainer(contract.Code)
+		contract.containers[contract.CodeHash] = res
+	}
+	return res.container, res.err
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
@@ -515,3 +611,7 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
+
+// GasCosts returns the gas costs charged by the environment, with the overrides
+// of the chain's gas schedule applied.
+func (evm *EVM) GasCosts() GasCosts { return evm.gasCosts }
@@ -0,0 +1,207 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// ExperimentalOp defines an opcode added (or redefined) by an experimental
+// extension.
+type ExperimentalOp struct {
+	Name string // Mnemonic of the opcode, must not clash with other opcodes
+
+	// Execute runs the opcode, in the same way as the builtin instructions.
+	Execute func(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error)
+
+	ConstantGas uint64 // Gas charged before running the opcode
+
+	// DynamicGas optionally computes the gas charged on top of the constant gas,
+	// given the memory size required by the opcode (see MemorySize). It must
+	// charge for the memory expansion, e.g. with MemoryGasCost.
+	DynamicGas func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error)
+
+	// MemorySize optionally returns the memory size needed by the opcode, the
+	// memory being expanded before the opcode runs. It requires DynamicGas.
+	MemorySize func(stack *Stack) (size uint64, overflow bool)
+
+	Pops   int // Number of stack items consumed by the opcode
+	Pushes int // Number of stack items produced by the opcode
+}
+
+// Extension is a named set of experimental opcodes and precompiled contracts,
+// prototyping a protocol change without modifying the builtin instruction sets.
+// Extensions are registered by Go packages via RegisterExtension, and enabled
+// per chain through the Experimental field of params.ChainConfig.
+type Extension struct {
+	Name        string
+	Opcodes     map[OpCode]*ExperimentalOp
+	Precompiles map[common.Address]PrecompiledContract
+}
+
+// extensions are the experimental extensions registered, by name.
+var extensions = make(map[string]*Extension)
+
+// RegisterExtension registers an experimental extension, to be activated by
+// chains listing it in their config. The opcodes of an extension override the
+// builtin ones with the same value, as do its precompiles.
+//
+// RegisterExtension is not safe for concurrent use with the EVM, and must be
+// called from package init functions.
+func RegisterExtension(ext *Extension) error {
+	if ext.Name == "" {
+		return errors.New("missing extension name")
+	}
+	if _, ok := extensions[ext.Name]; ok {
+		return fmt.Errorf("extension %q already registered", ext.Name)
+	}
+	for code, op := range ext.Opcodes {
+		if op.Execute == nil {
+			return fmt.Errorf("opcode %#x: missing execute function", int(code))
+		}
+		if op.MemorySize != nil && op.DynamicGas == nil {
+			return fmt.Errorf("opcode %#x: memory size requires a dynamic gas function", int(code))
+		}
+		if op.Pops < 0 || op.Pushes < 0 {
+			return fmt.Errorf("opcode %#x: invalid stack bounds", int(code))
+		}
+		if op.Name == "" {
+			return fmt.Errorf("opcode %#x: missing name", int(code))
+		}
+		if other, ok := stringToOp[op.Name]; ok && other != code {
+			return fmt.Errorf("opcode %#x: name %s already used by %#x", int(code), op.Name, int(other))
+		}
+	}
+	// Name the new opcodes, for the traces and the disassembler
+	for code, op := range ext.Opcodes {
+		if _, ok := opCodeToString[code]; !ok {
+			opCodeToString[code] = op.Name
+			stringToOp[op.Name] = code
+		}
+	}
+	extensions[ext.Name] = ext
+	return nil
+}
+
+// MustRegisterExtension registers an experimental extension, panicking if it's
+// invalid. It's meant to be called from package init functions.
+func MustRegisterExtension(ext *Extension) {
+	if err := RegisterExtension(ext); err != nil {
+		panic(fmt.Sprintf("invalid extension: %v", err))
+	}
+}
+
+// ValidExtension returns whether an experimental extension is registered by the
+// given name.
+func ValidExtension(name string) bool {
+	_, ok := extensions[name]
+	return ok
+}
+
+// ValidateExtensions checks that all the experimental extensions activated by a
+// chain configuration are registered. A node missing one of them would otherwise
+// run without it and fork off the chain.
+func ValidateExtensions(experimental map[string]*big.Int) error {
+	names := make([]string, 0, len(experimental))
+	for name := range experimental {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !ValidExtension(name) {
+			return fmt.Errorf("undefined experimental extension %q", name)
+		}
+	}
+	return nil
+}
+
+// Extensions returns the sorted names of the registered experimental extensions.
+func Extensions() []string {
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// enableExtension adds the opcodes of the named extension to the jump table.
+// This operation writes in-place, and callers need to ensure that the globally
+// defined jump tables are not polluted.
+func enableExtension(name string, jt *JumpTable) error {
+	ext, ok := extensions[name]
+	if !ok {
+		return fmt.Errorf("undefined extension %q", name)
+	}
+	for code, op := range ext.Opcodes {
+		jt[code] = &operation{
+			execute:     op.Execute,
+			constantGas: op.ConstantGas,
+			dynamicGas:  op.DynamicGas,
+			memorySize:  op.MemorySize,
+			minStack:    minStack(op.Pops, op.Pushes),
+			maxStack:    maxStack(op.Pops, op.Pushes),
+		}
+	}
+	return nil
+}
+
+// extensionPrecompile returns the precompiled contract defined at the address
+// by the given active extensions, along with the name of the extension.
+func extensionPrecompile(names []string, addr common.Address) (PrecompiledContract, string, bool) {
+	for _, name := range names {
+		if ext, ok := extensions[name]; ok {
+			if p, ok := ext.Precompiles[addr]; ok {
+				return p, name, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// MemoryGasCost calculates the quadratic gas cost of expanding the memory to
+// the given size, for use by the dynamic gas functions of experimental opcodes.
+func MemoryGasCost(evm *EVM, mem *Memory, newMemSize uint64) (uint64, error) {
+	return memoryGasCost(evm, mem, newMemSize)
+}
+
+// EVM returns the EVM the interpreter runs in.
+func (in *EVMInterpreter) EVM() *EVM {
+	return in.evm
+}
+
+// Push pushes a value on top of the stack. The stack bounds of the opcode
+// are checked by the interpreter before it runs.
+func (st *Stack) Push(d *uint256.Int) {
+	st.push(d)
+}
+
+// Pop removes and returns the value on top of the stack.
+func (st *Stack) Pop() uint256.Int {
+	return st.pop()
+}
+
+// Len returns the number of values on the stack.
+func (st *Stack) Len() int {
+	return st.len()
+}
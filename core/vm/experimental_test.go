@@ -0,0 +1,174 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// reversePrecompile is a test precompile returning its input reversed.
+type reversePrecompile struct{}
+
+func (reversePrecompile) RequiredGas(input []byte) uint64 { return 100 }
+
+func (reversePrecompile) Run(input []byte) ([]byte, error) {
+	output := make([]byte, len(input))
+	for i, b := range input {
+		output[len(input)-1-i] = b
+	}
+	return output, nil
+}
+
+var (
+	testExtensionOp          = OpCode(0x0c)
+	testExtensionPrecompile  = common.BytesToAddress([]byte{0x01, 0x00})
+	testExtensionContract    = common.BytesToAddress([]byte("contract"))
+	testExtensionContractHex = "0x60150c60005260206000f3" // PUSH1 21, DOUBLE, PUSH1 0, MSTORE, PUSH1 32, PUSH1 0, RETURN
+)
+
+func init() {
+	MustRegisterExtension(&Extension{
+		Name: "test",
+		Opcodes: map[OpCode]*ExperimentalOp{
+			testExtensionOp: {
+				Name: "DOUBLE",
+				Execute: func(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+					x := scope.Stack.Pop()
+					scope.Stack.Push(x.Add(&x, &x))
+					return nil, nil
+				},
+				ConstantGas: 5,
+				Pops:        1,
+				Pushes:      1,
+			},
+		},
+		Precompiles: map[common.Address]PrecompiledContract{
+			testExtensionPrecompile: reversePrecompile{},
+		},
+	})
+}
+
+// newExtensionEVM creates an EVM running the test contract, with the test
+// extension enabled if requested.
+func newExtensionEVM(enabled bool) *EVM {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(testExtensionContract)
+	statedb.SetCode(testExtensionContract, hexutil.MustDecode(testExtensionContractHex))
+
+	config := *params.AllEthashProtocolChanges
+	if enabled {
+		config.Experimental = map[string]*big.Int{"test": big.NewInt(0)}
+	}
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	return NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+}
+
+func TestExtensionOpcode(t *testing.T) {
+	// The opcode runs with the extension enabled
+	ret, gas, err := newExtensionEVM(true).Call(AccountRef(common.Address{}), testExtensionContract, nil, 100000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := new(big.Int).SetBytes(ret); have.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("result mismatch: have %v, want 42", have)
+	}
+	if used := 100000 - gas; used != 23 {
+		t.Errorf("gas used mismatch: have %d, want 23", used)
+	}
+	if testExtensionOp.String() != "DOUBLE" || StringToOp("DOUBLE") != testExtensionOp {
+		t.Errorf("opcode name mismatch: have %v", testExtensionOp)
+	}
+	// The opcode is invalid otherwise, and the builtin tables are untouched
+	_, _, err = newExtensionEVM(false).Call(AccountRef(common.Address{}), testExtensionContract, nil, 100000, new(big.Int))
+	if !errors.As(err, new(*ErrInvalidOpCode)) {
+		t.Errorf("error mismatch: have %v, want invalid opcode", err)
+	}
+}
+
+func TestExtensionPrecompile(t *testing.T) {
+	input := []byte{1, 2, 3}
+
+	// The precompile runs with the extension enabled
+	evm := newExtensionEVM(true)
+	ret, gas, err := evm.Call(AccountRef(common.Address{}), testExtensionPrecompile, input, 1000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !bytes.Equal(ret, []byte{3, 2, 1}) {
+		t.Errorf("result mismatch: have %x, want 030201", ret)
+	}
+	if used := 1000 - gas; used != 100 {
+		t.Errorf("gas used mismatch: have %d, want 100", used)
+	}
+	var found bool
+	for _, addr := range ActivePrecompiles(evm.chainRules) {
+		found = found || addr == testExtensionPrecompile
+	}
+	if !found {
+		t.Errorf("precompile %v not active", testExtensionPrecompile)
+	}
+	// The address is a plain account otherwise
+	evm = newExtensionEVM(false)
+	if ret, _, err := evm.Call(AccountRef(common.Address{}), testExtensionPrecompile, input, 1000, new(big.Int)); err != nil || len(ret) != 0 {
+		t.Errorf("result mismatch: have %x, %v, want empty", ret, err)
+	}
+	for _, addr := range ActivePrecompiles(evm.chainRules) {
+		if addr == testExtensionPrecompile {
+			t.Errorf("precompile %v active", testExtensionPrecompile)
+		}
+	}
+}
+
+func TestRegisterExtensionInvalid(t *testing.T) {
+	execute := func(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) { return nil, nil }
+
+	tests := []*Extension{
+		{Name: ""},
+		{Name: "test"},
+		{Name: "noexec", Opcodes: map[OpCode]*ExperimentalOp{0x0d: {Name: "FOO"}}},
+		{Name: "noname", Opcodes: map[OpCode]*ExperimentalOp{0x0d: {Execute: execute}}},
+		{Name: "clash", Opcodes: map[OpCode]*ExperimentalOp{0x0d: {Name: "ADD", Execute: execute}}},
+		{Name: "nogas", Opcodes: map[OpCode]*ExperimentalOp{0x0d: {Name: "FOO", Execute: execute, MemorySize: memoryMLoad}}},
+	}
+	for i, ext := range tests {
+		if err := RegisterExtension(ext); err == nil {
+			t.Errorf("test %d: expected error registering %q", i, ext.Name)
+		}
+	}
+}
+
+func TestValidateExtensions(t *testing.T) {
+	if err := ValidateExtensions(map[string]*big.Int{"test": big.NewInt(0)}); err != nil {
+		t.Errorf("registered extension rejected: %v", err)
+	}
+	if err := ValidateExtensions(map[string]*big.Int{"test": big.NewInt(0), "missing": big.NewInt(10)}); err == nil {
+		t.Error("unregistered extension accepted")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// GasCosts are the gas costs charged by the gas functions, which may be
+// overridden by the gas schedule of the chain.
+type GasCosts struct {
+	Memory       uint64 // Linear coefficient of the memory expansion cost
+	QuadCoeffDiv uint64 // Divisor of the quadratic memory expansion cost
+	Copy         uint64 // Per word cost of the *COPY opcodes
+
+	ColdAccountAccess uint64 // EIP-2929 cold account access cost
+	ColdSload         uint64 // EIP-2929 cold storage slot access cost
+	WarmStorageRead   uint64 // EIP-2929 warm access cost
+}
+
+// defaultGasCosts are the builtin gas costs.
+var defaultGasCosts = GasCosts{
+	Memory:            params.MemoryGas,
+	QuadCoeffDiv:      params.QuadCoeffDiv,
+	Copy:              params.CopyGas,
+	ColdAccountAccess: params.ColdAccountAccessCostEIP2929,
+	ColdSload:         params.ColdSloadCostEIP2929,
+	WarmStorageRead:   params.WarmStorageReadCostEIP2929,
+}
+
+// newGasCosts returns the gas costs with the overrides of the schedule applied.
+func newGasCosts(schedule *params.GasSchedule) GasCosts {
+	costs := defaultGasCosts
+	if schedule == nil {
+		return costs
+	}
+	override := func(dst *uint64, src *uint64) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	override(&costs.Memory, schedule.MemoryGas)
+	override(&costs.QuadCoeffDiv, schedule.QuadCoeffDiv)
+	override(&costs.Copy, schedule.CopyGas)
+	override(&costs.ColdAccountAccess, schedule.ColdAccountAccessCost)
+	override(&costs.ColdSload, schedule.ColdSloadCost)
+	override(&costs.WarmStorageRead, schedule.WarmStorageReadCost)
+	return costs
+}
+
+// ValidateGasSchedule checks that the gas schedule only reprices opcodes known
+// to the EVM, so that chain configurations with misspelled opcodes are rejected
+// when loaded instead of being silently ignored.
+func ValidateGasSchedule(schedule *params.GasSchedule) error {
+	if schedule == nil {
+		return nil
+	}
+	_, err := scheduledOpcodes(schedule)
+	return err
+}
+
+// opcodeGas is the constant gas of an opcode repriced by a gas schedule.
+type opcodeGas struct {
+	op  OpCode
+	gas uint64
+}
+
+// scheduledOpcodes resolves the opcodes repriced by the schedule, in the order
+// of their names.
+func scheduledOpcodes(schedule *params.GasSchedule) ([]opcodeGas, error) {
+	names := make([]string, 0, len(schedule.Opcodes))
+	for name := range schedule.Opcodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ops := make([]opcodeGas, len(names))
+	for i, name := range names {
+		op, ok := stringToOp[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode %s", name)
+		}
+		ops[i] = opcodeGas{op, schedule.Opcodes[name]}
+	}
+	return ops, nil
+}
+
+// applyGasSchedule overrides the constant gas of the opcodes in the jump table
+// according to the schedule. This operation writes in-place, and callers need
+// to ensure that the globally defined jump tables are not polluted. The jump
+// table is left untouched if the schedule is invalid.
+func applyGasSchedule(jt *JumpTable, schedule *params.GasSchedule, rules params.Rules) error {
+	ops, err := scheduledOpcodes(schedule)
+	if err != nil {
+		return err
+	}
+	// The warm access cost is charged upfront by the account accessing opcodes
+	if schedule.WarmStorageReadCost != nil && rules.IsBerlin {
+		for _, op := range []OpCode{EXTCODECOPY, EXTCODESIZE, EXTCODEHASH, BALANCE, CALL, CALLCODE, STATICCALL, DELEGATECALL} {
+			jt[op].constantGas = *schedule.WarmStorageReadCost
+		}
+	}
+	for _, o := range ops {
+		jt[o.op].constantGas = o.gas
+	}
+	return nil
+}
+
+// repricedPrecompile is a precompiled contract priced by the gas schedule.
+type repricedPrecompile struct {
+	PrecompiledContract
+	gas *params.PrecompileGas
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (p *repricedPrecompile) RequiredGas(input []byte) uint64 {
+	return p.gas.Base + p.gas.Word*toWordSize(uint64(len(input)))
+}
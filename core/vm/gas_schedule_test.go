@@ -0,0 +1,142 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func newUint64(v uint64) *uint64 { return &v }
+
+func TestGasSchedule(t *testing.T) {
+	var (
+		contract = common.BytesToAddress([]byte("contract"))
+		identity = common.BytesToAddress([]byte{0x04})
+	)
+	tests := []struct {
+		code     string
+		addr     common.Address
+		input    []byte
+		schedule *params.GasSchedule
+		builtin  uint64
+		repriced uint64
+	}{
+		{ // PUSH1 1, PUSH1 2, ADD
+			code:     "0x6001600201",
+			schedule: &params.GasSchedule{Opcodes: map[string]uint64{"ADD": 10}},
+			builtin:  9,
+			repriced: 16,
+		},
+		{ // PUSH1 0, PUSH1 0, MSTORE
+			code:     "0x6000600052",
+			schedule: &params.GasSchedule{MemoryGas: newUint64(10)},
+			builtin:  12,
+			repriced: 19,
+		},
+		{ // PUSH1 64, PUSH1 0, PUSH1 0, CALLDATACOPY
+			code:     "0x60406000600037",
+			schedule: &params.GasSchedule{CopyGas: newUint64(10)},
+			builtin:  24,
+			repriced: 38,
+		},
+		{ // PUSH1 0xff, BALANCE, PUSH1 0xff, BALANCE
+			code: "0x60ff3160ff31",
+			schedule: &params.GasSchedule{
+				ColdAccountAccessCost: newUint64(1000),
+				WarmStorageReadCost:   newUint64(50),
+			},
+			builtin:  3 + 2600 + 3 + 100,
+			repriced: 3 + 1000 + 3 + 50,
+		},
+		{ // PUSH1 1, SLOAD, PUSH1 1, SLOAD
+			code:     "0x600154600154",
+			schedule: &params.GasSchedule{ColdSloadCost: newUint64(500)},
+			builtin:  3 + 2100 + 3 + 100,
+			repriced: 3 + 500 + 3 + 100,
+		},
+		{ // Identity precompile
+			addr:     identity,
+			input:    make([]byte, 64),
+			schedule: &params.GasSchedule{Precompiles: map[common.Address]*params.PrecompileGas{identity: {Base: 1, Word: 2}}},
+			builtin:  21,
+			repriced: 5,
+		},
+	}
+	for i, tt := range tests {
+		for _, schedule := range []*params.GasSchedule{nil, tt.schedule} {
+			statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+			addr := tt.addr
+			if tt.code != "" {
+				addr = contract
+				statedb.CreateAccount(addr)
+				statedb.SetCode(addr, hexutil.MustDecode(tt.code))
+			}
+			config := *params.AllEthashProtocolChanges
+			config.GasSchedule = schedule
+
+			vmctx := BlockContext{
+				CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+				Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+				BlockNumber: big.NewInt(0),
+			}
+			evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+			_, gas, err := evm.Call(AccountRef(common.Address{}), addr, tt.input, 100000, new(big.Int))
+			if err != nil {
+				t.Fatalf("test %d: call failed: %v", i, err)
+			}
+			want := tt.builtin
+			if schedule != nil {
+				want = tt.repriced
+			}
+			if used := 100000 - gas; used != want {
+				t.Errorf("test %d (schedule %v): gas used mismatch: have %d, want %d", i, schedule != nil, used, want)
+			}
+		}
+	}
+}
+
+// Tests that gas schedules repricing unknown opcodes are rejected without
+// modifying the jump table.
+func TestGasScheduleUnknownOpcode(t *testing.T) {
+	schedule := &params.GasSchedule{
+		Opcodes:             map[string]uint64{"ADD": 10, "MUL": 20, "NOTANOPCODE": 1, "SUB": 30},
+		WarmStorageReadCost: newUint64(50),
+	}
+	if err := ValidateGasSchedule(schedule); err == nil {
+		t.Fatal("expected error for unknown opcode")
+	}
+	jt := copyJumpTable(&londonInstructionSet)
+	if err := applyGasSchedule(jt, schedule, params.Rules{IsBerlin: true}); err == nil {
+		t.Fatal("expected error for unknown opcode")
+	}
+	for op := range jt {
+		if jt[op] != nil && jt[op].constantGas != londonInstructionSet[op].constantGas {
+			t.Errorf("opcode %v: constant gas modified: have %d, want %d", OpCode(op), jt[op].constantGas, londonInstructionSet[op].constantGas)
+		}
+	}
+	delete(schedule.Opcodes, "NOTANOPCODE")
+	if err := ValidateGasSchedule(schedule); err != nil {
+		t.Fatalf("failed to validate gas schedule: %v", err)
+	}
+}
@@ -41,6 +41,16 @@ type ScopeContext struct {
 	Memory   *Memory
 	Stack    *Stack
 	Contract *Contract
+
+	CodeSection uint64           // Index of the EOF code section being executed
+	ReturnStack []*ReturnContext // Return stack of the EOF function calls
+}
+
+// ReturnContext is the return point of an EOF function call, pushed by CALLF
+// and popped by RETF.
+type ReturnContext struct {
+	Section uint64 // Code section of the caller
+	Pc      uint64 // Position of the instruction following the call
 }
 
 // EVMInterpreter represents an EVM interpreter
@@ -60,6 +70,8 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// If jump table was not initialised we set the default one.
 	if cfg.JumpTable == nil {
 		switch {
+		case evm.chainRules.IsEOF:
+			cfg.JumpTable = &eofInstructionSet
 		case evm.chainRules.IsMerge:
 			cfg.JumpTable = &mergeInstructionSet
 		case evm.chainRules.IsLondon:
@@ -82,7 +94,8 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 			cfg.JumpTable = &frontierInstructionSet
 		}
 		var extraEips []int
-		if len(cfg.ExtraEips) > 0 {
+		schedule := evm.chainConfig.GasSchedule
+		if len(cfg.ExtraEips) > 0 || len(evm.chainRules.Experimental) > 0 || schedule != nil {
 			// Deep-copy jumptable to prevent modification of opcodes in other tables
 			cfg.JumpTable = copyJumpTable(cfg.JumpTable)
 		}
@@ -95,6 +108,17 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 			}
 		}
 		cfg.ExtraEips = extraEips
+
+		for _, name := range evm.chainRules.Experimental {
+			if err := enableExtension(name, cfg.JumpTable); err != nil {
+				log.Error("Extension activation failed", "name", name, "error", err)
+			}
+		}
+		if schedule != nil {
+			if err := applyGasSchedule(cfg.JumpTable, schedule, evm.chainRules); err != nil {
+				log.Error("Gas schedule activation failed", "error", err)
+			}
+		}
 	}
 
 	return &EVMInterpreter{
@@ -129,6 +153,15 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	// Decode and validate the EOF container of the code, unless done at creation
+	// already. Containers are validated at deployment, but invalid ones may still
+	// come from the genesis allocation or predate EOF, and are invalid code. The
+	// validation is shared within the call tree, like the JUMPDEST analysis.
+	if contract.Container == nil && in.evm.chainRules.IsEOF && hasEOFMagic(contract.Code) {
+		if contract.Container, err = in.evm.contractContainer(contract); err != nil {
+			return nil, err
+		}
+	}
 
 	var (
 		op          OpCode        // current opcode
@@ -149,6 +182,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		gasCopy uint64 // for EVMLogger to log gas remaining before execution
 		logged  bool   // deferred EVMLogger should ignore already logged steps
 		res     []byte // result of the opcode execution function
+		eof     = contract.Container != nil
 	)
 	// Don't move this deferred function, it's placed before the capturestate-deferred method,
 	// so that it get's executed _after_: the capturestate needs the stacks before
@@ -180,7 +214,11 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		}
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
-		op = contract.GetOp(pc)
+		if eof {
+			op = contract.GetOpInSection(callContext.CodeSection, pc)
+		} else {
+			op = contract.GetOp(pc)
+		}
 		operation := in.cfg.JumpTable[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
@@ -42,3 +42,21 @@ type EVMLogger interface {
 	CaptureState(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error)
 	CaptureFault(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error)
 }
+
+// PrecompileLogger is an optional extension of EVMLogger, notified of every
+// invocation of a precompiled contract, between the CaptureEnter and CaptureExit
+// (or CaptureStart and CaptureEnd) of the call. The gas is the price charged by
+// the precompile for the input, which may exceed the gas available if err is
+// ErrOutOfGas.
+type PrecompileLogger interface {
+	CapturePrecompile(addr common.Address, name string, input []byte, output []byte, gas uint64, err error)
+}
+
+// TransferLogger is an optional extension of EVMLogger, notified of every value
+// transfer: those of calls and creations, and the balances sent by self-destructs.
+// The depth is 0 for the value sent by the transaction itself, and the depth of
+// the transferring frame for internal transfers. Transfers are reported before
+// the receiving frame is entered, and those of frames reverted later on too.
+type TransferLogger interface {
+	CaptureTransfer(from common.Address, to common.Address, value *big.Int, depth int)
+}
@@ -33,7 +33,9 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -248,6 +250,60 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// PeerTxStats retrieves the transaction propagation statistics of the connected
+// and recently disconnected peers, keyed by peer ID.
+func (api *AdminAPI) PeerTxStats() map[string]fetcher.TxPeerStats {
+	return api.eth.handler.txFetcher.PeerStats()
+}
+
+// TxPoolAPI is the collection of transaction pool APIs only available on full
+// nodes.
+type TxPoolAPI struct {
+	eth *Ethereum
+}
+
+// NewTxPoolAPI creates a new TxPoolAPI instance.
+func NewTxPoolAPI(eth *Ethereum) *TxPoolAPI {
+	return &TxPoolAPI{eth: eth}
+}
+
+// Lifecycle creates a subscription that is triggered on every transition of a
+// transaction in the pool: admission, queueing, promotion, demotion, replacement,
+// drop and inclusion.
+func (api *TxPoolAPI) Lifecycle(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan txpool.TxLifecycleEvent, 128)
+		sub := api.eth.TxPool().SubscribeLifecycleEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Explain diagnoses the pooled transactions of an account, reporting any nonce
+// gaps and why each transaction is not executable or not being mined.
+func (api *TxPoolAPI) Explain(addr common.Address) *txpool.AccountExplanation {
+	return api.eth.TxPool().Explain(addr)
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -0,0 +1,187 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SendBundleArgs represents the arguments to submit a transaction bundle.
+type SendBundleArgs struct {
+	Txs         []hexutil.Bytes `json:"txs"`         // Signed transactions to include atomically, in order
+	BlockNumber hexutil.Uint64  `json:"blockNumber"` // Number of the block to include the bundle in
+}
+
+// CallBundleArgs represents the arguments to simulate a transaction bundle.
+type CallBundleArgs struct {
+	Txs              []hexutil.Bytes       `json:"txs"`              // Signed transactions to execute, in order
+	StateBlockNumber rpc.BlockNumberOrHash `json:"stateBlockNumber"` // Block on top of which to execute the bundle
+	Coinbase         *common.Address       `json:"coinbase"`         // Fee recipient of the simulated block (default = etherbase)
+	Timestamp        *hexutil.Uint64       `json:"timestamp"`        // Timestamp of the simulated block (default = parent + 1)
+}
+
+// CallBundleTxResult is the outcome of a single transaction of a simulated bundle.
+type CallBundleTxResult struct {
+	TxHash     common.Hash    `json:"txHash"`
+	From       common.Address `json:"from"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	ReturnData hexutil.Bytes  `json:"returnData,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// CallBundleResult is the outcome of a simulated bundle.
+type CallBundleResult struct {
+	BundleHash       common.Hash          `json:"bundleHash"`
+	StateBlockNumber hexutil.Uint64       `json:"stateBlockNumber"`
+	GasUsed          hexutil.Uint64       `json:"gasUsed"`
+	CoinbaseDiff     *hexutil.Big         `json:"coinbaseDiff"`
+	Results          []CallBundleTxResult `json:"results"`
+}
+
+// decodeBundle decodes and sender-checks the signed transactions of a bundle.
+func decodeBundle(signer types.Signer, raws []hexutil.Bytes) (types.Transactions, error) {
+	if len(raws) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+	txs := make(types.Transactions, 0, len(raws))
+	for i, raw := range raws {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if _, err := types.Sender(signer, tx); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// SendBundle submits an ordered batch of signed transactions to be included by
+// the local miner atomically at the top of the given block. The bundle is dropped
+// if any of its transactions fails or if the block is built without it.
+func (api *EthereumAPI) SendBundle(args SendBundleArgs) (common.Hash, error) {
+	txs, err := decodeBundle(types.LatestSigner(api.e.blockchain.Config()), args.Txs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return api.e.Miner().SendBundle(txs, uint64(args.BlockNumber))
+}
+
+// CallBundle simulates an ordered batch of signed transactions in a new block on
+// top of the given one, reporting the outcome of each transaction. Unlike when
+// mining, the simulation continues after reverted transactions.
+func (api *EthereumAPI) CallBundle(ctx context.Context, args CallBundleArgs) (*CallBundleResult, error) {
+	config := api.e.blockchain.Config()
+
+	txs, err := decodeBundle(types.LatestSigner(config), args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	statedb, parent, err := api.e.APIBackend.StateAndHeaderByNumberOrHash(ctx, args.StateBlockNumber)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	// Assemble the header of the simulated block
+	coinbase := args.Coinbase
+	if coinbase == nil {
+		etherbase, _ := api.e.Etherbase()
+		coinbase = &etherbase
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   *coinbase,
+		Difficulty: parent.Difficulty,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+	}
+	if args.Timestamp != nil {
+		header.Time = uint64(*args.Timestamp)
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(config, parent)
+	}
+	// Setup a context so the simulation may be aborted on timeout
+	var cancel context.CancelFunc
+	if timeout := api.e.APIBackend.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	// Execute the transactions one after the other
+	var (
+		signer   = types.MakeSigner(config, header.Number)
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		balance  = statedb.GetBalance(*coinbase)
+		blockCtx = core.NewEVMBlockContext(header, api.e.blockchain, coinbase)
+		result   = &CallBundleResult{
+			BundleHash:       (&miner.Bundle{Txs: txs}).Hash(),
+			StateBlockNumber: hexutil.Uint64(parent.Number.Uint64()),
+		}
+	)
+	for i, tx := range txs {
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, config, vm.Config{})
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+		res, err := core.ApplyMessage(evm, msg, gp)
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", api.e.APIBackend.RPCEVMTimeout())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		statedb.Finalise(config.IsEIP158(header.Number))
+
+		txResult := CallBundleTxResult{
+			TxHash:     tx.Hash(),
+			From:       msg.From(),
+			GasUsed:    hexutil.Uint64(res.UsedGas),
+			ReturnData: res.Return(),
+		}
+		if res.Err != nil {
+			txResult.Error = res.Err.Error()
+			txResult.ReturnData = res.Revert()
+		}
+		result.Results = append(result.Results, txResult)
+		result.GasUsed += hexutil.Uint64(res.UsedGas)
+	}
+	result.CoinbaseDiff = (*hexutil.Big)(new(big.Int).Sub(statedb.GetBalance(*coinbase), balance))
+	return result, nil
+}
@@ -210,6 +210,12 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = stack.ResolvePath(config.TxPool.Snapshot)
+	}
+	if config.TxPool.Lifecycle != "" {
+		config.TxPool.Lifecycle = stack.ResolvePath(config.TxPool.Lifecycle)
+	}
 	eth.txPool = txpool.NewTxPool(config.TxPool, eth.blockchain.Config(), eth.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -307,6 +313,9 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.eventMux),
+		}, {
+			Namespace: "txpool",
+			Service:   NewTxPoolAPI(s),
 		}, {
 			Namespace: "admin",
 			Service:   NewAdminAPI(s),
@@ -92,6 +92,7 @@ var Defaults = Config{
 	RPCEVMTimeout:           5 * time.Second,
 	GPO:                     FullNodeGPO,
 	RPCTxFeeCap:             1, // 1 ether
+	TraceCacheSize:          1024,
 }
 
 func init() {
@@ -188,6 +189,13 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// VMTrace is the name of the tracer to run on the blocks imported into the
+	// chain, streaming the results to subscribers (empty = disabled).
+	VMTrace string
+
+	// VMTraceConfig is the JSON config of the live block tracer.
+	VMTraceConfig string `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -201,6 +209,12 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// TraceCache is the directory to cache block trace results in (empty = disabled).
+	TraceCache string
+
+	// TraceCacheSize is the maximum size of the block trace cache in megabytes.
+	TraceCacheSize int
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
 
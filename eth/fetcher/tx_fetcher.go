@@ -149,6 +149,7 @@ type TxFetcher struct {
 	quit    chan struct{}
 
 	underpriced mapset.Set[common.Hash] // Transactions discarded as too cheap (don't re-fetch)
+	stats       *txStats                // Transaction propagation statistics of the peers
 
 	// Stage 1: Waiting lists for newly discovered transactions that might be
 	// broadcast without needing explicit request/reply round trips.
@@ -169,9 +170,9 @@ type TxFetcher struct {
 	alternates map[common.Hash]map[string]struct{} // In-flight transaction alternate origins if retrieval fails
 
 	// Callbacks
-	hasTx    func(common.Hash) bool             // Retrieves a tx from the local txpool
-	addTxs   func([]*types.Transaction) []error // Insert a batch of transactions into local txpool
-	fetchTxs func(string, []common.Hash) error  // Retrieves a set of txs from a remote peer
+	hasTx    func(common.Hash) bool                     // Retrieves a tx from the local txpool
+	addTxs   func(string, []*types.Transaction) []error // Insert a batch of transactions into local txpool
+	fetchTxs func(string, []common.Hash) error          // Retrieves a set of txs from a remote peer
 
 	step  chan struct{} // Notification channel when the fetcher loop iterates
 	clock mclock.Clock  // Time wrapper to simulate in tests
@@ -180,14 +181,14 @@ type TxFetcher struct {
 
 // NewTxFetcher creates a transaction fetcher to retrieve transaction
 // based on hash announcements.
-func NewTxFetcher(hasTx func(common.Hash) bool, addTxs func([]*types.Transaction) []error, fetchTxs func(string, []common.Hash) error) *TxFetcher {
+func NewTxFetcher(hasTx func(common.Hash) bool, addTxs func(string, []*types.Transaction) []error, fetchTxs func(string, []common.Hash) error) *TxFetcher {
 	return NewTxFetcherForTests(hasTx, addTxs, fetchTxs, mclock.System{}, nil)
 }
 
 // NewTxFetcherForTests is a testing method to mock out the realtime clock with
 // a simulated version and the internal randomness with a deterministic one.
 func NewTxFetcherForTests(
-	hasTx func(common.Hash) bool, addTxs func([]*types.Transaction) []error, fetchTxs func(string, []common.Hash) error,
+	hasTx func(common.Hash) bool, addTxs func(string, []*types.Transaction) []error, fetchTxs func(string, []common.Hash) error,
 	clock mclock.Clock, rand *mrand.Rand) *TxFetcher {
 	return &TxFetcher{
 		notify:      make(chan *txAnnounce),
@@ -203,6 +204,7 @@ func NewTxFetcherForTests(
 		requests:    make(map[string]*txRequest),
 		alternates:  make(map[common.Hash]map[string]struct{}),
 		underpriced: mapset.NewSet[common.Hash](),
+		stats:       newTxStats(clock),
 		hasTx:       hasTx,
 		addTxs:      addTxs,
 		fetchTxs:    fetchTxs,
@@ -241,6 +243,8 @@ func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
 	txAnnounceKnownMeter.Mark(duplicate)
 	txAnnounceUnderpricedMeter.Mark(underpriced)
 
+	f.stats.announce(peer, hashes, unknowns)
+
 	// If anything's left to announce, push it into the internal loop
 	if len(unknowns) == 0 {
 		return nil
@@ -294,7 +298,9 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			otherreject int64
 		)
 		batch := txs[i:end]
-		for j, err := range f.addTxs(batch) {
+		for j, err := range f.addTxs(peer, batch) {
+			f.stats.deliver(peer, batch[j].Hash(), err)
+
 			// Track the transaction hash if the price is too low for us.
 			// Avoid re-request this transaction when we receive another
 			// announcement.
@@ -337,9 +343,17 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 	}
 }
 
+// Register should be called when a peer connects, to start accounting its
+// transaction propagation statistics.
+func (f *TxFetcher) Register(peer string) {
+	f.stats.register(peer)
+}
+
 // Drop should be called when a peer disconnects. It cleans up all the internal
 // data structures of the given node.
 func (f *TxFetcher) Drop(peer string) error {
+	f.stats.drop(peer)
+
 	select {
 	case f.drop <- &txDrop{peer: peer}:
 		return nil
@@ -348,6 +362,12 @@ func (f *TxFetcher) Drop(peer string) error {
 	}
 }
 
+// PeerStats returns the transaction propagation statistics of the connected and
+// recently disconnected peers.
+func (f *TxFetcher) PeerStats() map[string]TxPeerStats {
+	return f.stats.stats()
+}
+
 // Start boots up the announcement based synchroniser, accepting and processing
 // hash notifications and block fetches until termination requested.
 func (f *TxFetcher) Start() {
@@ -0,0 +1,205 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxTxStatsHashes is the number of recently announced transaction hashes
+	// tracked to detect first announcements and measure delivery latencies.
+	maxTxStatsHashes = 65536
+
+	// maxTxStatsDropped is the number of disconnected peers whose statistics are
+	// retained after they disconnect.
+	maxTxStatsDropped = 256
+)
+
+var (
+	txAnnounceFirstMeter  = metrics.NewRegisteredMeter("eth/fetcher/transaction/announces/first", nil)
+	txDeliveryLatencyHist = metrics.NewRegisteredHistogram("eth/fetcher/transaction/latency", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// TxPeerStats is the transaction propagation accounting of a single peer.
+type TxPeerStats struct {
+	Connected      bool          `json:"connected"`      // Whether the peer is still connected
+	Announced      uint64        `json:"announced"`      // Transaction hashes announced by the peer
+	FirstAnnounced uint64        `json:"firstAnnounced"` // Announcements of transactions nobody announced before
+	Delivered      uint64        `json:"delivered"`      // Transactions broadcast or delivered on request
+	Accepted       uint64        `json:"accepted"`       // Delivered transactions accepted into the pool
+	Duplicate      uint64        `json:"duplicate"`      // Delivered transactions already known to the pool
+	Underpriced    uint64        `json:"underpriced"`    // Delivered transactions rejected as underpriced
+	Invalid        uint64        `json:"invalid"`        // Delivered transactions rejected for any other reason
+	Latencies      uint64        `json:"latencies"`      // Deliveries of previously announced transactions
+	Latency        time.Duration `json:"latency"`        // Mean time between first announcement and delivery
+
+	latency time.Duration // Total announce-to-delivery latency for the mean
+}
+
+// txStats is a bounded tracker of the transaction propagation statistics of the
+// peers feeding the fetcher.
+type txStats struct {
+	peers   map[string]*TxPeerStats                   // Statistics of connected and recently dropped peers
+	dropped []string                                  // Disconnected peers in order of disconnection
+	seen    lru.BasicLRU[common.Hash, mclock.AbsTime] // First announcement times of recent transactions
+
+	clock mclock.Clock
+	lock  sync.Mutex
+}
+
+// newTxStats creates a transaction propagation statistics tracker.
+func newTxStats(clock mclock.Clock) *txStats {
+	return &txStats{
+		peers: make(map[string]*TxPeerStats),
+		seen:  lru.NewBasicLRU[common.Hash, mclock.AbsTime](maxTxStatsHashes),
+		clock: clock,
+	}
+}
+
+// register starts tracking the statistics of a newly connected peer, resuming
+// the previous ones if it reconnects before they are discarded.
+func (s *txStats) register(peer string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.peers[peer]
+	if stats == nil {
+		stats = new(TxPeerStats)
+		s.peers[peer] = stats
+	}
+	stats.Connected = true
+}
+
+// peer retrieves the statistics of a connected peer, or nil if the peer is not
+// tracked, e.g. if it was already dropped.
+//
+// Note, this method assumes the lock is held!
+func (s *txStats) peer(id string) *TxPeerStats {
+	stats := s.peers[id]
+	if stats == nil || !stats.Connected {
+		return nil
+	}
+	return stats
+}
+
+// announce accounts a batch of hashes announced by a peer, of which the unknown
+// ones are not yet tracked by the local pool. Announcements from untracked peers
+// are ignored.
+func (s *txStats) announce(peer string, hashes []common.Hash, unknowns []common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.peer(peer)
+	if stats == nil {
+		return
+	}
+	stats.Announced += uint64(len(hashes))
+
+	now := s.clock.Now()
+	for _, hash := range unknowns {
+		if s.seen.Contains(hash) {
+			continue
+		}
+		s.seen.Add(hash, now)
+		stats.FirstAnnounced++
+		txAnnounceFirstMeter.Mark(1)
+	}
+}
+
+// deliver accounts a single transaction delivered by a peer along with the
+// result of inserting it into the pool. Deliveries from untracked peers, e.g.
+// arriving after the peer was dropped, are ignored.
+func (s *txStats) deliver(peer string, hash common.Hash, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.peer(peer)
+	if stats == nil {
+		return
+	}
+	stats.Delivered++
+
+	switch {
+	case err == nil:
+		stats.Accepted++
+
+	case errors.Is(err, txpool.ErrAlreadyKnown):
+		stats.Duplicate++
+
+	case errors.Is(err, txpool.ErrUnderpriced) || errors.Is(err, txpool.ErrReplaceUnderpriced):
+		stats.Underpriced++
+
+	default:
+		stats.Invalid++
+	}
+	if err == nil {
+		if announced, ok := s.seen.Peek(hash); ok {
+			latency := time.Duration(s.clock.Now() - announced)
+
+			stats.Latencies++
+			stats.latency += latency
+			txDeliveryLatencyHist.Update(int64(latency))
+		}
+	}
+}
+
+// drop marks a peer disconnected, discarding the statistics of the oldest
+// disconnected peers if too many are retained.
+func (s *txStats) drop(peer string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats, ok := s.peers[peer]
+	if !ok || !stats.Connected {
+		return
+	}
+	stats.Connected = false
+	s.dropped = append(s.dropped, peer)
+
+	for len(s.dropped) > maxTxStatsDropped {
+		// Only delete the peer if it did not reconnect in the meantime
+		if stats := s.peers[s.dropped[0]]; stats != nil && !stats.Connected {
+			delete(s.peers, s.dropped[0])
+		}
+		s.dropped = s.dropped[1:]
+	}
+}
+
+// stats returns a copy of the statistics of all the tracked peers.
+func (s *txStats) stats() map[string]TxPeerStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := make(map[string]TxPeerStats, len(s.peers))
+	for id, peer := range s.peers {
+		cpy := *peer
+		if cpy.Latencies > 0 {
+			cpy.Latency = cpy.latency / time.Duration(cpy.Latencies)
+		}
+		stats[id] = cpy
+	}
+	return stats
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/txpool"
+)
+
+// Tests that the per-peer propagation statistics credit first announcements,
+// classify deliveries and measure announce-to-delivery latencies.
+func TestTransactionStatsAccounting(t *testing.T) {
+	clock := new(mclock.Simulated)
+	stats := newTxStats(clock)
+
+	// Two peers announcing the same transactions, only the first gets credit
+	stats.register("A")
+	stats.register("B")
+	stats.announce("A", testTxsHashes[:2], testTxsHashes[:2])
+	clock.Run(100 * time.Millisecond)
+	stats.announce("B", testTxsHashes[:3], testTxsHashes[:3])
+	clock.Run(100 * time.Millisecond)
+
+	// Deliver the transactions with various results
+	stats.deliver("A", testTxsHashes[0], nil)
+	stats.deliver("B", testTxsHashes[0], txpool.ErrAlreadyKnown)
+	stats.deliver("B", testTxsHashes[1], txpool.ErrUnderpriced)
+	stats.deliver("B", testTxsHashes[2], nil)
+	stats.deliver("B", testTxsHashes[3], errors.New("invalid"))
+
+	have := stats.stats()
+	want := map[string]TxPeerStats{
+		"A": {Connected: true, Announced: 2, FirstAnnounced: 2, Delivered: 1, Accepted: 1, Latencies: 1, Latency: 200 * time.Millisecond},
+		"B": {Connected: true, Announced: 3, FirstAnnounced: 1, Delivered: 4, Accepted: 1, Duplicate: 1, Underpriced: 1, Invalid: 1, Latencies: 1, Latency: 100 * time.Millisecond},
+	}
+	for id, exp := range want {
+		got := have[id]
+		got.latency = 0
+		if got != exp {
+			t.Errorf("peer %s: stats mismatch: have %+v, want %+v", id, got, exp)
+		}
+	}
+}
+
+// Tests that the statistics of disconnected peers are only retained up to a
+// limit, discarding the oldest ones first.
+func TestTransactionStatsDropLimit(t *testing.T) {
+	stats := newTxStats(new(mclock.Simulated))
+
+	for i := 0; i < maxTxStatsDropped+10; i++ {
+		peer := fmt.Sprintf("peer-%d", i)
+		stats.register(peer)
+		stats.announce(peer, testTxsHashes[:1], nil)
+		stats.drop(peer)
+	}
+	stats.register("live")
+	stats.announce("live", testTxsHashes[:1], nil)
+
+	have := stats.stats()
+	if len(have) != maxTxStatsDropped+1 {
+		t.Fatalf("tracked peer count mismatch: have %d, want %d", len(have), maxTxStatsDropped+1)
+	}
+	if _, ok := have["peer-0"]; ok {
+		t.Errorf("oldest dropped peer retained")
+	}
+	if s, ok := have[fmt.Sprintf("peer-%d", maxTxStatsDropped+9)]; !ok || s.Connected {
+		t.Errorf("latest dropped peer mismatch: have %+v, tracked %v", s, ok)
+	}
+	if s := have["live"]; !s.Connected {
+		t.Errorf("live peer reported disconnected")
+	}
+}
+
+// Tests that late deliveries and announcements from dropped peers are ignored,
+// instead of resurrecting their statistics.
+func TestTransactionStatsUntracked(t *testing.T) {
+	stats := newTxStats(new(mclock.Simulated))
+
+	stats.register("A")
+	stats.announce("A", testTxsHashes[:1], testTxsHashes[:1])
+	stats.drop("A")
+	stats.deliver("A", testTxsHashes[0], nil)
+
+	if have := stats.stats()["A"]; have.Connected || have.Delivered != 0 {
+		t.Errorf("dropped peer stats mismatch: have %+v", have)
+	}
+	// Push the peer out of the retained ones, it must not come back
+	for i := 0; i < maxTxStatsDropped; i++ {
+		peer := fmt.Sprintf("peer-%d", i)
+		stats.register(peer)
+		stats.drop(peer)
+	}
+	stats.announce("A", testTxsHashes[:1], nil)
+	stats.deliver("A", testTxsHashes[0], nil)
+	stats.deliver("unknown", testTxsHashes[0], nil)
+
+	have := stats.stats()
+	if _, ok := have["A"]; ok {
+		t.Errorf("evicted peer resurrected")
+	}
+	if _, ok := have["unknown"]; ok {
+		t.Errorf("unknown peer tracked")
+	}
+}
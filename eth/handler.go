@@ -62,8 +62,13 @@ type txPool interface {
 	// tx hash.
 	Get(hash common.Hash) *types.Transaction
 
-	// AddRemotes should add the given transactions to the pool.
-	AddRemotes([]*types.Transaction) []error
+	// AddRemotesFrom should add the given transactions received from the
+	// given peer to the pool.
+	AddRemotesFrom(peer string, txs []*types.Transaction) []error
+
+	// IsPrivate should return whether a transaction was submitted privately
+	// and must not be propagated to the network.
+	IsPrivate(hash common.Hash) bool
 
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
@@ -301,7 +306,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 		return p.RequestTxs(hashes)
 	}
-	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, h.txpool.AddRemotes, fetchTx)
+	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, h.txpool.AddRemotesFrom, fetchTx)
 	h.chainSync = newChainSyncer(h)
 	return h, nil
 }
@@ -360,6 +365,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		peer.Log().Error("Ethereum peer registration failed", "err", err)
 		return err
 	}
+	h.txFetcher.Register(peer.ID())
 	defer h.unregisterPeer(peer.ID())
 
 	p := h.peers.peer(peer.ID())
@@ -638,6 +644,10 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
+		// Never leak privately submitted transactions to the network
+		if h.txpool.IsPrivate(tx.Hash()) {
+			continue
+		}
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
 		// Send the tx unconditionally to a subset of our peers
 		numDirect := int(math.Sqrt(float64(len(peers))))
@@ -91,6 +91,17 @@ func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// AddRemotesFrom is like AddRemotes, ignoring the origin peer.
+func (p *testTxPool) AddRemotesFrom(peer string, txs []*types.Transaction) []error {
+	return p.AddRemotes(txs)
+}
+
+// IsPrivate returns whether a transaction was submitted privately, which is
+// never the case in the test pool.
+func (p *testTxPool) IsPrivate(hash common.Hash) bool {
+	return false
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending(enforceTips bool) map[common.Address]types.Transactions {
 	p.lock.RLock()
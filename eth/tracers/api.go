@@ -91,6 +91,8 @@ type Backend interface {
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
 type API struct {
 	backend Backend
+	cache   *TraceCache // Optional cache of block trace results
+	live    *LiveTracer // Optional tracer of the imported blocks
 }
 
 // NewAPI creates a new API definition for the tracing methods of the Ethereum service.
@@ -214,9 +216,10 @@ type blockTraceTask struct {
 // blockTraceResult represents the results of tracing a single block when an entire
 // chain is being traced.
 type blockTraceResult struct {
-	Block  hexutil.Uint64   `json:"block"`  // Block number corresponding to this trace
-	Hash   common.Hash      `json:"hash"`   // Block hash corresponding to this trace
-	Traces []*txTraceResult `json:"traces"` // Trace results produced by the task
+	Block  hexutil.Uint64   `json:"block"`           // Block number corresponding to this trace
+	Hash   common.Hash      `json:"hash"`            // Block hash corresponding to this trace
+	Traces []*txTraceResult `json:"traces"`          // Trace results produced by the task
+	Error  string           `json:"error,omitempty"` // Error if the block could not be traced
 }
 
 // txTraceTask represents a single transaction trace task when an entire block
@@ -588,6 +591,11 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	if api.cache != nil {
+		if results := api.cache.get(block.Hash(), config); results != nil {
+			return results, nil
+		}
+	}
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
 		return nil, err
@@ -664,6 +672,15 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if failed != nil {
 		return nil, failed
 	}
+	// Cache the results unless any of the traces failed (e.g. timed out)
+	if api.cache != nil {
+		for _, result := range results {
+			if result.Error != "" {
+				return results, nil
+			}
+		}
+		api.cache.put(block.Hash(), config, results)
+	}
 	return results, nil
 }
 
@@ -835,6 +852,98 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // created during the execution of EVM if the given transaction was added on
 // top of the provided block and returns them as a JSON object.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+	}
+	// Execute the trace
+	msg, err := args.ToMessage(api.backend.RPCGasCap(), block.BaseFee())
+	if err != nil {
+		return nil, err
+	}
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
+}
+
+// Bundle is a group of calls simulated by TraceCallMany, executed on top of the
+// same block context.
+type Bundle struct {
+	Transactions   []ethapi.TransactionArgs `json:"transactions"`
+	BlockOverrides *ethapi.BlockOverrides   `json:"blockOverrides"`
+}
+
+// TraceCallMany lets you trace a sequence of eth_calls on top of the state of a
+// given block, each seeing the state changes of the previous ones. The calls are
+// grouped into bundles, each of which can override the block context fields on
+// top of the global block overrides. The result holds the traces of the calls
+// of each bundle.
+func (api *API) TraceCallMany(ctx context.Context, bundles []Bundle, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([][]interface{}, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("no bundles to trace")
+	}
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	blockCtx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&blockCtx)
+		traceConfig = &config.TraceConfig
+	}
+	var (
+		results = make([][]interface{}, len(bundles))
+		index   int
+	)
+	for i, bundle := range bundles {
+		vmctx := blockCtx
+		bundle.BlockOverrides.Apply(&vmctx)
+
+		results[i] = make([]interface{}, len(bundle.Transactions))
+		for j, args := range bundle.Transactions {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			msg, err := args.ToMessage(api.backend.RPCGasCap(), vmctx.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			res, err := api.traceTx(ctx, msg, &Context{TxIndex: index}, vmctx, statedb, traceConfig)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+			statedb.Finalise(api.backend.ChainConfig().IsEIP158(vmctx.BlockNumber))
+			results[i][j] = res
+			index++
+		}
+	}
+	return results, nil
+}
+
+// callState retrieves the block to execute calls on top of, and regenerates its
+// state. Pending blocks are not supported.
+func (api *API) callState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (*types.Block, *state.StateDB, StateReleaseFunc, error) {
 	// Try to retrieve the specified block
 	var (
 		err   error
@@ -849,14 +958,14 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// more flexibility and stability than trying to trace on 'pending', since
 			// the contents of 'pending' is unstable and probably not a true representation
 			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
+			return nil, nil, nil, errors.New("tracing on top of pending is not supported")
 		}
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
@@ -865,29 +974,9 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	}
 	statedb, release, err := api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	if err != nil {
-		return nil, err
-	}
-	defer release()
-
-	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-	// Apply the customization rules if required.
-	if config != nil {
-		if err := config.StateOverrides.Apply(statedb); err != nil {
-			return nil, err
-		}
-		config.BlockOverrides.Apply(&vmctx)
-	}
-	// Execute the trace
-	msg, err := args.ToMessage(api.backend.RPCGasCap(), block.BaseFee())
-	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-
-	var traceConfig *TraceConfig
-	if config != nil {
-		traceConfig = &config.TraceConfig
-	}
-	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
+	return block, statedb, release, nil
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
@@ -938,13 +1027,21 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 	return tracer.GetResult()
 }
 
-// APIs return the collection of RPC services the tracer package offers.
-func APIs(backend Backend) []rpc.API {
+// APIs return the collection of RPC services the tracer package offers. The
+// block trace results are cached if a trace cache is given, and streamed as the
+// blocks are imported if a live tracer is given.
+func APIs(backend Backend, cache *TraceCache, live *LiveTracer) []rpc.API {
+	api := &API{backend: backend, cache: cache, live: live}
+
 	// Append all the local APIs and return
 	return []rpc.API{
 		{
 			Namespace: "debug",
-			Service:   NewAPI(backend),
+			Service:   api,
+		},
+		{
+			Namespace: "trace",
+			Service:   &TraceAPI{api: api},
 		},
 	}
 }
@@ -0,0 +1,166 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// AggregateBlockByNumber traces all the transactions of a block with a tracer
+// supporting aggregation, and merges their results into the result of the block.
+func (api *API) AggregateBlockByNumber(ctx context.Context, number rpc.BlockNumber, config *TraceConfig) (json.RawMessage, error) {
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.aggregateBlock(ctx, block, config)
+}
+
+// AggregateBlockByHash traces all the transactions of a block with a tracer
+// supporting aggregation, and merges their results into the result of the block.
+func (api *API) AggregateBlockByHash(ctx context.Context, hash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	block, err := api.blockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return api.aggregateBlock(ctx, block, config)
+}
+
+// AggregateChain traces all the transactions of a range of blocks (including
+// both ends) with a tracer supporting aggregation, and merges their results into
+// the result of the whole range.
+func (api *API) AggregateChain(ctx context.Context, start, end rpc.BlockNumber, config *TraceConfig) (json.RawMessage, error) {
+	name, err := aggregateTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	from, err := api.blockByNumber(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.blockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", to.NumberU64(), from.NumberU64())
+	}
+	if from.NumberU64() == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	// The chain tracer excludes the start block, so start from its parent
+	parent, err := api.blockByNumber(ctx, rpc.BlockNumber(from.NumberU64()-1))
+	if err != nil {
+		return nil, err
+	}
+	closed := make(chan interface{})
+	resCh := api.traceChain(parent, to, config, closed)
+	defer func() {
+		// Abort the tracing if still running, draining any in-flight results
+		close(closed)
+		go func() {
+			for range resCh {
+			}
+		}()
+	}()
+	// Merge the results block by block to avoid holding onto all of them. The
+	// chain tracer skips the blocks without transactions but always reports the
+	// last one.
+	aggregator, err := NewAggregator(name)
+	if err != nil {
+		return nil, err
+	}
+	for res := range resCh {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results, err := aggregateResults(uint64(res.Block), res.Traces)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if err := aggregator.Add(result); err != nil {
+				return nil, err
+			}
+		}
+		if uint64(res.Block) == to.NumberU64() {
+			return aggregator.Result()
+		}
+	}
+	return nil, errors.New("chain tracing aborted")
+}
+
+// aggregateBlock traces all the transactions of a block with a tracer supporting
+// aggregation, and merges their results.
+func (api *API) aggregateBlock(ctx context.Context, block *types.Block, config *TraceConfig) (json.RawMessage, error) {
+	name, err := aggregateTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	traces, err := api.traceBlock(ctx, block, config)
+	if err != nil {
+		return nil, err
+	}
+	results, err := aggregateResults(block.NumberU64(), traces)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(name, results)
+}
+
+// aggregateTracer returns the name of the tracer requested by the configuration,
+// failing if it doesn't support aggregation.
+func aggregateTracer(config *TraceConfig) (string, error) {
+	if config == nil || config.Tracer == nil {
+		return "", errors.New("no tracer specified")
+	}
+	if !Mergeable(*config.Tracer) {
+		return "", fmt.Errorf("tracer %s does not support aggregation", *config.Tracer)
+	}
+	return *config.Tracer, nil
+}
+
+// aggregateResults collects the results of the transactions of a block, failing
+// if any transaction could not be traced.
+func aggregateResults(number uint64, traces []*txTraceResult) ([]json.RawMessage, error) {
+	results := make([]json.RawMessage, 0, len(traces))
+	for i, trace := range traces {
+		if trace == nil || trace.Error != "" {
+			var err string
+			if trace != nil {
+				err = trace.Error
+			}
+			return nil, fmt.Errorf("block #%d transaction %d: tracing failed: %s", number, i, err)
+		}
+		blob, ok := trace.Result.(json.RawMessage)
+		if !ok {
+			var err error
+			if blob, err = json.Marshal(trace.Result); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, blob)
+	}
+	return results, nil
+}
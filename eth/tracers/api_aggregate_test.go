@@ -0,0 +1,152 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testCountTracerName is the name of a mergeable test tracer, counting the
+// transactions and the value transferred by them.
+const testCountTracerName = "testCountTracer"
+
+// testCountResult is the result of the test counting tracer.
+type testCountResult struct {
+	Txs   uint64 `json:"txs"`
+	Value uint64 `json:"value"`
+}
+
+// testCountTracer is a mergeable tracer reporting a single transaction of 1000
+// wei per trace.
+type testCountTracer struct {
+	testFlatTracer
+}
+
+func init() {
+	RegisterLookup(false, func(name string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
+		if name != testCountTracerName {
+			return nil, fmt.Errorf("unknown tracer %s", name)
+		}
+		return new(testCountTracer), nil
+	})
+	RegisterAggregator(testCountTracerName, func() Aggregator {
+		return new(testCountAggregator)
+	})
+}
+
+// testCountAggregator sums up the results of the test count tracer.
+type testCountAggregator struct {
+	merged testCountResult
+}
+
+func (a *testCountAggregator) Add(blob json.RawMessage) error {
+	var res testCountResult
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return err
+	}
+	a.merged.Txs += res.Txs
+	a.merged.Value += res.Value
+	return nil
+}
+
+func (a *testCountAggregator) Result() (json.RawMessage, error) {
+	return json.Marshal(&a.merged)
+}
+
+func (t *testCountTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(&testCountResult{Txs: 1, Value: 1000})
+}
+
+func TestAggregate(t *testing.T) {
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	// Block i contains i transactions, except for the empty blocks 3 and 4
+	backend := newTestBackend(t, 10, genesis, func(i int, b *core.BlockGen) {
+		if i == 2 || i == 3 {
+			return
+		}
+		for j := 0; j < i+1; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+			nonce++
+		}
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	tracer := testCountTracerName
+	config := &TraceConfig{Tracer: &tracer}
+
+	decode := func(blob json.RawMessage) testCountResult {
+		var res testCountResult
+		if err := json.Unmarshal(blob, &res); err != nil {
+			t.Fatalf("failed to decode result: %v", err)
+		}
+		return res
+	}
+	res, err := api.AggregateBlockByNumber(context.Background(), rpc.BlockNumber(5), config)
+	if err != nil {
+		t.Fatalf("failed to aggregate block: %v", err)
+	}
+	if have := decode(res); have != (testCountResult{Txs: 5, Value: 5000}) {
+		t.Errorf("block aggregate mismatch: have %+v", have)
+	}
+	for i, tt := range []struct {
+		start, end uint64
+		txs        uint64
+	}{
+		{1, 10, 48},
+		{3, 4, 0},
+		{2, 5, 7},
+		{10, 10, 10},
+	} {
+		res, err := api.AggregateChain(context.Background(), rpc.BlockNumber(tt.start), rpc.BlockNumber(tt.end), config)
+		if err != nil {
+			t.Errorf("test %d: failed to aggregate chain: %v", i, err)
+			continue
+		}
+		if have := decode(res); have != (testCountResult{Txs: tt.txs, Value: 1000 * tt.txs}) {
+			t.Errorf("test %d: chain aggregate mismatch: have %+v, want %d txs", i, have, tt.txs)
+		}
+	}
+	// Tracers without aggregation support are rejected
+	if _, err := api.AggregateBlockByNumber(context.Background(), rpc.BlockNumber(5), nil); err == nil {
+		t.Errorf("aggregation without tracer accepted")
+	}
+	other := flatCallTracerName
+	if _, err := api.AggregateChain(context.Background(), 1, 2, &TraceConfig{Tracer: &other}); err == nil {
+		t.Errorf("aggregation of non-mergeable tracer accepted")
+	}
+}
@@ -0,0 +1,479 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth/tracers/internal/columnar"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// callTracerName is the name of the native tracer producing nested call frames.
+	callTracerName = "callTracer"
+
+	// prestateTracerName is the name of the native tracer producing the accounts
+	// touched by a transaction, or their changes in diff mode.
+	prestateTracerName = "prestateTracer"
+)
+
+// TraceExportConfig is the configuration of a trace export. The tables written
+// depend on the tracer used:
+//
+//   - callTracer:     calls and logs, the latter empty unless withLog is enabled
+//   - prestateTracer: state_diffs, with the post values only in diffMode
+//   - any other:      results, holding the json result of each transaction
+//
+// All tables start with the block_number, block_hash, tx_index and tx_hash
+// columns of the transaction traced. Addresses, hashes and binary data are hex
+// encoded, amounts of wei are decimal strings to avoid overflows.
+//
+// The output directory can't be chosen over RPC, as that would allow clients to
+// write files anywhere the node can. Remote exports always go into a new temporary
+// directory, only the offline `geth trace export` command sets Dir.
+type TraceExportConfig struct {
+	TraceConfig
+	Dir    string `json:"-"` // Directory to write the files into, a new temporary one if empty
+	Format string // Format of the files, csv (default) or parquet
+}
+
+// exportTxColumns are the leading columns of all tables, identifying the
+// transaction the row belongs to.
+var exportTxColumns = []columnar.Column{
+	{Name: "block_number", Type: columnar.Int64},
+	{Name: "block_hash", Type: columnar.String},
+	{Name: "tx_index", Type: columnar.Int64},
+	{Name: "tx_hash", Type: columnar.String},
+}
+
+var (
+	// exportCallsTable holds the call frames of the transactions, in depth-first
+	// order. The position of a frame is given by the dot separated indices of it
+	// and its parents within their callers (e.g. 1.0), empty for the top frame.
+	exportCallsTable = exportTable("calls",
+		columnar.Column{Name: "trace_address", Type: columnar.String},
+		columnar.Column{Name: "depth", Type: columnar.Int64},
+		columnar.Column{Name: "type", Type: columnar.String}, // CALL, CREATE, DELEGATECALL, SELFDESTRUCT, etc.
+		columnar.Column{Name: "from", Type: columnar.String},
+		columnar.Column{Name: "to", Type: columnar.String}, // Created address for creations, empty if failed
+		columnar.Column{Name: "value", Type: columnar.String},
+		columnar.Column{Name: "gas", Type: columnar.Int64},
+		columnar.Column{Name: "gas_used", Type: columnar.Int64},
+		columnar.Column{Name: "input", Type: columnar.String},
+		columnar.Column{Name: "output", Type: columnar.String},
+		columnar.Column{Name: "error", Type: columnar.String}, // Empty if the call succeeded
+		columnar.Column{Name: "revert_reason", Type: columnar.String},
+	)
+	// exportLogsTable holds the logs emitted by the transactions, including the
+	// ones of reverted calls. The logs are indexed in the order of the call frames
+	// emitting them, which may differ from the order of emission across frames.
+	exportLogsTable = exportTable("logs",
+		columnar.Column{Name: "log_index", Type: columnar.Int64},
+		columnar.Column{Name: "trace_address", Type: columnar.String}, // Call frame emitting the log
+		columnar.Column{Name: "address", Type: columnar.String},
+		columnar.Column{Name: "topic0", Type: columnar.String}, // Empty if the log has less topics
+		columnar.Column{Name: "topic1", Type: columnar.String},
+		columnar.Column{Name: "topic2", Type: columnar.String},
+		columnar.Column{Name: "topic3", Type: columnar.String},
+		columnar.Column{Name: "data", Type: columnar.String},
+	)
+	// exportStateDiffsTable holds the account fields touched by the transactions,
+	// one row per field or storage slot. Values are empty if the account doesn't
+	// exist, and post values are always empty if the tracer isn't in diffMode.
+	exportStateDiffsTable = exportTable("state_diffs",
+		columnar.Column{Name: "address", Type: columnar.String},
+		columnar.Column{Name: "field", Type: columnar.String}, // balance, nonce, code or storage
+		columnar.Column{Name: "slot", Type: columnar.String},  // Empty unless the field is storage
+		columnar.Column{Name: "pre", Type: columnar.String},
+		columnar.Column{Name: "post", Type: columnar.String},
+	)
+	// exportResultsTable holds the results of tracers without a dedicated schema.
+	exportResultsTable = exportTable("results",
+		columnar.Column{Name: "result", Type: columnar.String}, // Json encoded tracer result
+	)
+)
+
+// exportTable creates a table schema, prefixed with the transaction columns.
+func exportTable(name string, columns ...columnar.Column) columnar.Table {
+	return columnar.Table{
+		Name:    name,
+		Columns: append(append([]columnar.Column{}, exportTxColumns...), columns...),
+	}
+}
+
+// exportTx is the transaction a trace result to export belongs to.
+type exportTx struct {
+	block     uint64
+	blockHash common.Hash
+	index     int
+	hash      common.Hash
+}
+
+// traceExporter converts the results of a tracer into table rows.
+type traceExporter struct {
+	tables []columnar.Table
+	export func(result json.RawMessage, write func(table int, row ...interface{}) error) error
+}
+
+// newTraceExporter returns the exporter of the results of the configured tracer.
+func newTraceExporter(config *TraceConfig) *traceExporter {
+	var tracer string
+	if config.Tracer != nil {
+		tracer = *config.Tracer
+	}
+	switch tracer {
+	case callTracerName:
+		return &traceExporter{tables: []columnar.Table{exportCallsTable, exportLogsTable}, export: exportCalls}
+	case prestateTracerName:
+		return &traceExporter{tables: []columnar.Table{exportStateDiffsTable}, export: exportStateDiffs}
+	default:
+		return &traceExporter{tables: []columnar.Table{exportResultsTable}, export: exportResults}
+	}
+}
+
+// ExportTraces traces a range of blocks, inclusive of both ends, and writes the
+// results into CSV or Parquet files for offline analysis, one per table as
+// documented on TraceExportConfig. The names of the files are returned.
+func (api *API) ExportTraces(ctx context.Context, start, end rpc.BlockNumber, config *TraceExportConfig) ([]string, error) {
+	if config == nil {
+		config = new(TraceExportConfig)
+	}
+	var newWriter func(*os.File, columnar.Table) columnar.Writer
+	switch config.Format {
+	case "", "csv":
+		newWriter = func(f *os.File, table columnar.Table) columnar.Writer { return columnar.NewCSVWriter(f, table) }
+	case "parquet":
+		newWriter = func(f *os.File, table columnar.Table) columnar.Writer { return columnar.NewParquetWriter(f, table) }
+	default:
+		return nil, fmt.Errorf("unknown export format %q", config.Format)
+	}
+	from, err := api.blockByNumber(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.blockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", to.NumberU64(), from.NumberU64())
+	}
+	// The chain tracer excludes the start block, so start from its parent
+	parent := from
+	if from.NumberU64() > 0 {
+		if parent, err = api.blockByNumber(ctx, rpc.BlockNumber(from.NumberU64()-1)); err != nil {
+			return nil, err
+		}
+	}
+	// Create the output files, deleting them if the export fails
+	dir := config.Dir
+	if dir == "" {
+		if dir, err = os.MkdirTemp(os.TempDir(), "trace-export-"); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	var (
+		exporter = newTraceExporter(&config.TraceConfig)
+		files    []*os.File
+		writers  []columnar.Writer
+		names    []string
+		done     bool
+	)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+			if !done {
+				os.Remove(file.Name())
+			}
+		}
+	}()
+	ext := config.Format
+	if ext == "" {
+		ext = "csv"
+	}
+	for _, table := range exporter.tables {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s-%d-%d.%s", table.Name, from.NumberU64(), to.NumberU64(), ext)))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		writers = append(writers, newWriter(file, table))
+		names = append(names, file.Name())
+	}
+	// Trace the blocks and write out the results as they arrive
+	if parent.NumberU64() < to.NumberU64() {
+		closed := make(chan interface{})
+		resCh := api.traceChain(parent, to, &config.TraceConfig, closed)
+		defer func() {
+			// Abort the tracing if still running, draining any in-flight results
+			close(closed)
+			go func() {
+				for range resCh {
+				}
+			}()
+		}()
+		var last uint64
+		for res := range resCh {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			block, err := api.blockByHash(ctx, res.Hash)
+			if err != nil {
+				return nil, err
+			}
+			txs := block.Transactions()
+			for i, result := range res.Traces {
+				if result == nil || result.Error != "" {
+					reason := "not traced"
+					if result != nil {
+						reason = result.Error
+					}
+					return nil, fmt.Errorf("failed to trace transaction %#x: %s", txs[i].Hash(), reason)
+				}
+				blob, err := json.Marshal(result.Result)
+				if err != nil {
+					return nil, err
+				}
+				tx := &exportTx{block: block.NumberU64(), blockHash: block.Hash(), index: i, hash: txs[i].Hash()}
+				write := func(table int, row ...interface{}) error {
+					return writers[table].Write(append([]interface{}{tx.block, tx.blockHash.Hex(), tx.index, tx.hash.Hex()}, row...))
+				}
+				if err := exporter.export(blob, write); err != nil {
+					return nil, fmt.Errorf("failed to export transaction %#x: %v", tx.hash, err)
+				}
+			}
+			last = uint64(res.Block)
+		}
+		if last < to.NumberU64() {
+			return nil, fmt.Errorf("chain tracing aborted at block #%d", last+1)
+		}
+	}
+	for _, writer := range writers {
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		if err := file.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	done = true
+	log.Info("Exported chain traces", "from", from.NumberU64(), "to", to.NumberU64(), "files", len(names), "dir", dir)
+	return names, nil
+}
+
+// exportCallFrame is the subset of a callTracer frame exported.
+type exportCallFrame struct {
+	Type         string            `json:"type"`
+	From         common.Address    `json:"from"`
+	To           *common.Address   `json:"to"`
+	Value        *hexutil.Big      `json:"value"`
+	Gas          hexutil.Uint64    `json:"gas"`
+	GasUsed      hexutil.Uint64    `json:"gasUsed"`
+	Input        hexutil.Bytes     `json:"input"`
+	Output       hexutil.Bytes     `json:"output"`
+	Error        string            `json:"error"`
+	RevertReason string            `json:"revertReason"`
+	Calls        []exportCallFrame `json:"calls"`
+	Logs         []struct {
+		Address common.Address `json:"address"`
+		Topics  []common.Hash  `json:"topics"`
+		Data    hexutil.Bytes  `json:"data"`
+	} `json:"logs"`
+}
+
+// exportCalls writes the call frames and logs of a callTracer result into the
+// calls and logs tables.
+func exportCalls(result json.RawMessage, write func(table int, row ...interface{}) error) error {
+	var frame exportCallFrame
+	if err := json.Unmarshal(result, &frame); err != nil {
+		return err
+	}
+	var logs int
+	return exportCallTree(&frame, nil, &logs, write)
+}
+
+// exportCallTree writes a call frame, its logs and its subcalls recursively.
+func exportCallTree(frame *exportCallFrame, path []string, logs *int, write func(table int, row ...interface{}) error) error {
+	var (
+		address = strings.Join(path, ".")
+		to      string
+		value   = "0"
+	)
+	if frame.To != nil {
+		to = hexutil.Encode(frame.To[:])
+	}
+	if frame.Value != nil {
+		value = frame.Value.ToInt().String()
+	}
+	err := write(0, address, len(path), frame.Type, hexutil.Encode(frame.From[:]), to, value, uint64(frame.Gas), uint64(frame.GasUsed),
+		hexutil.Encode(frame.Input), hexutil.Encode(frame.Output), frame.Error, frame.RevertReason)
+	if err != nil {
+		return err
+	}
+	for _, entry := range frame.Logs {
+		var topics [4]string
+		for i := 0; i < len(entry.Topics) && i < len(topics); i++ {
+			topics[i] = entry.Topics[i].Hex()
+		}
+		if err := write(1, *logs, address, hexutil.Encode(entry.Address[:]), topics[0], topics[1], topics[2], topics[3], hexutil.Encode(entry.Data)); err != nil {
+			return err
+		}
+		*logs++
+	}
+	for i := range frame.Calls {
+		if err := exportCallTree(&frame.Calls[i], append(path, strconv.Itoa(i)), logs, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportAccount is an account as reported by the prestateTracer.
+type exportAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   *uint64                     `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// exportStateDiffs writes the accounts of a prestateTracer result into the
+// state_diffs table.
+func exportStateDiffs(result json.RawMessage, write func(table int, row ...interface{}) error) error {
+	var (
+		pre  map[common.Address]*exportAccount
+		post map[common.Address]*exportAccount
+		diff struct {
+			Pre  map[common.Address]*exportAccount `json:"pre"`
+			Post map[common.Address]*exportAccount `json:"post"`
+		}
+	)
+	// Results in diff mode have pre and post sections, otherwise only the former
+	if err := json.Unmarshal(result, &pre); err != nil {
+		if err := json.Unmarshal(result, &diff); err != nil {
+			return err
+		}
+		pre, post = diff.Pre, diff.Post
+	}
+	addrs := make(map[common.Address]struct{})
+	for addr := range pre {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range post {
+		addrs[addr] = struct{}{}
+	}
+	sorted := make([]common.Address, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	for _, addr := range sorted {
+		before, after := pre[addr], post[addr]
+		existed := before != nil
+		if before == nil {
+			before = new(exportAccount)
+		}
+		// In diff mode, post holds the changed fields only, unless the account was
+		// deleted, in which case it's missing altogether
+		deleted := post != nil && after == nil
+		if after == nil {
+			after = new(exportAccount)
+		}
+		for _, field := range []string{"balance", "nonce", "code"} {
+			from, to := before.field(field, existed), after.field(field, false)
+			if post != nil && to == "" && !deleted {
+				continue // Unchanged
+			}
+			if err := write(0, hexutil.Encode(addr[:]), field, "", from, to); err != nil {
+				return err
+			}
+		}
+		// Only the changed storage slots are reported in diff mode, with cleared
+		// slots missing from post and empty ones missing from pre
+		slots := make([]common.Hash, 0, len(before.Storage)+len(after.Storage))
+		for slot := range before.Storage {
+			slots = append(slots, slot)
+		}
+		for slot := range after.Storage {
+			if _, ok := before.Storage[slot]; !ok {
+				slots = append(slots, slot)
+			}
+		}
+		sort.Slice(slots, func(i, j int) bool {
+			return bytes.Compare(slots[i][:], slots[j][:]) < 0
+		})
+		for _, slot := range slots {
+			from, to := before.Storage[slot].Hex(), ""
+			if post != nil && !deleted {
+				to = after.Storage[slot].Hex()
+			}
+			if err := write(0, hexutil.Encode(addr[:]), "storage", slot.Hex(), from, to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// field returns the string representation of an account field, empty if absent.
+// Zero nonces and empty code are omitted by the tracer, so they are filled in if
+// the account is known to exist.
+func (a *exportAccount) field(name string, exists bool) string {
+	switch name {
+	case "balance":
+		if a.Balance != nil {
+			return a.Balance.ToInt().String()
+		}
+	case "nonce":
+		if a.Nonce != nil {
+			return strconv.FormatUint(*a.Nonce, 10)
+		}
+		if exists {
+			return "0"
+		}
+	case "code":
+		if a.Code != nil || exists {
+			return hexutil.Encode(a.Code)
+		}
+	}
+	return ""
+}
+
+// exportResults writes a tracer result as is into the results table.
+func exportResults(result json.RawMessage, write func(table int, row ...interface{}) error) error {
+	if len(result) == 0 {
+		return errors.New("empty result")
+	}
+	return write(0, string(result))
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestExportTraces(t *testing.T) {
+	backend, _, _ := newTraceTestBackend(t)
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	// Export the struct logs of blocks [1, 6] as CSV
+	dir := t.TempDir()
+	files, err := api.ExportTraces(context.Background(), 1, 6, &TraceExportConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("failed to export traces: %v", err)
+	}
+	if want := []string{filepath.Join(dir, "results-1-6.csv")}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("file mismatch: have %v, want %v", files, want)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("failed to open export: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	var want [][]string
+	for number := rpc.BlockNumber(1); number <= 6; number++ {
+		block, _ := backend.BlockByNumber(context.Background(), number)
+		for _, tx := range block.Transactions() {
+			want = append(want, []string{block.Number().String(), block.Hash().Hex(), tx.Hash().Hex()})
+		}
+	}
+	if len(records) != len(want)+1 {
+		t.Fatalf("row count mismatch: have %d, want %d", len(records)-1, len(want))
+	}
+	if have := strings.Join(records[0], ","); have != "block_number,block_hash,tx_index,tx_hash,result" {
+		t.Errorf("header mismatch: %s", have)
+	}
+	for i, record := range records[1:] {
+		if have := []string{record[0], record[1], record[3]}; !reflect.DeepEqual(have, want[i]) {
+			t.Errorf("row %d mismatch: have %v, want %v", i, have, want[i])
+		}
+		if !json.Valid([]byte(record[4])) {
+			t.Errorf("row %d: invalid result %s", i, record[4])
+		}
+	}
+	// Export the same range as Parquet
+	files, err = api.ExportTraces(context.Background(), 1, 6, &TraceExportConfig{Dir: dir, Format: "parquet"})
+	if err != nil {
+		t.Fatalf("failed to export traces: %v", err)
+	}
+	blob, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if filepath.Base(files[0]) != "results-1-6.parquet" || !bytes.HasPrefix(blob, []byte("PAR1")) || !bytes.HasSuffix(blob, []byte("PAR1")) {
+		t.Errorf("invalid parquet export %s", files[0])
+	}
+	// Invalid requests are rejected without leaving files behind
+	if _, err := api.ExportTraces(context.Background(), 6, 1, &TraceExportConfig{Dir: dir}); err == nil {
+		t.Errorf("reversed range accepted")
+	}
+	if _, err := api.ExportTraces(context.Background(), 1, 6, &TraceExportConfig{Dir: dir, Format: "json"}); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("file count mismatch: have %d, want 2", len(entries))
+	}
+}
+
+// exportRows runs an exporter on a result, collecting the rows per table.
+func exportRows(t *testing.T, export func(json.RawMessage, func(int, ...interface{}) error) error, result string) [][][]interface{} {
+	rows := make([][][]interface{}, 2)
+	err := export(json.RawMessage(result), func(table int, row ...interface{}) error {
+		rows[table] = append(rows[table], row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to export result: %v", err)
+	}
+	return rows
+}
+
+func TestExportCalls(t *testing.T) {
+	rows := exportRows(t, exportCalls, `{
+		"type": "CALL", "from": "0x00000000000000000000000000000000000000aa", "to": "0x00000000000000000000000000000000000000bb",
+		"value": "0x10", "gas": "0x100", "gasUsed": "0x80", "input": "0x01", "output": "0x02",
+		"logs": [{"address": "0x00000000000000000000000000000000000000bb", "topics": ["0x0000000000000000000000000000000000000000000000000000000000000001"], "data": "0x"}],
+		"calls": [
+			{"type": "STATICCALL", "from": "0x00000000000000000000000000000000000000bb", "to": "0x00000000000000000000000000000000000000cc", "gas": "0x50", "gasUsed": "0x10", "input": "0x"},
+			{"type": "CREATE", "from": "0x00000000000000000000000000000000000000bb", "value": "0x0", "gas": "0x50", "gasUsed": "0x50", "input": "0x60", "error": "out of gas",
+			 "calls": [{"type": "CALL", "from": "0x00000000000000000000000000000000000000dd", "to": "0x00000000000000000000000000000000000000ee", "gas": "0x1", "gasUsed": "0x1", "input": "0x",
+			  "logs": [{"address": "0x00000000000000000000000000000000000000dd", "topics": [], "data": "0xff"}]}]}
+		]
+	}`)
+	calls := [][]interface{}{
+		{"", 0, "CALL", "0x00000000000000000000000000000000000000aa", "0x00000000000000000000000000000000000000bb", "16", uint64(256), uint64(128), "0x01", "0x02", "", ""},
+		{"0", 1, "STATICCALL", "0x00000000000000000000000000000000000000bb", "0x00000000000000000000000000000000000000cc", "0", uint64(80), uint64(16), "0x", "0x", "", ""},
+		{"1", 1, "CREATE", "0x00000000000000000000000000000000000000bb", "", "0", uint64(80), uint64(80), "0x60", "0x", "out of gas", ""},
+		{"1.0", 2, "CALL", "0x00000000000000000000000000000000000000dd", "0x00000000000000000000000000000000000000ee", "0", uint64(1), uint64(1), "0x", "0x", "", ""},
+	}
+	if !reflect.DeepEqual(rows[0], calls) {
+		t.Errorf("calls mismatch:\nhave %v\nwant %v", rows[0], calls)
+	}
+	logs := [][]interface{}{
+		{0, "", "0x00000000000000000000000000000000000000bb", "0x0000000000000000000000000000000000000000000000000000000000000001", "", "", "", "0x"},
+		{1, "1.0", "0x00000000000000000000000000000000000000dd", "", "", "", "", "0xff"},
+	}
+	if !reflect.DeepEqual(rows[1], logs) {
+		t.Errorf("logs mismatch:\nhave %v\nwant %v", rows[1], logs)
+	}
+}
+
+func TestExportStateDiffs(t *testing.T) {
+	const (
+		aa   = "0x00000000000000000000000000000000000000aa"
+		bb   = "0x00000000000000000000000000000000000000bb"
+		cc   = "0x00000000000000000000000000000000000000cc"
+		zero = "0x0000000000000000000000000000000000000000000000000000000000000000"
+		one  = "0x0000000000000000000000000000000000000000000000000000000000000001"
+		two  = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	)
+	// Prestate mode reports the accounts touched, without post values
+	rows := exportRows(t, exportStateDiffs, `{"`+aa+`": {"balance": "0x10", "storage": {"`+one+`": "`+two+`"}}}`)
+	want := [][]interface{}{
+		{aa, "balance", "", "16", ""},
+		{aa, "nonce", "", "0", ""},
+		{aa, "code", "", "0x", ""},
+		{aa, "storage", one, two, ""},
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("prestate mismatch:\nhave %v\nwant %v", rows[0], want)
+	}
+	// Diff mode reports the changed fields only: aa is modified (with a slot
+	// cleared and one set), bb is created and cc is deleted
+	rows = exportRows(t, exportStateDiffs, `{
+		"pre":  {"`+aa+`": {"balance": "0x10", "nonce": 1, "storage": {"`+one+`": "`+two+`"}}, "`+cc+`": {"balance": "0x1", "code": "0x60"}},
+		"post": {"`+aa+`": {"balance": "0x8", "nonce": 2, "storage": {"`+two+`": "`+one+`"}}, "`+bb+`": {"balance": "0x2"}}
+	}`)
+	want = [][]interface{}{
+		{aa, "balance", "", "16", "8"},
+		{aa, "nonce", "", "1", "2"},
+		{aa, "storage", one, two, zero},
+		{aa, "storage", two, zero, one},
+		{bb, "balance", "", "", "2"},
+		{cc, "balance", "", "1", ""},
+		{cc, "nonce", "", "0", ""},
+		{cc, "code", "", "0x60", ""},
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("diff mismatch:\nhave %v\nwant %v", rows[0], want)
+	}
+}
+
+// Tests that the output directory of an export can't be set over RPC.
+func TestExportTracesConfigDir(t *testing.T) {
+	var config TraceExportConfig
+	if err := json.Unmarshal([]byte(`{"tracer": "callTracer", "dir": "/etc", "Dir": "/etc", "format": "parquet"}`), &config); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if config.Dir != "" {
+		t.Errorf("output directory set from json: %q", config.Dir)
+	}
+	if config.Tracer == nil || *config.Tracer != "callTracer" || config.Format != "parquet" {
+		t.Errorf("config mismatch: %+v", config)
+	}
+}
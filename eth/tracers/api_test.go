@@ -325,6 +325,74 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 2, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	// Fund account 1 in the first bundle, then spend the funds in the second one,
+	// which would fail without the state changes of the first
+	var (
+		head   = rpc.LatestBlockNumber
+		number = (*hexutil.Big)(big.NewInt(0x1337))
+	)
+	bundles := []Bundle{
+		{
+			Transactions: []ethapi.TransactionArgs{
+				{From: &accounts[0].addr, To: &accounts[1].addr, Value: (*hexutil.Big)(big.NewInt(params.GWei))},
+			},
+		},
+		{
+			Transactions: []ethapi.TransactionArgs{
+				{From: &accounts[1].addr, To: &accounts[2].addr, Value: (*hexutil.Big)(big.NewInt(params.GWei / 2))},
+				{From: &accounts[1].addr, Input: &hexutil.Bytes{0x43}}, // blocknumber
+			},
+			BlockOverrides: &ethapi.BlockOverrides{Number: number},
+		},
+	}
+	results, err := api.TraceCallMany(context.Background(), bundles, rpc.BlockNumberOrHash{BlockNumber: &head}, nil)
+	if err != nil {
+		t.Fatalf("failed to trace calls: %v", err)
+	}
+	if len(results) != 2 || len(results[0]) != 1 || len(results[1]) != 2 {
+		t.Fatalf("result count mismatch: %v", results)
+	}
+	var traces [][]*logger.ExecutionResult
+	blob, _ := json.Marshal(results)
+	if err := json.Unmarshal(blob, &traces); err != nil {
+		t.Fatalf("failed to decode traces: %v", err)
+	}
+	for i, bundle := range traces {
+		for j, trace := range bundle {
+			if trace.Failed {
+				t.Errorf("bundle %d, call %d: execution failed", i, j)
+			}
+		}
+	}
+	logs := traces[1][1].StructLogs
+	if len(logs) != 2 || len(*logs[1].Stack) != 1 || (*logs[1].Stack)[0] != "0x1337" {
+		t.Errorf("block override not applied: %+v", logs)
+	}
+	// The bundles are traced on top of each other, not the block state
+	if _, err := api.TraceCallMany(context.Background(), bundles[1:], rpc.BlockNumberOrHash{BlockNumber: &head}, nil); err == nil {
+		t.Errorf("spending unfunded account succeeded")
+	}
+	// The state of the block is left untouched
+	state, _ := backend.chain.State()
+	if balance := state.GetBalance(accounts[1].addr); balance.Sign() != 0 {
+		t.Errorf("state modified: balance %v", balance)
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,369 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// flatCallTracerName is the name of the native tracer producing the traces of
+// the Parity/OpenEthereum style trace namespace.
+const flatCallTracerName = "flatCallTracer"
+
+// TraceAPI is the collection of Parity/OpenEthereum style tracing APIs, reporting
+// the flattened call frames of transactions, blocks and whole chain segments.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the trace namespace methods of
+// the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceFilterArgs represents the arguments to search the traces of a block range
+// with. All fields are optional.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`   // First block to search (default = latest)
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`     // Last block to search (default = latest)
+	FromAddress []common.Address `json:"fromAddress"` // Senders to match, any if empty
+	ToAddress   []common.Address `json:"toAddress"`   // Recipients to match, any if empty
+	After       *uint64          `json:"after"`       // Number of matching traces to skip
+	Count       *uint64          `json:"count"`       // Maximum number of traces to return
+}
+
+// traceFilter selects the traces matching the address filters of a search and
+// paginates them.
+type traceFilter struct {
+	from  map[common.Address]struct{} // Senders to match, any if empty
+	to    map[common.Address]struct{} // Recipients to match, any if empty
+	after uint64                      // Number of matching traces still to skip
+	count uint64                      // Number of matching traces still to return
+
+	traces []json.RawMessage // Matching traces collected so far
+}
+
+// newTraceFilter creates a trace filter from the search arguments.
+func newTraceFilter(args *TraceFilterArgs) *traceFilter {
+	filter := &traceFilter{
+		from:   make(map[common.Address]struct{}),
+		to:     make(map[common.Address]struct{}),
+		count:  math.MaxUint64,
+		traces: []json.RawMessage{},
+	}
+	for _, addr := range args.FromAddress {
+		filter.from[addr] = struct{}{}
+	}
+	for _, addr := range args.ToAddress {
+		filter.to[addr] = struct{}{}
+	}
+	if args.After != nil {
+		filter.after = *args.After
+	}
+	if args.Count != nil {
+		filter.count = *args.Count
+	}
+	return filter
+}
+
+// full returns whether the filter collected the requested number of traces.
+func (f *traceFilter) full() bool {
+	return f.count == 0
+}
+
+// add runs the given traces through the filter, collecting the matching ones
+// until the requested number of traces is reached.
+func (f *traceFilter) add(traces []json.RawMessage) error {
+	for _, trace := range traces {
+		if f.full() {
+			return nil
+		}
+		match, err := f.match(trace)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		if f.after > 0 {
+			f.after--
+			continue
+		}
+		f.traces = append(f.traces, trace)
+		f.count--
+	}
+	return nil
+}
+
+// match checks whether the sender and the recipient of a trace match the filter.
+// As with Parity, the recipient of a creation is the created contract, the sender
+// and recipient of a self-destruct are the destructed contract and the refund
+// address, and rewards only have a recipient.
+func (f *traceFilter) match(blob json.RawMessage) (bool, error) {
+	var trace struct {
+		Type   string `json:"type"`
+		Action struct {
+			From          *common.Address `json:"from"`
+			To            *common.Address `json:"to"`
+			Address       *common.Address `json:"address"`
+			RefundAddress *common.Address `json:"refundAddress"`
+			Author        *common.Address `json:"author"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(blob, &trace); err != nil {
+		return false, err
+	}
+	var from, to *common.Address
+	switch trace.Type {
+	case "call":
+		from, to = trace.Action.From, trace.Action.To
+	case "create":
+		from = trace.Action.From
+		if trace.Result != nil {
+			to = trace.Result.Address
+		}
+	case "suicide":
+		from, to = trace.Action.Address, trace.Action.RefundAddress
+	case "reward":
+		to = trace.Action.Author
+	}
+	return matchTraceAddress(f.from, from) && matchTraceAddress(f.to, to), nil
+}
+
+// matchTraceAddress checks whether an address is contained within a filter set,
+// an empty set matching any address.
+func matchTraceAddress(set map[common.Address]struct{}, addr *common.Address) bool {
+	if len(set) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	_, ok := set[*addr]
+	return ok
+}
+
+// flatTraceConfig returns the trace configuration running the flat call tracer.
+func flatTraceConfig() *TraceConfig {
+	tracer := flatCallTracerName
+	return &TraceConfig{Tracer: &tracer}
+}
+
+// splitFlatTraces splits the result of the flat call tracer into the individual
+// trace entries.
+func splitFlatTraces(result interface{}) ([]json.RawMessage, error) {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		var err error
+		if blob, err = json.Marshal(result); err != nil {
+			return nil, err
+		}
+	}
+	var traces []json.RawMessage
+	if err := json.Unmarshal(blob, &traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// splitBlockTraces splits the flat call tracer results of the transactions of a
+// block into the individual trace entries, failing if any transaction could not
+// be traced.
+func splitBlockTraces(number uint64, results []*txTraceResult) ([]json.RawMessage, error) {
+	var traces []json.RawMessage
+	for i, result := range results {
+		if result == nil || result.Error != "" {
+			var err string
+			if result != nil {
+				err = result.Error
+			}
+			return nil, fmt.Errorf("block #%d transaction %d: tracing failed: %s", number, i, err)
+		}
+		txTraces, err := splitFlatTraces(result.Result)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	return traces, nil
+}
+
+// rewardTraces returns the flat traces of the mining rewards of a block. Only the
+// blocks sealed by ethash have rewards, neither clique nor proof-of-stake blocks
+// (rewarded by the consensus layer) have any.
+func (api *TraceAPI) rewardTraces(block *types.Block) ([]json.RawMessage, error) {
+	if block.NumberU64() == 0 {
+		return nil, nil
+	}
+	engine := api.api.backend.Engine()
+	if beacon, ok := engine.(*beacon.Beacon); ok {
+		if beacon.IsPoSHeader(block.Header()) {
+			return nil, nil
+		}
+		engine = beacon.InnerEngine()
+	}
+	if _, ok := engine.(*ethash.Ethash); !ok {
+		return nil, nil
+	}
+	reward, uncleRewards := ethash.Rewards(api.api.backend.ChainConfig(), block.Header(), block.Uncles())
+	res, err := RewardTraces(flatCallTracerName, block.Header(), block.Uncles(), reward, uncleRewards)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	return splitFlatTraces(res)
+}
+
+// Transaction returns the flattened call frames of a transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]json.RawMessage, error) {
+	result, err := api.api.TraceTransaction(ctx, hash, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	return splitFlatTraces(result)
+}
+
+// Block returns the flattened call frames of all the transactions of a block,
+// followed by its mining rewards if it was sealed by ethash.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces, err := splitBlockTraces(block.NumberU64(), results)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := api.rewardTraces(block)
+	if err != nil {
+		return nil, err
+	}
+	return append(traces, rewards...), nil
+}
+
+// Filter searches the flattened call frames and mining rewards of a range of
+// blocks for the ones sent from or to the given addresses. The blocks are traced
+// concurrently, stopping as soon as the requested number of traces is found.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	// Resolve the block range to search
+	fromNumber, toNumber := rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		fromNumber = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		toNumber = *args.ToBlock
+	}
+	from, err := api.api.blockByNumber(ctx, fromNumber)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.api.blockByNumber(ctx, toNumber)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", to.NumberU64(), from.NumberU64())
+	}
+	filter := newTraceFilter(&args)
+	if filter.full() {
+		return filter.traces, nil
+	}
+	// The chain tracer excludes the start block, so start from its parent
+	start := from
+	if from.NumberU64() > 0 {
+		if start, err = api.api.blockByNumber(ctx, rpc.BlockNumber(from.NumberU64()-1)); err != nil {
+			return nil, err
+		}
+	}
+	if start.NumberU64() == to.NumberU64() {
+		return filter.traces, nil // Genesis only, nothing to trace
+	}
+	closed := make(chan interface{})
+	resCh := api.api.traceChain(start, to, flatTraceConfig(), closed)
+	defer func() {
+		// Abort the tracing if still running, draining any in-flight results
+		close(closed)
+		go func() {
+			for range resCh {
+			}
+		}()
+	}()
+	// Collect the traces block by block, followed by their rewards. The chain
+	// tracer skips the blocks without transactions but always reports the last
+	// one, so the rewards of the skipped blocks are added in between.
+	next := start.NumberU64() + 1
+	for res := range resCh {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for ; next < uint64(res.Block); next++ {
+			block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(next))
+			if err != nil {
+				return nil, err
+			}
+			if err := api.filterRewards(filter, block); err != nil {
+				return nil, err
+			}
+		}
+		next++
+
+		traces, err := splitBlockTraces(uint64(res.Block), res.Traces)
+		if err != nil {
+			return nil, err
+		}
+		if err := filter.add(traces); err != nil {
+			return nil, err
+		}
+		block, err := api.api.blockByHash(ctx, res.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.filterRewards(filter, block); err != nil {
+			return nil, err
+		}
+		if filter.full() || uint64(res.Block) == to.NumberU64() {
+			return filter.traces, nil
+		}
+	}
+	return nil, errors.New("chain tracing aborted")
+}
+
+// filterRewards runs the reward traces of a block through a trace filter.
+func (api *TraceAPI) filterRewards(filter *traceFilter, block *types.Block) error {
+	rewards, err := api.rewardTraces(block)
+	if err != nil {
+		return err
+	}
+	return filter.add(rewards)
+}
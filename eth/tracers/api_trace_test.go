@@ -0,0 +1,261 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testFlatTracer is a minimal stand-in for the native flat call tracer (which
+// cannot be imported here), reporting the top call frame only.
+type testFlatTracer struct {
+	ctx      *Context
+	from, to common.Address
+}
+
+func init() {
+	RegisterLookup(false, func(name string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
+		if name != flatCallTracerName {
+			return nil, fmt.Errorf("unknown tracer %s", name)
+		}
+		return &testFlatTracer{ctx: ctx}, nil
+	})
+	RegisterDeterministic(flatCallTracerName)
+	RegisterRewards(flatCallTracerName, func(header *types.Header, uncles []*types.Header, reward *big.Int, uncleRewards []*big.Int) (json.RawMessage, error) {
+		traces := []interface{}{map[string]interface{}{
+			"type":        "reward",
+			"action":      map[string]interface{}{"author": header.Coinbase, "rewardType": "block", "value": (*hexutil.Big)(reward)},
+			"blockNumber": header.Number.Uint64(),
+		}}
+		for i, uncle := range uncles {
+			traces = append(traces, map[string]interface{}{
+				"type":        "reward",
+				"action":      map[string]interface{}{"author": uncle.Coinbase, "rewardType": "uncle", "value": (*hexutil.Big)(uncleRewards[i])},
+				"blockNumber": header.Number.Uint64(),
+			})
+		}
+		return json.Marshal(traces)
+	})
+}
+
+func (t *testFlatTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *testFlatTracer) CaptureTxEnd(restGas uint64)    {}
+func (t *testFlatTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.from, t.to = from, to
+}
+func (t *testFlatTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {}
+func (t *testFlatTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (t *testFlatTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (t *testFlatTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+func (t *testFlatTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+func (t *testFlatTracer) Stop(err error) {}
+
+func (t *testFlatTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal([]interface{}{map[string]interface{}{
+		"type":            "call",
+		"action":          map[string]interface{}{"callType": "call", "from": t.from, "to": t.to},
+		"transactionHash": t.ctx.TxHash,
+	}})
+}
+
+// testTrace is the subset of a trace entry checked by the tests.
+type testTrace struct {
+	Type   string `json:"type"`
+	Action struct {
+		From       common.Address `json:"from"`
+		To         common.Address `json:"to"`
+		Author     common.Address `json:"author"`
+		RewardType string         `json:"rewardType"`
+	} `json:"action"`
+	BlockNumber     uint64       `json:"blockNumber"`
+	TransactionHash *common.Hash `json:"transactionHash"`
+}
+
+func decodeTestTraces(t *testing.T, blobs []json.RawMessage) []testTrace {
+	traces := make([]testTrace, len(blobs))
+	for i, blob := range blobs {
+		if err := json.Unmarshal(blob, &traces[i]); err != nil {
+			t.Fatalf("failed to decode trace %d: %v", i, err)
+		}
+	}
+	return traces
+}
+
+func newTraceTestBackend(t *testing.T) (*testBackend, Accounts, []common.Hash) {
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	// Send a transaction to account 1 on even blocks and to account 2 on every
+	// third one, leaving the rest empty
+	var (
+		signer = types.HomesteadSigner{}
+		hashes []common.Hash
+		nonce  uint64
+	)
+	backend := newTestBackend(t, 12, genesis, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0xc0, byte(i + 1)})
+
+		for j, to := range []common.Address{accounts[1].addr, accounts[2].addr} {
+			if (i+1)%(j+2) != 0 {
+				continue
+			}
+			tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+			nonce++
+		}
+	})
+	return backend, accounts, hashes
+}
+
+func TestTraceAPIBlockAndTransaction(t *testing.T) {
+	backend, accounts, hashes := newTraceTestBackend(t)
+	defer backend.chain.Stop()
+	api := NewTraceAPI(backend)
+
+	// Block 6 contains transfers to both accounts, followed by the block reward
+	res, err := api.Block(context.Background(), rpc.BlockNumber(6))
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	traces := decodeTestTraces(t, res)
+	if len(traces) != 3 {
+		t.Fatalf("trace count mismatch: have %d, want 3", len(traces))
+	}
+	if traces[0].Type != "call" || traces[0].Action.To != accounts[1].addr {
+		t.Errorf("trace 0 mismatch: %+v", traces[0])
+	}
+	if traces[1].Type != "call" || traces[1].Action.To != accounts[2].addr {
+		t.Errorf("trace 1 mismatch: %+v", traces[1])
+	}
+	if traces[2].Type != "reward" || traces[2].Action.RewardType != "block" || traces[2].Action.Author != (common.Address{0xc0, 6}) || traces[2].BlockNumber != 6 {
+		t.Errorf("trace 2 mismatch: %+v", traces[2])
+	}
+	// The debug API reports one result per transaction, without the rewards
+	results, err := api.api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(6), flatTraceConfig())
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	// Transactions only report their own frames
+	res, err = api.Transaction(context.Background(), hashes[0])
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	traces = decodeTestTraces(t, res)
+	if len(traces) != 1 || *traces[0].TransactionHash != hashes[0] || traces[0].Action.From != accounts[0].addr {
+		t.Errorf("transaction trace mismatch: %+v", traces)
+	}
+}
+
+func TestTraceAPIFilter(t *testing.T) {
+	backend, accounts, _ := newTraceTestBackend(t)
+	defer backend.chain.Stop()
+	api := NewTraceAPI(backend)
+
+	number := func(n int64) *rpc.BlockNumber {
+		num := rpc.BlockNumber(n)
+		return &num
+	}
+	count := func(n uint64) *uint64 {
+		return &n
+	}
+	var tests = []struct {
+		args   TraceFilterArgs
+		blocks []uint64 // Block numbers of the expected traces
+	}{
+		// Transfers to account 1 are on even blocks
+		{
+			args:   TraceFilterArgs{FromBlock: number(0), ToBlock: number(12), ToAddress: []common.Address{accounts[1].addr}},
+			blocks: []uint64{2, 4, 6, 8, 10, 12},
+		},
+		// Ranges are inclusive on both ends
+		{
+			args:   TraceFilterArgs{FromBlock: number(3), ToBlock: number(9), ToAddress: []common.Address{accounts[2].addr}},
+			blocks: []uint64{3, 6, 9},
+		},
+		// Senders and recipients must both match
+		{
+			args:   TraceFilterArgs{FromBlock: number(1), ToBlock: number(12), FromAddress: []common.Address{accounts[1].addr}, ToAddress: []common.Address{accounts[2].addr}},
+			blocks: []uint64{},
+		},
+		// Paging skips and limits the matching traces
+		{
+			args:   TraceFilterArgs{FromBlock: number(1), ToBlock: number(12), FromAddress: []common.Address{accounts[0].addr}, After: count(2), Count: count(3)},
+			blocks: []uint64{4, 6, 6},
+		},
+		// Rewards of blocks without transactions are included too
+		{
+			args:   TraceFilterArgs{FromBlock: number(1), ToBlock: number(12), ToAddress: []common.Address{{0xc0, 1}, {0xc0, 5}, {0xc0, 12}}},
+			blocks: []uint64{1, 5, 12},
+		},
+		// The latest block is searched by default
+		{
+			args:   TraceFilterArgs{},
+			blocks: []uint64{12, 12, 12},
+		},
+	}
+	for i, tt := range tests {
+		res, err := api.Filter(context.Background(), tt.args)
+		if err != nil {
+			t.Errorf("test %d: failed to filter traces: %v", i, err)
+			continue
+		}
+		traces := decodeTestTraces(t, res)
+		blocks := make([]uint64, len(traces))
+		for j, trace := range traces {
+			blocks[j] = trace.BlockNumber
+			if trace.Type == "call" {
+				// The test tracer doesn't report the block, derive it from the tx
+				_, _, number, _, err := backend.GetTransaction(context.Background(), *trace.TransactionHash)
+				if err != nil {
+					t.Fatalf("test %d: failed to retrieve traced transaction: %v", i, err)
+				}
+				blocks[j] = number
+			}
+		}
+		if fmt.Sprint(blocks) != fmt.Sprint(tt.blocks) {
+			t.Errorf("test %d: trace blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+		}
+	}
+	// Reversed ranges are rejected
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: number(5), ToBlock: number(4)}); err == nil {
+		t.Errorf("reversed range accepted")
+	}
+}
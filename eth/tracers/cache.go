@@ -0,0 +1,257 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// traceCacheSuffix is the file extension of the cached block trace results.
+const traceCacheSuffix = ".json"
+
+// TraceCache is an on-disk cache of block trace results, keyed by block hash,
+// tracer and tracer configuration. The cache is limited in total size, evicting
+// the least recently used results first. The recency of the results is tracked
+// via the modification times of the files, so it's retained across restarts.
+// Only the results of deterministic tracers are cached (see RegisterDeterministic).
+type TraceCache struct {
+	dir   string // Directory to store the cached results in
+	limit uint64 // Maximum total size of the cached results
+	size  uint64 // Current total size of the cached results
+
+	lru   *list.List                    // Cached results, most recently used first
+	items map[common.Hash]*list.Element // Cached results by key
+	lock  sync.Mutex
+}
+
+// traceCacheItem is the metadata of a single cached result.
+type traceCacheItem struct {
+	key  common.Hash
+	size uint64
+}
+
+// NewTraceCache opens the trace result cache in the given directory, creating
+// it if it doesn't exist yet, and limits it to the given total size in bytes.
+func NewTraceCache(dir string, limit uint64) (*TraceCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	cache := &TraceCache{
+		dir:   dir,
+		limit: limit,
+		lru:   list.New(),
+		items: make(map[common.Hash]*list.Element),
+	}
+	// Index any previously cached results, most recently used first
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type file struct {
+		key  common.Hash
+		size uint64
+		time time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, traceCacheSuffix) {
+			continue
+		}
+		key := common.HexToHash(strings.TrimSuffix(name, traceCacheSuffix))
+		if cache.path(key) != filepath.Join(dir, name) {
+			continue // Not a cache file
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{key: key, size: uint64(info.Size()), time: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].time.After(files[j].time)
+	})
+	for _, f := range files {
+		cache.items[f.key] = cache.lru.PushBack(&traceCacheItem{key: f.key, size: f.size})
+		cache.size += f.size
+	}
+	cache.evict()
+	log.Info("Opened trace cache", "dir", dir, "results", len(cache.items), "size", common.StorageSize(cache.size), "limit", common.StorageSize(limit))
+	return cache, nil
+}
+
+// traceCacheable returns whether the results of a trace configuration can be
+// cached, i.e. whether its tracer is deterministic.
+func traceCacheable(config *TraceConfig) bool {
+	if config == nil || config.Tracer == nil {
+		return true
+	}
+	return Deterministic(*config.Tracer)
+}
+
+// traceCacheKey derives the cache key of the trace results of a block, from the
+// block hash, the tracer name and a hash of the tracer configuration. Options
+// not affecting the results of successful traces (timeout, reexec) are ignored.
+func traceCacheKey(hash common.Hash, config *TraceConfig) (common.Hash, error) {
+	var (
+		tracer       string
+		loggerConfig *logger.Config
+		tracerConfig []byte
+	)
+	if config != nil {
+		if config.Tracer != nil {
+			tracer = *config.Tracer
+		}
+		loggerConfig = config.Config
+		if len(config.TracerConfig) > 0 {
+			buf := new(bytes.Buffer)
+			if err := json.Compact(buf, config.TracerConfig); err != nil {
+				return common.Hash{}, err
+			}
+			tracerConfig = buf.Bytes()
+		}
+	}
+	blob, err := json.Marshal(loggerConfig)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	configHash := crypto.Keccak256(blob, tracerConfig)
+	return crypto.Keccak256Hash(hash[:], crypto.Keccak256([]byte(tracer)), configHash), nil
+}
+
+// path returns the file path of a cached result.
+func (c *TraceCache) path(key common.Hash) string {
+	return filepath.Join(c.dir, key.Hex()[2:]+traceCacheSuffix)
+}
+
+// get retrieves the cached trace results of a block, if available.
+func (c *TraceCache) get(hash common.Hash, config *TraceConfig) []*txTraceResult {
+	if !traceCacheable(config) {
+		return nil
+	}
+	key, err := traceCacheKey(hash, config)
+	if err != nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem := c.items[key]
+	if elem == nil {
+		return nil
+	}
+	blob, err := os.ReadFile(c.path(key))
+	if err != nil {
+		log.Warn("Failed to read cached trace", "hash", hash, "err", err)
+		c.remove(elem)
+		return nil
+	}
+	var cached []struct {
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(blob, &cached); err != nil {
+		log.Warn("Failed to decode cached trace", "hash", hash, "err", err)
+		c.remove(elem)
+		return nil
+	}
+	// Mark the result as recently used, persisting it into the file too
+	c.lru.MoveToFront(elem)
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now)
+
+	results := make([]*txTraceResult, len(cached))
+	for i, res := range cached {
+		results[i] = &txTraceResult{Error: res.Error}
+		if res.Result != nil {
+			results[i].Result = res.Result
+		}
+	}
+	return results
+}
+
+// put inserts the trace results of a block into the cache, evicting the least
+// recently used results if the size limit is exceeded.
+func (c *TraceCache) put(hash common.Hash, config *TraceConfig, results []*txTraceResult) {
+	if !traceCacheable(config) {
+		return
+	}
+	key, err := traceCacheKey(hash, config)
+	if err != nil {
+		return
+	}
+	blob, err := json.Marshal(results)
+	if err != nil {
+		log.Warn("Failed to encode trace for caching", "hash", hash, "err", err)
+		return
+	}
+	if uint64(len(blob)) > c.limit {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem := c.items[key]; elem != nil {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	// Write the results atomically, so a crash doesn't leave corrupt entries
+	path := c.path(key)
+	if err := os.WriteFile(path+".tmp", blob, 0600); err != nil {
+		log.Warn("Failed to write cached trace", "hash", hash, "err", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		log.Warn("Failed to write cached trace", "hash", hash, "err", err)
+		os.Remove(path + ".tmp")
+		return
+	}
+	c.items[key] = c.lru.PushFront(&traceCacheItem{key: key, size: uint64(len(blob))})
+	c.size += uint64(len(blob))
+	c.evict()
+}
+
+// evict drops the least recently used results until the cache fits its limit.
+func (c *TraceCache) evict() {
+	for c.size > c.limit {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops a result from the cache, deleting its file.
+func (c *TraceCache) remove(elem *list.Element) {
+	item := c.lru.Remove(elem).(*traceCacheItem)
+	delete(c.items, item.key)
+	c.size -= item.size
+
+	if err := os.Remove(c.path(item.key)); err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to delete cached trace", "key", item.key, "err", err)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+)
+
+// newTestTraceResults creates block trace results of roughly the given size.
+func newTestTraceResults(size int) []*txTraceResult {
+	return []*txTraceResult{
+		{Result: json.RawMessage(`"` + strings.Repeat("a", size) + `"`)},
+		{Error: "failed"},
+	}
+}
+
+// Tests that trace results are keyed by block, tracer and tracer configuration.
+func TestTraceCacheKeys(t *testing.T) {
+	cache, err := NewTraceCache(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	var (
+		tracer  = flatCallTracerName
+		block   = common.Hash{0x01}
+		config  = &TraceConfig{Tracer: &tracer, TracerConfig: json.RawMessage(`{"onlyTopCall": true}`)}
+		results = newTestTraceResults(10)
+	)
+	cache.put(block, config, results)
+
+	have := cache.get(block, config)
+	if len(have) != len(results) {
+		t.Fatalf("cached results mismatch: have %d, want %d", len(have), len(results))
+	}
+	blob, _ := json.Marshal(have)
+	want, _ := json.Marshal(results)
+	if string(blob) != string(want) {
+		t.Errorf("cached results mismatch: have %s, want %s", blob, want)
+	}
+	// Formatting and options not affecting the results must hit the cache
+	timeout := "1s"
+	if cache.get(block, &TraceConfig{Tracer: &tracer, Timeout: &timeout, TracerConfig: json.RawMessage(`{ "onlyTopCall":true }`)}) == nil {
+		t.Errorf("equivalent config missed the cache")
+	}
+	// Different blocks, tracers or configs must miss the cache
+	if cache.get(common.Hash{0x02}, config) != nil {
+		t.Errorf("different block hit the cache")
+	}
+	if cache.get(block, nil) != nil {
+		t.Errorf("different tracer hit the cache")
+	}
+	if cache.get(block, &TraceConfig{Tracer: &tracer}) != nil {
+		t.Errorf("different tracer config hit the cache")
+	}
+	if cache.get(block, &TraceConfig{Config: &logger.Config{EnableMemory: true}}) != nil {
+		t.Errorf("different logger config hit the cache")
+	}
+	// Tracers not declared deterministic must not be cached
+	profiler := "opProfileTracer"
+	cache.put(block, &TraceConfig{Tracer: &profiler}, results)
+	if cache.get(block, &TraceConfig{Tracer: &profiler}) != nil || len(cache.items) != 1 {
+		t.Errorf("non-deterministic tracer cached")
+	}
+}
+
+// Tests that the cache evicts the least recently used results once full, and
+// that the recency of results is retained across restarts.
+func TestTraceCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+
+	// Fill the cache with three results, leaving room for one more
+	size := len(mustMarshal(t, newTestTraceResults(1000)))
+	cache, err := NewTraceCache(dir, uint64(4*size))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	for i := byte(0); i < 3; i++ {
+		cache.put(common.Hash{i}, nil, newTestTraceResults(1000))
+	}
+	// Access the oldest result and persist the order to disk, the filesystem
+	// timestamps might not have the resolution to distinguish the inserts
+	cache.get(common.Hash{0}, nil)
+	for i, hash := range []common.Hash{{1}, {2}, {0}} {
+		key, _ := traceCacheKey(hash, nil)
+		stamp := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(cache.path(key), stamp, stamp); err != nil {
+			t.Fatalf("failed to set file time: %v", err)
+		}
+	}
+	// Reopen the cache and insert two more results, evicting the least recent
+	cache, err = NewTraceCache(dir, uint64(4*size))
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	cache.put(common.Hash{3}, nil, newTestTraceResults(1000))
+	cache.put(common.Hash{4}, nil, newTestTraceResults(1000))
+
+	for i, want := range []bool{true, false, true, true, true} {
+		if have := cache.get(common.Hash{byte(i)}, nil) != nil; have != want {
+			t.Errorf("result %d: cached mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+traceCacheSuffix))
+	if len(files) != 4 {
+		t.Errorf("cache file count mismatch: have %d, want 4", len(files))
+	}
+	// Results larger than the whole cache are not stored
+	cache.put(common.Hash{5}, nil, newTestTraceResults(5*size))
+	if cache.get(common.Hash{5}, nil) != nil || len(cache.items) != 4 {
+		t.Errorf("oversized result cached")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	return blob
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package columnar
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSVWriter writes a table as RFC 4180 comma separated values, with a header
+// line holding the column names.
+type CSVWriter struct {
+	table  Table
+	out    *csv.Writer
+	header bool // Whether the header line was already written
+}
+
+// NewCSVWriter creates a CSV writer of a table.
+func NewCSVWriter(w io.Writer, table Table) *CSVWriter {
+	return &CSVWriter{table: table, out: csv.NewWriter(w)}
+}
+
+// Write implements Writer, appending a row to the table.
+func (w *CSVWriter) Write(row []interface{}) error {
+	if err := w.table.checkRow(row); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	record := make([]string, len(row))
+	for i, column := range w.table.Columns {
+		if column.Type == Int64 {
+			record[i] = strconv.FormatInt(int64Value(row[i]), 10)
+		} else {
+			record[i] = row[i].(string)
+		}
+	}
+	return w.out.Write(record)
+}
+
+// Close implements Writer, flushing the buffered rows.
+func (w *CSVWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
+
+// writeHeader writes the header line if not yet done.
+func (w *CSVWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+
+	names := make([]string, len(w.table.Columns))
+	for i, column := range w.table.Columns {
+		names[i] = column.Name
+	}
+	return w.out.Write(names)
+}
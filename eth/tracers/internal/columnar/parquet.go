@@ -0,0 +1,244 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// parquetMagic is the magic number starting and ending a Parquet file.
+const parquetMagic = "PAR1"
+
+// ParquetRowGroupSize is the number of rows buffered into a row group before
+// it's written out.
+const ParquetRowGroupSize = 64 * 1024
+
+// Enum values of the Parquet format, see parquet.thrift of apache/parquet-format.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetRequired = 0
+	parquetUTF8     = 0 // ConvertedType of strings
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecSnappy = 1
+	parquetDataPage    = 0
+)
+
+// parquetChunk is the metadata of a written column chunk.
+type parquetChunk struct {
+	offset       int64 // Offset of the data page within the file
+	uncompressed int64 // Size of the page header and uncompressed page data
+	compressed   int64 // Size of the page header and compressed page data
+}
+
+// parquetRowGroup is the metadata of a written row group.
+type parquetRowGroup struct {
+	chunks []parquetChunk
+	rows   int64
+}
+
+// ParquetWriter writes a table in the Apache Parquet format. Rows are buffered
+// into row groups holding a single snappy compressed, plain encoded data page
+// per column. All columns are required (non-null).
+type ParquetWriter struct {
+	table   Table
+	out     io.Writer
+	offset  int64          // Number of bytes written to the output
+	columns []bytes.Buffer // Plain encoded values of the buffered rows
+	rows    int64          // Number of buffered rows
+	limit   int64          // Number of rows per row group
+	groups  []parquetRowGroup
+	err     error // Sticky write error
+}
+
+// NewParquetWriter creates a Parquet writer of a table.
+func NewParquetWriter(w io.Writer, table Table) *ParquetWriter {
+	return &ParquetWriter{
+		table:   table,
+		out:     w,
+		columns: make([]bytes.Buffer, len(table.Columns)),
+		limit:   ParquetRowGroupSize,
+	}
+}
+
+// Write implements Writer, appending a row to the table.
+func (w *ParquetWriter) Write(row []interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.table.checkRow(row); err != nil {
+		return err
+	}
+	for i, column := range w.table.Columns {
+		buf := &w.columns[i]
+		if column.Type == Int64 {
+			binary.Write(buf, binary.LittleEndian, int64Value(row[i]))
+		} else {
+			value := row[i].(string)
+			binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+			buf.WriteString(value)
+		}
+	}
+	w.rows++
+	if w.rows >= w.limit {
+		w.flush()
+	}
+	return w.err
+}
+
+// Close implements Writer, flushing the buffered rows and writing the footer
+// holding the file metadata.
+func (w *ParquetWriter) Close() error {
+	if w.rows > 0 {
+		w.flush()
+	}
+	metadata := w.metadata()
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(metadata)))
+
+	w.write(metadata)
+	w.write(length)
+	w.write([]byte(parquetMagic))
+	return w.err
+}
+
+// write writes data to the output, preceded by the magic number if it's the
+// start of the file.
+func (w *ParquetWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	if w.offset == 0 {
+		w.offset = int64(len(parquetMagic))
+		if _, w.err = io.WriteString(w.out, parquetMagic); w.err != nil {
+			return
+		}
+	}
+	var n int
+	n, w.err = w.out.Write(data)
+	w.offset += int64(n)
+}
+
+// flush writes out the buffered rows as a row group.
+func (w *ParquetWriter) flush() {
+	group := parquetRowGroup{rows: w.rows}
+	for i := range w.columns {
+		data := w.columns[i].Bytes()
+		page := snappy.Encode(nil, data)
+
+		header := newThriftWriter()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(page)))
+		header.begin(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.end()
+		blob := header.bytes()
+
+		w.write(nil) // Ensure the magic is written before taking the offset
+		group.chunks = append(group.chunks, parquetChunk{
+			offset:       w.offset,
+			uncompressed: int64(len(blob) + len(data)),
+			compressed:   int64(len(blob) + len(page)),
+		})
+		w.write(blob)
+		w.write(page)
+		w.columns[i].Reset()
+	}
+	w.groups = append(w.groups, group)
+	w.rows = 0
+}
+
+// metadata encodes the FileMetaData of the file.
+func (w *ParquetWriter) metadata() []byte {
+	var rows int64
+	for _, group := range w.groups {
+		rows += group.rows
+	}
+	meta := newThriftWriter()
+	meta.i32(1, 1)
+
+	// Flat schema, with the root element holding the columns
+	meta.list(2, thriftStruct, len(w.table.Columns)+1)
+	meta.beginElem()
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(w.table.Columns)))
+	meta.end()
+	for _, column := range w.table.Columns {
+		meta.beginElem()
+		meta.i32(1, column.parquetType())
+		meta.i32(3, parquetRequired)
+		meta.string(4, column.Name)
+		if column.Type == String {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.end()
+	}
+	meta.i64(3, rows)
+
+	meta.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		var size int64
+		for _, chunk := range group.chunks {
+			size += chunk.uncompressed
+		}
+		meta.beginElem()
+		meta.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			column := w.table.Columns[i]
+
+			meta.beginElem()
+			meta.i64(2, chunk.offset)
+			meta.begin(3)
+			meta.i32(1, column.parquetType())
+			meta.list(2, thriftI32, 1)
+			meta.i32Elem(parquetEncodingPlain)
+			meta.list(3, thriftBinary, 1)
+			meta.stringElem(column.Name)
+			meta.i32(4, parquetCodecSnappy)
+			meta.i64(5, group.rows)
+			meta.i64(6, chunk.uncompressed)
+			meta.i64(7, chunk.compressed)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+		}
+		meta.i64(2, size)
+		meta.i64(3, group.rows)
+		meta.end()
+	}
+	meta.string(6, "go-ethereum")
+	return meta.bytes()
+}
+
+// parquetType returns the Parquet physical type of a column.
+func (c Column) parquetType() int32 {
+	if c.Type == Int64 {
+		return parquetTypeInt64
+	}
+	return parquetTypeByteArray
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package columnar implements writers of tabular trace data in columnar friendly
+// file formats (CSV and Parquet), to be loaded into analytical databases.
+package columnar
+
+import (
+	"fmt"
+)
+
+// Type is the data type of a column.
+type Type int
+
+const (
+	Int64  Type = iota // Signed 64 bit integer, accepting int64, uint64 and int values
+	String             // UTF-8 string
+)
+
+// String implements fmt.Stringer.
+func (t Type) String() string {
+	switch t {
+	case Int64:
+		return "int64"
+	case String:
+		return "string"
+	default:
+		return fmt.Sprintf("type(%d)", int(t))
+	}
+}
+
+// Column is a named, typed column of a table. Columns are never null, absent
+// values are represented by zero or the empty string.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Table is the schema of a table.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Writer writes the rows of a table into an output stream.
+type Writer interface {
+	// Write appends a row to the table. The values must match the columns of the
+	// table in number and type.
+	Write(row []interface{}) error
+
+	// Close flushes any buffered rows and finalizes the output. It doesn't close
+	// the underlying stream.
+	Close() error
+}
+
+// checkRow verifies that a row matches the schema of a table.
+func (t *Table) checkRow(row []interface{}) error {
+	if len(row) != len(t.Columns) {
+		return fmt.Errorf("table %s: row has %d values, want %d", t.Name, len(row), len(t.Columns))
+	}
+	for i, column := range t.Columns {
+		var ok bool
+		switch column.Type {
+		case Int64:
+			switch v := row[i].(type) {
+			case int64, int:
+				ok = true
+			case uint64:
+				ok = v <= 1<<63-1
+			}
+		case String:
+			_, ok = row[i].(string)
+		}
+		if !ok {
+			return fmt.Errorf("table %s: invalid value %v (%T) for %s column %s", t.Name, row[i], row[i], column.Type, column.Name)
+		}
+	}
+	return nil
+}
+
+// int64Value converts a value already checked by checkRow into an int64.
+func int64Value(v interface{}) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	panic(fmt.Sprintf("invalid int64 value %T", v))
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Type identifiers of the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter is a minimal encoder of the Thrift compact protocol, sufficient
+// to produce the page headers and file metadata of Parquet files.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // Last field id written in each of the open structs
+}
+
+// newThriftWriter creates an encoder with the top level struct already open.
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+// field writes the header of a struct field.
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes a zigzag encoded variable length integer.
+func (t *thriftWriter) varint(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	t.buf.Write(buf[:binary.PutVarint(buf[:], v)])
+}
+
+// uvarint writes an unsigned variable length integer.
+func (t *thriftWriter) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	t.buf.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+// i32 writes a 32 bit integer field.
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+// i64 writes a 64 bit integer field.
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+// string writes a string field.
+func (t *thriftWriter) string(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.uvarint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+// list writes the header of a list field. The elements must be written right
+// after, using the element methods or beginElem/end for structs.
+func (t *thriftWriter) list(id int16, typ byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | typ)
+	} else {
+		t.buf.WriteByte(0xf0 | typ)
+		t.uvarint(uint64(size))
+	}
+}
+
+// i32Elem writes a 32 bit integer list element.
+func (t *thriftWriter) i32Elem(v int32) {
+	t.varint(int64(v))
+}
+
+// stringElem writes a string list element.
+func (t *thriftWriter) stringElem(v string) {
+	t.uvarint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+// begin opens a struct field.
+func (t *thriftWriter) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, 0)
+}
+
+// beginElem opens a struct list element.
+func (t *thriftWriter) beginElem() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost open struct, or the top level one.
+func (t *thriftWriter) end() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+// bytes closes the top level struct and returns the encoded data.
+func (t *thriftWriter) bytes() []byte {
+	t.end()
+	return t.buf.Bytes()
+}
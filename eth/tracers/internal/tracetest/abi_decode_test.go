@@ -0,0 +1,218 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+const (
+	testTokenABI = `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]}
+	]`
+	testErrorABI = `[{"type":"error","name":"Insufficient","inputs":[{"name":"available","type":"uint256"}]}]`
+)
+
+// abiDecodeTrace executes a token transfer which emits an event and calls into
+// a contract reverting with a custom error, tracing it with the given tracer.
+func abiDecodeTrace(t *testing.T, tracerName string, config string) json.RawMessage {
+	var (
+		to       = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		callee   = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+		receiver = common.HexToAddress("0x000000000000000000000000000000000000beef")
+	)
+	token, _ := abi.JSON(strings.NewReader(testTokenABI))
+	failure, _ := abi.JSON(strings.NewReader(testErrorABI))
+	selector := failure.Errors["Insufficient"].ID
+
+	input, err := token.Pack("transfer", receiver, big.NewInt(42))
+	if err != nil {
+		t.Fatalf("failed to pack input: %v", err)
+	}
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.LatestSigner(params.AllEthashProtocolChanges)
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+		Data:     input,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+
+	// Emit Transfer(origin, receiver, 42), call the failing contract, return true
+	code := []byte{byte(vm.PUSH1), 42, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH20)}
+	code = append(code, receiver.Bytes()...)
+	code = append(code, byte(vm.PUSH20))
+	code = append(code, origin.Bytes()...)
+	code = append(code, byte(vm.PUSH32))
+	code = append(code, token.Events["Transfer"].ID.Bytes()...)
+	code = append(code, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3))
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH20))
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	code = append(code, byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN))
+
+	// Emit an anonymous log, then revert with Insufficient(7)
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.PUSH32)}
+	revert = append(revert, common.RightPadBytes(selector[:4], 32)...)
+	revert = append(revert, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 7, byte(vm.PUSH1), 4, byte(vm.MSTORE))
+	revert = append(revert, byte(vm.PUSH1), 36, byte(vm.PUSH1), 0, byte(vm.REVERT))
+
+	var alloc = core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		callee: core.GenesisAccount{Nonce: 1, Code: revert},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	cfg := strings.NewReplacer("$TOKEN", to.Hex(), "$ERROR", hexutil.Encode(selector[:4])).Replace(config)
+	tracer, err := tracers.New(tracerName, nil, json.RawMessage(cfg))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: new(big.Int).SetUint64(1),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		BaseFee:     big.NewInt(0),
+	}
+	evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(0)}, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, context.BaseFee)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+const testABIConfig = `{"withLog": true, "abis": {"$TOKEN": ` + testTokenABI + `, "$ERROR": ` + testErrorABI + `}}`
+
+// Tests that the call tracer decodes calls, return values, custom errors and
+// logs with the supplied ABIs.
+func TestCallTracerABIDecoding(t *testing.T) {
+	res := abiDecodeTrace(t, "callTracer", testABIConfig)
+
+	var have struct {
+		Decoded json.RawMessage `json:"decoded"`
+		Logs    []struct {
+			Decoded json.RawMessage `json:"decoded"`
+		} `json:"logs"`
+		Calls []struct {
+			Decoded json.RawMessage `json:"decoded"`
+		} `json:"calls"`
+	}
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	want := `{"signature":"transfer(address,uint256)","inputs":[{"name":"to","type":"address","value":"0x000000000000000000000000000000000000beef"},{"name":"amount","type":"uint256","value":"42"}],"outputs":[{"name":"arg0","type":"bool","value":true}]}`
+	if string(have.Decoded) != want {
+		t.Errorf("decoded call mismatch:\nhave %s\nwant %s", have.Decoded, want)
+	}
+	if len(have.Logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(have.Logs))
+	}
+	want = `{"signature":"Transfer(address,address,uint256)","args":[{"name":"from","type":"address","value":"0x682a80a6f560eec50d54e63cbeda1c324c5f8d1b","indexed":true},{"name":"to","type":"address","value":"0x000000000000000000000000000000000000beef","indexed":true},{"name":"amount","type":"uint256","value":"42"}]}`
+	if string(have.Logs[0].Decoded) != want {
+		t.Errorf("decoded log mismatch:\nhave %s\nwant %s", have.Logs[0].Decoded, want)
+	}
+	if len(have.Calls) != 1 {
+		t.Fatalf("call count mismatch: have %d, want 1", len(have.Calls))
+	}
+	want = `{"error":{"signature":"Insufficient(uint256)","args":[{"name":"available","type":"uint256","value":"7"}]}}`
+	if string(have.Calls[0].Decoded) != want {
+		t.Errorf("decoded revert mismatch:\nhave %s\nwant %s", have.Calls[0].Decoded, want)
+	}
+}
+
+// Tests that the log tracer collects the logs of successful calls in order and
+// decodes them with the supplied ABIs.
+func TestLogTracer(t *testing.T) {
+	res := abiDecodeTrace(t, "logTracer", testABIConfig)
+
+	var have []struct {
+		Address common.Address  `json:"address"`
+		Topics  []common.Hash   `json:"topics"`
+		Data    hexutil.Bytes   `json:"data"`
+		Decoded json.RawMessage `json:"decoded"`
+	}
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	// The log of the reverted call must be dropped
+	if len(have) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(have))
+	}
+	if have[0].Address != common.HexToAddress("0x00000000000000000000000000000000deadbeef") || len(have[0].Topics) != 3 || new(big.Int).SetBytes(have[0].Data).Uint64() != 42 {
+		t.Errorf("raw log mismatch: %+v", have[0])
+	}
+	if !strings.Contains(string(have[0].Decoded), `"signature":"Transfer(address,address,uint256)"`) {
+		t.Errorf("log not decoded: %s", have[0].Decoded)
+	}
+	// Without ABIs the logs are reported raw
+	res = abiDecodeTrace(t, "logTracer", `{}`)
+	if strings.Contains(string(res), "decoded") {
+		t.Errorf("log decoded without abi: %s", res)
+	}
+}
+
+// Tests that invalid ABI configurations are rejected.
+func TestABIDecodingInvalidConfig(t *testing.T) {
+	for _, cfg := range []string{
+		`{"abis": {"0x1234": []}}`,     // neither address, selector nor topic
+		`{"abis": {"0x12345678": []}}`, // selector without matching method
+		`{"abis": {"0x00000000000000000000000000000000deadbeef": "invalid"}}`,
+	} {
+		if _, err := tracers.New("callTracer", nil, json.RawMessage(cfg)); err == nil {
+			t.Errorf("config %s: invalid abi accepted", cfg)
+		}
+		if _, err := tracers.New("logTracer", nil, json.RawMessage(cfg)); err == nil {
+			t.Errorf("config %s: invalid abi accepted", cfg)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// flatCallTrace is the subset of a flatCallTracer entry checked by the tests.
+type flatCallTrace struct {
+	Action struct {
+		CallType      string          `json:"callType"`
+		From          *common.Address `json:"from"`
+		To            *common.Address `json:"to"`
+		Address       *common.Address `json:"address"`
+		RefundAddress *common.Address `json:"refundAddress"`
+	} `json:"action"`
+	Error        string `json:"error"`
+	Subtraces    int    `json:"subtraces"`
+	TraceAddress []int  `json:"traceAddress"`
+	Type         string `json:"type"`
+}
+
+// flattenCallTrace flattens a nested callTracer result the way Parity traces
+// are expected to, into one-line summaries comparable with the flat tracer.
+func flattenCallTrace(call *callTrace, address []int) []string {
+	var (
+		typ  = strings.ToLower(call.Type)
+		flat []string
+	)
+	switch typ {
+	case "create", "create2":
+		flat = append(flat, fmt.Sprintf("create %x %v %d %q", call.From, address, len(call.Calls), call.Error))
+	case "selfdestruct":
+		flat = append(flat, fmt.Sprintf("suicide %x->%x %v %d %q", call.From, call.To, address, len(call.Calls), call.Error))
+	default:
+		flat = append(flat, fmt.Sprintf("call/%s %x->%x %v %d %q", typ, call.From, call.To, address, len(call.Calls), call.Error))
+	}
+	for i := range call.Calls {
+		child := append(append([]int{}, address...), i)
+		flat = append(flat, flattenCallTrace(&call.Calls[i], child)...)
+	}
+	return flat
+}
+
+// summarizeFlatTrace converts a flat tracer entry into the same one-line summary
+// format as flattenCallTrace.
+func summarizeFlatTrace(trace *flatCallTrace) string {
+	switch trace.Type {
+	case "create":
+		return fmt.Sprintf("create %x %v %d %q", *trace.Action.From, trace.TraceAddress, trace.Subtraces, trace.Error)
+	case "suicide":
+		return fmt.Sprintf("suicide %x->%x %v %d %q", *trace.Action.Address, *trace.Action.RefundAddress, trace.TraceAddress, trace.Subtraces, trace.Error)
+	default:
+		return fmt.Sprintf("%s/%s %x->%x %v %d %q", trace.Type, trace.Action.CallType, *trace.Action.From, *trace.Action.To, trace.TraceAddress, trace.Subtraces, trace.Error)
+	}
+}
+
+// Tests that the flat call tracer reports the same call frames as the nested
+// call tracer, with correct trace addresses and subtrace counts.
+func TestFlatCallTracerNative(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				test = new(callTracerTest)
+				tx   = new(types.Transaction)
+			)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			if len(test.TracerConfig) > 0 {
+				t.Skip("call tracer specific configuration")
+			}
+			if err := tx.UnmarshalBinary(common.FromHex(test.Input)); err != nil {
+				t.Fatalf("failed to parse testcase input: %v", err)
+			}
+			var (
+				signer    = types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+				origin, _ = signer.Sender(tx)
+				txContext = vm.TxContext{
+					Origin:   origin,
+					GasPrice: tx.GasPrice(),
+				}
+				context = vm.BlockContext{
+					CanTransfer: core.CanTransfer,
+					Transfer:    core.Transfer,
+					Coinbase:    test.Context.Miner,
+					BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+					Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+					Difficulty:  (*big.Int)(test.Context.Difficulty),
+					GasLimit:    uint64(test.Context.GasLimit),
+					BaseFee:     test.Genesis.BaseFee,
+				}
+				_, statedb = tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+			)
+			txctx := &tracers.Context{BlockHash: common.Hash{0x01}, TxIndex: 3, TxHash: tx.Hash()}
+			tracer, err := tracers.New("flatCallTracer", txctx, json.RawMessage(`{"includePrecompiles": true}`))
+			if err != nil {
+				t.Fatalf("failed to create flat call tracer: %v", err)
+			}
+			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+			msg, err := tx.AsMessage(signer, nil)
+			if err != nil {
+				t.Fatalf("failed to prepare transaction for tracing: %v", err)
+			}
+			if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			var traces []flatCallTrace
+			if err := json.Unmarshal(res, &traces); err != nil {
+				t.Fatalf("failed to unmarshal flat traces: %v", err)
+			}
+			have := make([]string, len(traces))
+			for i := range traces {
+				have[i] = summarizeFlatTrace(&traces[i])
+			}
+			if want := flattenCallTrace(test.Result, []int{}); !reflect.DeepEqual(have, want) {
+				t.Fatalf("trace mismatch\n have: %v\n want: %v\n", have, want)
+			}
+			// Ensure the transaction context is reported on all entries
+			var contexts []struct {
+				BlockHash           common.Hash `json:"blockHash"`
+				BlockNumber         uint64      `json:"blockNumber"`
+				TransactionHash     common.Hash `json:"transactionHash"`
+				TransactionPosition uint64      `json:"transactionPosition"`
+			}
+			if err := json.Unmarshal(res, &contexts); err != nil {
+				t.Fatalf("failed to unmarshal flat trace context: %v", err)
+			}
+			for i, ctx := range contexts {
+				if ctx.BlockHash != txctx.BlockHash || ctx.BlockNumber != uint64(test.Context.Number) || ctx.TransactionHash != tx.Hash() || ctx.TransactionPosition != 3 {
+					t.Errorf("trace %d: context mismatch: %+v", i, ctx)
+				}
+			}
+		})
+	}
+}
+
+// Tests that the flat call tracer reports the block and uncle rewards of a block
+// in the Parity format.
+func TestFlatCallTracerRewards(t *testing.T) {
+	var (
+		header = &types.Header{Number: big.NewInt(10), Coinbase: common.Address{0x01}}
+		uncles = []*types.Header{{Number: big.NewInt(9), Coinbase: common.Address{0x02}}}
+	)
+	res, err := tracers.RewardTraces("flatCallTracer", header, uncles, big.NewInt(2000), []*big.Int{big.NewInt(1750)})
+	if err != nil {
+		t.Fatalf("failed to format rewards: %v", err)
+	}
+	var have []struct {
+		Action struct {
+			Author     common.Address `json:"author"`
+			RewardType string         `json:"rewardType"`
+			Value      string         `json:"value"`
+		} `json:"action"`
+		BlockHash    common.Hash `json:"blockHash"`
+		BlockNumber  uint64      `json:"blockNumber"`
+		TraceAddress []int       `json:"traceAddress"`
+		Type         string      `json:"type"`
+	}
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("failed to decode rewards: %v", err)
+	}
+	if len(have) != 2 {
+		t.Fatalf("reward count mismatch: have %d, want 2", len(have))
+	}
+	for i, want := range []struct {
+		author common.Address
+		kind   string
+		value  string
+	}{
+		{common.Address{0x01}, "block", "0x7d0"},
+		{common.Address{0x02}, "uncle", "0x6d6"},
+	} {
+		if have[i].Type != "reward" || have[i].Action.Author != want.author || have[i].Action.RewardType != want.kind || have[i].Action.Value != want.value {
+			t.Errorf("reward %d: mismatch: have %+v, want %+v", i, have[i], want)
+		}
+		if have[i].BlockHash != header.Hash() || have[i].BlockNumber != 10 || len(have[i].TraceAddress) != 0 {
+			t.Errorf("reward %d: position mismatch: have %+v", i, have[i])
+		}
+	}
+	// Tracers without rewards report none
+	if res, err := tracers.RewardTraces("callTracer", header, uncles, big.NewInt(2000), []*big.Int{big.NewInt(1750)}); res != nil || err != nil {
+		t.Errorf("unexpected call tracer rewards: %s, %v", res, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+type opProfile struct {
+	Count       uint64 `json:"count"`
+	Gas         uint64 `json:"gas"`
+	Nanoseconds uint64 `json:"nanoseconds"`
+	MemoryGas   uint64 `json:"memoryGas"`
+}
+
+// Tests that the opcode profiler aggregates the opcodes per contract, excluding
+// the gas used by called frames from the calling opcodes.
+func TestOpProfileTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		callee = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+	)
+	// The memory expansion cost is derived from the chain's gas schedule
+	for _, memoryGas := range []uint64{params.MemoryGas, 10} {
+		config := *params.AllEthashProtocolChanges
+		if memoryGas != params.MemoryGas {
+			config.GasSchedule = &params.GasSchedule{MemoryGas: &memoryGas}
+		}
+		privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+		signer := types.LatestSigner(&config)
+		tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+			GasPrice: big.NewInt(0),
+			Gas:      100000,
+			To:       &to,
+		})
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+		origin, _ := signer.Sender(tx)
+		txContext := vm.TxContext{
+			Origin:   origin,
+			GasPrice: big.NewInt(0),
+		}
+		context := vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    common.Address{},
+			BlockNumber: new(big.Int).SetUint64(1),
+			Time:        new(big.Int).SetUint64(5),
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+			BaseFee:     big.NewInt(0),
+		}
+		code := []byte{
+			byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.MSTORE), // expand memory by a word
+			byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, // call args
+			byte(vm.PUSH20),
+		}
+		code = append(code, callee.Bytes()...)
+		code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+		var alloc = core.GenesisAlloc{
+			to: core.GenesisAccount{
+				Nonce: 1,
+				Code:  code,
+			},
+			callee: core.GenesisAccount{
+				Nonce: 1,
+				Code:  []byte{byte(vm.PUSH1), 0x0, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP)},
+			},
+			origin: core.GenesisAccount{
+				Nonce:   0,
+				Balance: big.NewInt(500000000000000),
+			},
+		}
+		_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+		// Create the tracer, the EVM environment and run it
+		tracer, err := tracers.New("opProfileTracer", nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create opcode profiler: %v", err)
+		}
+		evm := vm.NewEVM(context, txContext, statedb, &config, vm.Config{Debug: true, Tracer: tracer})
+		msg, err := tx.AsMessage(signer, context.BaseFee)
+		if err != nil {
+			t.Fatalf("failed to prepare transaction for tracing: %v", err)
+		}
+		st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+		if _, err = st.TransitionDb(); err != nil {
+			t.Fatalf("failed to execute transaction: %v", err)
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve trace result: %v", err)
+		}
+		var have struct {
+			Opcodes   map[string]opProfile                    `json:"opcodes"`
+			Contracts map[common.Address]map[string]opProfile `json:"contracts"`
+		}
+		if err := json.Unmarshal(res, &have); err != nil {
+			t.Fatalf("failed to unmarshal trace result: %v", err)
+		}
+		check := func(name string, have opProfile, count, gas, memory uint64) {
+			t.Helper()
+			if have.Count != count || have.Gas != gas || have.MemoryGas != memory {
+				t.Errorf("%s: profile mismatch: have %+v, want count %d, gas %d, memory gas %d", name, have, count, gas, memory)
+			}
+		}
+		check("PUSH1", have.Opcodes["PUSH1"], 8, 8*3, 0)
+		check("MSTORE", have.Opcodes["MSTORE"], 1, 3+memoryGas, memoryGas)
+		check("CALL", have.Opcodes["CALL"], 1, params.ColdAccountAccessCostEIP2929, 0)
+		check("SLOAD", have.Opcodes["SLOAD"], 1, params.ColdSloadCostEIP2929, 0)
+		check("STOP", have.Opcodes["STOP"], 2, 0, 0)
+
+		check("caller PUSH1", have.Contracts[to]["PUSH1"], 7, 7*3, 0)
+		check("callee PUSH1", have.Contracts[callee]["PUSH1"], 1, 3, 0)
+		if _, ok := have.Contracts[callee]["CALL"]; ok {
+			t.Errorf("caller opcode attributed to callee")
+		}
+		var elapsed uint64
+		for _, profile := range have.Opcodes {
+			elapsed += profile.Nanoseconds
+		}
+		if elapsed == 0 {
+			t.Errorf("no execution time measured")
+		}
+		// Aggregating the transaction twice doubles the profiles
+		merged, err := tracers.Merge("opProfileTracer", []json.RawMessage{res, res})
+		if err != nil {
+			t.Fatalf("failed to merge trace results: %v", err)
+		}
+		have.Opcodes, have.Contracts = nil, nil
+		if err := json.Unmarshal(merged, &have); err != nil {
+			t.Fatalf("failed to unmarshal merged result: %v", err)
+		}
+		check("merged PUSH1", have.Opcodes["PUSH1"], 16, 16*3, 0)
+		check("merged MSTORE", have.Opcodes["MSTORE"], 2, 2*(3+memoryGas), 2*memoryGas)
+		check("merged callee PUSH1", have.Contracts[callee]["PUSH1"], 2, 6, 0)
+	}
+}
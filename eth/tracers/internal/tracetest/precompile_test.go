@@ -0,0 +1,261 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// Tests that the precompile tracer reports the invocations of precompiles, both
+// directly and via the muxTracer, decoding the modexp inputs.
+func TestPrecompileTracer(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+
+	// 3 ** 5 % 7, with base, exponent and modulus of a single byte each
+	input := append(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{1}, 32)...)
+	input = append(input, common.LeftPadBytes([]byte{1}, 32)...)
+	input = append(input, 3, 5, 7)
+
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.LatestSigner(params.AllEthashProtocolChanges)
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+		Data:     input,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: new(big.Int).SetUint64(1),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		BaseFee:     big.NewInt(0),
+	}
+	// Pass the calldata to modexp, then to sha256 with too little gas
+	code := []byte{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 32, byte(vm.PUSH2), 0x2, 0x0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 5, byte(vm.GAS), byte(vm.STATICCALL), byte(vm.POP),
+		byte(vm.PUSH1), 32, byte(vm.PUSH2), 0x2, 0x0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 2, byte(vm.PUSH1), 10, byte(vm.STATICCALL), byte(vm.POP),
+		byte(vm.STOP),
+	}
+	var alloc = core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	type precompileStats struct {
+		Address     common.Address `json:"address"`
+		Calls       uint64         `json:"calls"`
+		Failed      uint64         `json:"failed"`
+		Gas         uint64         `json:"gas"`
+		InputBytes  uint64         `json:"inputBytes"`
+		OutputBytes uint64         `json:"outputBytes"`
+	}
+	type result struct {
+		Precompiles map[string]precompileStats `json:"precompiles"`
+		Calls       []struct {
+			Name   string        `json:"name"`
+			Gas    uint64        `json:"gas"`
+			Output hexutil.Bytes `json:"output"`
+			Error  string        `json:"error"`
+			Modexp *struct {
+				BaseLen uint64        `json:"baseLen"`
+				ExpLen  uint64        `json:"expLen"`
+				ModLen  uint64        `json:"modLen"`
+				ExpHead hexutil.Bytes `json:"expHead"`
+			} `json:"modexp"`
+		} `json:"calls"`
+	}
+	for _, tracerName := range []string{"precompileTracer", "muxTracer"} {
+		config := json.RawMessage(`{"withInput": true}`)
+		if tracerName == "muxTracer" {
+			config = json.RawMessage(`{"precompileTracer": {"withInput": true}, "callTracer": {}}`)
+		}
+		tracer, err := tracers.New(tracerName, nil, config)
+		if err != nil {
+			t.Fatalf("failed to create tracer: %v", err)
+		}
+		_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+		evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(0)}, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+		msg, err := tx.AsMessage(signer, context.BaseFee)
+		if err != nil {
+			t.Fatalf("failed to prepare transaction for tracing: %v", err)
+		}
+		st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+		if _, err = st.TransitionDb(); err != nil {
+			t.Fatalf("failed to execute transaction: %v", err)
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve trace result: %v", err)
+		}
+		var have result
+		if tracerName == "muxTracer" {
+			var mux map[string]json.RawMessage
+			if err := json.Unmarshal(res, &mux); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			res = mux["precompileTracer"]
+		}
+		if err := json.Unmarshal(res, &have); err != nil {
+			t.Fatalf("failed to unmarshal trace result: %v", err)
+		}
+		if len(have.Calls) != 2 {
+			t.Fatalf("%s: call count mismatch: have %d, want 2", tracerName, len(have.Calls))
+		}
+		modexp := have.Calls[0]
+		if modexp.Name != "modexp" || modexp.Gas != 200 || modexp.Error != "" || len(modexp.Output) != 1 || modexp.Output[0] != 5 {
+			t.Errorf("%s: modexp call mismatch: %+v", tracerName, modexp)
+		}
+		if m := modexp.Modexp; m == nil || m.BaseLen != 1 || m.ExpLen != 1 || m.ModLen != 1 || len(m.ExpHead) != 1 || m.ExpHead[0] != 5 {
+			t.Errorf("%s: modexp input mismatch: %+v", tracerName, modexp.Modexp)
+		}
+		sha := have.Calls[1]
+		if sha.Name != "sha256" || sha.Gas != 60+12*4 || sha.Error != vm.ErrOutOfGas.Error() || sha.Modexp != nil {
+			t.Errorf("%s: sha256 call mismatch: %+v", tracerName, sha)
+		}
+		want := precompileStats{Address: common.BytesToAddress([]byte{2}), Calls: 1, Failed: 1, Gas: 108, InputBytes: uint64(len(input))}
+		if have.Precompiles["sha256"] != want {
+			t.Errorf("%s: sha256 stats mismatch: have %+v, want %+v", tracerName, have.Precompiles["sha256"], want)
+		}
+		if tracerName != "precompileTracer" {
+			continue
+		}
+		// Aggregating the transaction twice sums up the statistics of the precompiles
+		merged, err := tracers.Merge(tracerName, []json.RawMessage{res, res})
+		if err != nil {
+			t.Fatalf("failed to merge trace results: %v", err)
+		}
+		have = result{}
+		if err := json.Unmarshal(merged, &have); err != nil {
+			t.Fatalf("failed to unmarshal merged result: %v", err)
+		}
+		want.Calls, want.Failed, want.Gas, want.InputBytes = 2, 2, 2*108, 2*uint64(len(input))
+		if len(have.Calls) != 4 || have.Precompiles["sha256"] != want {
+			t.Errorf("merged result mismatch: have %d calls, sha256 stats %+v, want %+v", len(have.Calls), have.Precompiles["sha256"], want)
+		}
+	}
+}
+
+// Tests that the transfer tracer reports the internal value transfers of calls
+// and self-destructs, but not the value sent by the transaction itself.
+func TestTransferTracer(t *testing.T) {
+	var (
+		to          = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		callee      = common.HexToAddress("0xbb")
+		beneficiary = common.HexToAddress("0xcc")
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.LatestSigner(params.AllEthashProtocolChanges)
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+		Value:    big.NewInt(10),
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: new(big.Int).SetUint64(1),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		BaseFee:     big.NewInt(0),
+	}
+	// Send 1 wei to the callee, then self-destruct to the beneficiary
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 1, byte(vm.PUSH1), 0xbb, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 0xcc, byte(vm.SELFDESTRUCT),
+	}
+	var alloc = core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	tracer, err := tracers.New("transferTracer", &tracers.Context{TxHash: tx.Hash()}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+	evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(0)}, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, context.BaseFee)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	merged, err := tracers.Merge("transferTracer", []json.RawMessage{res, res})
+	if err != nil {
+		t.Fatalf("failed to merge trace results: %v", err)
+	}
+	var have []struct {
+		TxHash common.Hash    `json:"txHash"`
+		From   common.Address `json:"from"`
+		To     common.Address `json:"to"`
+		Value  *hexutil.Big   `json:"value"`
+		Depth  int            `json:"depth"`
+	}
+	if err := json.Unmarshal(merged, &have); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	want := []struct {
+		to    common.Address
+		value int64
+	}{
+		{callee, 1}, {beneficiary, 9}, {callee, 1}, {beneficiary, 9},
+	}
+	if len(have) != len(want) {
+		t.Fatalf("transfer count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i, transfer := range have {
+		if transfer.TxHash != tx.Hash() || transfer.From != to || transfer.To != want[i].to || transfer.Value.ToInt().Int64() != want[i].value || transfer.Depth != 1 {
+			t.Errorf("transfer %d mismatch: have %+v, want %+v", i, transfer, want[i])
+		}
+	}
+}
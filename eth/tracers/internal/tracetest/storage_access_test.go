@@ -0,0 +1,224 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// Tests that the storage access tracer reports the warm/cold status, gas, refunds
+// and values of storage accesses, aggregating them per contract.
+func TestStorageAccessTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		config = *params.AllEthashProtocolChanges
+	)
+	res := traceStorageAccesses(t, &config, to)
+	checkStorageAccesses(t, res, to, params.ColdSloadCostEIP2929, params.WarmStorageReadCostEIP2929)
+
+	// Aggregating the transaction twice sums up the statistics of the contract
+	merged, err := tracers.Merge("storageAccessTracer", []json.RawMessage{res, res})
+	if err != nil {
+		t.Fatalf("failed to merge trace results: %v", err)
+	}
+	var have struct {
+		Accesses  []json.RawMessage                   `json:"accesses"`
+		Contracts map[common.Address]map[string]int64 `json:"contracts"`
+	}
+	if err := json.Unmarshal(merged, &have); err != nil {
+		t.Fatalf("failed to unmarshal merged result: %v", err)
+	}
+	if len(have.Accesses) != 10 {
+		t.Errorf("merged access count mismatch: have %d, want %d", len(have.Accesses), 10)
+	}
+	wantContract := storageContractStats(params.ColdSloadCostEIP2929, params.WarmStorageReadCostEIP2929)
+	for field, value := range wantContract {
+		wantContract[field] = 2 * value
+	}
+	if !reflect.DeepEqual(have.Contracts[to], wantContract) {
+		t.Errorf("merged contract statistics mismatch: have %v, want %v", have.Contracts[to], wantContract)
+	}
+}
+
+// Tests that the storage access tracer derives the warm/cold status of accesses
+// from the access costs of the chain's gas schedule.
+func TestStorageAccessTracerGasSchedule(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		config = *params.AllEthashProtocolChanges
+		cold   = uint64(500)
+		warm   = uint64(50)
+	)
+	config.GasSchedule = &params.GasSchedule{ColdSloadCost: &cold, WarmStorageReadCost: &warm}
+
+	res := traceStorageAccesses(t, &config, to)
+	checkStorageAccesses(t, res, to, cold, warm)
+}
+
+// traceStorageAccesses executes a transaction calling a contract accessing its
+// storage, and returns the result of the storage access tracer.
+func traceStorageAccesses(t *testing.T, config *params.ChainConfig, to common.Address) json.RawMessage {
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.LatestSigner(config)
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(0),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(1),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		BaseFee:     big.NewInt(0),
+	}
+	var code = []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.SLOAD), byte(vm.POP), // cold load of slot 0
+		byte(vm.PUSH1), 0x0, byte(vm.SLOAD), byte(vm.POP), // warm load of slot 0
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, byte(vm.SSTORE), // cold set of slot 1
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x1, byte(vm.SSTORE), // warm reset of slot 1, refunded
+		byte(vm.PUSH1), 0x7, byte(vm.PUSH1), 0x2, byte(vm.SSTORE), // cold no-op write of slot 2
+		byte(vm.STOP),
+	}
+	var alloc = core.GenesisAlloc{
+		to: core.GenesisAccount{
+			Nonce: 1,
+			Code:  code,
+			Storage: map[common.Hash]common.Hash{
+				{}:                       common.HexToHash("0x05"),
+				common.HexToHash("0x02"): common.HexToHash("0x07"),
+			},
+		},
+		origin: core.GenesisAccount{
+			Nonce:   0,
+			Balance: big.NewInt(500000000000000),
+		},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+	// Create the tracer, the EVM environment and run it
+	tracer, err := tracers.New("storageAccessTracer", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create storage access tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, config, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, context.BaseFee)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+// checkStorageAccesses checks the result of the storage access tracer for the
+// transaction of traceStorageAccesses, given the EIP-2929 access costs.
+func checkStorageAccesses(t *testing.T, res json.RawMessage, to common.Address, cold, warm uint64) {
+	t.Helper()
+
+	var have struct {
+		Accesses []struct {
+			Op     string       `json:"op"`
+			Slot   common.Hash  `json:"slot"`
+			Cold   bool         `json:"cold"`
+			Gas    uint64       `json:"gas"`
+			Refund int64        `json:"refund"`
+			New    *common.Hash `json:"new"`
+		} `json:"accesses"`
+		Contracts map[common.Address]map[string]int64 `json:"contracts"`
+	}
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	wantAccesses := []struct {
+		op     string
+		slot   byte
+		cold   bool
+		gas    uint64
+		refund int64
+	}{
+		{"SLOAD", 0, true, cold, 0},
+		{"SLOAD", 0, false, warm, 0},
+		{"SSTORE", 1, true, params.SstoreSetGasEIP2200 + cold, 0},
+		{"SSTORE", 1, false, warm, int64(params.SstoreSetGasEIP2200 - warm)},
+		{"SSTORE", 2, true, warm + cold, 0},
+	}
+	if len(have.Accesses) != len(wantAccesses) {
+		t.Fatalf("access count mismatch: have %d, want %d", len(have.Accesses), len(wantAccesses))
+	}
+	for i, want := range wantAccesses {
+		access := have.Accesses[i]
+		if access.Op != want.op || access.Slot != common.BytesToHash([]byte{want.slot}) || access.Cold != want.cold || access.Gas != want.gas || access.Refund != want.refund {
+			t.Errorf("access %d mismatch: have %+v, want %+v", i, access, want)
+		}
+		if (access.New != nil) != (want.op == "SSTORE") {
+			t.Errorf("access %d: new value presence mismatch", i)
+		}
+	}
+	if want := storageContractStats(cold, warm); !reflect.DeepEqual(have.Contracts[to], want) {
+		t.Errorf("contract statistics mismatch: have %v, want %v", have.Contracts[to], want)
+	}
+}
+
+// storageContractStats returns the expected statistics of the contract called by
+// the transaction of traceStorageAccesses, given the EIP-2929 access costs.
+func storageContractStats(cold, warm uint64) map[string]int64 {
+	return map[string]int64{
+		"sloads":      2,
+		"sstores":     3,
+		"coldSloads":  1,
+		"coldSstores": 2,
+		"gas":         int64(3*cold + 3*warm + params.SstoreSetGasEIP2200),
+		"refund":      int64(params.SstoreSetGasEIP2200 - warm),
+		"slots":       3,
+		"reuses":      2,
+		"noopWrites":  1,
+		"resetWrites": 1,
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// livePendingLimit is the number of executed blocks whose traces are kept
+	// around while waiting for them to become canonical.
+	livePendingLimit = 128
+
+	// liveChainEventSize is the size of the channel listening to ChainEvent.
+	liveChainEventSize = 10
+)
+
+var (
+	// errLiveTracingDisabled is returned when subscribing to the live block traces
+	// on a node not running the live tracer.
+	errLiveTracingDisabled = errors.New("live block tracing not enabled (see --vmtrace)")
+
+	// errLiveSubscriberLagging is returned by the subscriptions dropped for not
+	// keeping up with the traces of the imported blocks.
+	errLiveSubscriberLagging = errors.New("live trace subscriber lagging behind")
+
+	// errLiveTracesUnavailable is reported for the canonical blocks whose traces
+	// were not collected, or evicted before the block became canonical.
+	errLiveTracesUnavailable = errors.New("block traces unavailable")
+)
+
+// liveChain is the subset of the blockchain methods needed by the live tracer.
+type liveChain interface {
+	GetHeaderByHash(hash common.Hash) *types.Header
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+}
+
+// liveBlock is the trace state of the block currently being executed.
+type liveBlock struct {
+	block   *types.Block
+	tracers []Tracer // Tracers of the transactions, nil if untraced
+}
+
+// liveSubscriber is a subscriber of the traces of the canonical blocks.
+type liveSubscriber struct {
+	ch      chan<- *blockTraceResult
+	dropped chan struct{} // Closed if the subscriber is dropped for lagging behind
+}
+
+// LiveTracer traces the blocks as they are imported into the chain, avoiding
+// a second execution pass to trace them. The traces of every block are held
+// back until the block becomes canonical, and are then streamed to the
+// subscribers. Subscribers not keeping up with the chain are dropped instead of
+// stalling the block import.
+//
+// LiveTracer implements core.BlockTracer, to be set on the chain, and
+// node.Lifecycle, to follow the chain head.
+type LiveTracer struct {
+	chain  liveChain
+	name   string          // Name of the tracer to run on every transaction
+	config json.RawMessage // Config of the tracer
+
+	current *liveBlock                                 // Block being executed, only accessed by the importer
+	pending *lru.Cache[common.Hash, *blockTraceResult] // Traces of the executed blocks, not yet canonical
+
+	subs     map[*liveSubscriber]struct{} // Subscribers of the canonical traces
+	subsLock sync.Mutex
+	scope    event.SubscriptionScope
+	sub      event.Subscription
+	wg       sync.WaitGroup
+}
+
+// NewLiveTracer creates a live tracer running the named tracer, configured by
+// the given config, on the blocks imported into the chain.
+func NewLiveTracer(chain liveChain, name string, config json.RawMessage) (*LiveTracer, error) {
+	// Make sure the tracer exists and accepts the config before attaching it
+	if _, err := New(name, new(Context), config); err != nil {
+		return nil, fmt.Errorf("invalid live tracer %q: %v", name, err)
+	}
+	return &LiveTracer{
+		chain:   chain,
+		name:    name,
+		config:  config,
+		pending: lru.NewCache[common.Hash, *blockTraceResult](livePendingLimit),
+		subs:    make(map[*liveSubscriber]struct{}),
+	}, nil
+}
+
+// BlockStart implements core.BlockTracer, starting the tracing of a block.
+func (t *LiveTracer) BlockStart(block *types.Block) {
+	t.current = &liveBlock{
+		block:   block,
+		tracers: make([]Tracer, len(block.Transactions())),
+	}
+}
+
+// TxTracer implements core.BlockTracer, creating the tracer of a transaction.
+func (t *LiveTracer) TxTracer(index int, tx *types.Transaction) vm.EVMLogger {
+	if t.current == nil {
+		return nil
+	}
+	tracer, err := New(t.name, &Context{
+		BlockHash: t.current.block.Hash(),
+		TxIndex:   index,
+		TxHash:    tx.Hash(),
+	}, t.config)
+	if err != nil {
+		log.Warn("Failed to create live transaction tracer", "tx", tx.Hash(), "err", err)
+		return nil
+	}
+	t.current.tracers[index] = tracer
+	return tracer
+}
+
+// BlockEnd implements core.BlockTracer, collecting the traces of the block to
+// be published once the block becomes canonical.
+func (t *LiveTracer) BlockEnd(err error) {
+	current := t.current
+	t.current = nil
+	if current == nil || err != nil {
+		return
+	}
+	result := &blockTraceResult{
+		Block:  hexutil.Uint64(current.block.NumberU64()),
+		Hash:   current.block.Hash(),
+		Traces: make([]*txTraceResult, len(current.tracers)),
+	}
+	for i, tracer := range current.tracers {
+		if tracer == nil {
+			result.Traces[i] = &txTraceResult{Error: "tracer unavailable"}
+			continue
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			result.Traces[i] = &txTraceResult{Error: err.Error()}
+			continue
+		}
+		result.Traces[i] = &txTraceResult{Result: res}
+	}
+	t.pending.Add(result.Hash, result)
+}
+
+// Start implements node.Lifecycle, starting to publish the traces of the
+// blocks becoming canonical.
+func (t *LiveTracer) Start() error {
+	events := make(chan core.ChainEvent, liveChainEventSize)
+	t.sub = t.chain.SubscribeChainEvent(events)
+
+	t.wg.Add(1)
+	go t.loop(events)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the publishing of the traces.
+func (t *LiveTracer) Stop() error {
+	if t.sub != nil {
+		t.sub.Unsubscribe()
+	}
+	t.wg.Wait()
+	t.scope.Close()
+	return nil
+}
+
+// loop publishes the traces of the blocks as they become canonical. Chain events
+// are only fired for the new head when several blocks become canonical at once,
+// e.g. on reorgs or when the beacon client sets the head, so the ancestors of the
+// new head not yet published are published first, in chain order.
+func (t *LiveTracer) loop(events chan core.ChainEvent) {
+	defer t.wg.Done()
+
+	published := lru.NewBasicLRU[common.Hash, struct{}](livePendingLimit)
+	for {
+		select {
+		case ev := <-events:
+			for _, header := range t.unpublished(ev.Block.Header(), &published) {
+				t.publish(header)
+				published.Add(header.Hash(), struct{}{})
+			}
+
+		case <-t.sub.Err():
+			return
+		}
+	}
+}
+
+// unpublished returns the given head and its ancestors back to the last published
+// block, in chain order. Before anything is published, only the head is returned,
+// as the tracer didn't see the earlier blocks. The walk is bounded by the number
+// of pending traces kept around.
+func (t *LiveTracer) unpublished(head *types.Header, published *lru.BasicLRU[common.Hash, struct{}]) []*types.Header {
+	if published.Contains(head.Hash()) {
+		return nil
+	}
+	headers := []*types.Header{head}
+	if published.Len() > 0 {
+		for len(headers) < livePendingLimit {
+			child := headers[len(headers)-1]
+			if child.Number.Sign() == 0 {
+				break
+			}
+			header := t.chain.GetHeaderByHash(child.ParentHash)
+			if header == nil || published.Contains(header.Hash()) {
+				break
+			}
+			headers = append(headers, header)
+		}
+	}
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return headers
+}
+
+// publish delivers the traces of a canonical block to the subscribers. If the
+// traces are unavailable, e.g. evicted before the block became canonical, the
+// block is still delivered, with an error instead of the traces.
+func (t *LiveTracer) publish(header *types.Header) {
+	hash := header.Hash()
+	result, ok := t.pending.Get(hash)
+	if ok {
+		t.pending.Remove(hash)
+	} else {
+		log.Warn("Live block traces unavailable", "number", header.Number, "hash", hash)
+		result = &blockTraceResult{
+			Block: hexutil.Uint64(header.Number.Uint64()),
+			Hash:  hash,
+			Error: errLiveTracesUnavailable.Error(),
+		}
+	}
+	t.send(result)
+}
+
+// send delivers the traces of a canonical block to the subscribers. The chain
+// event subscription must not stall, as that would block the block import, so
+// subscribers whose channel is full are dropped.
+func (t *LiveTracer) send(result *blockTraceResult) {
+	t.subsLock.Lock()
+	defer t.subsLock.Unlock()
+
+	for sub := range t.subs {
+		select {
+		case sub.ch <- result:
+		default:
+			log.Warn("Dropping lagging live trace subscriber", "number", result.Block, "hash", result.Hash)
+			delete(t.subs, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// subscribe registers a subscription for the traces of the canonical blocks. The
+// subscription fails with errLiveSubscriberLagging if the channel is full when
+// the traces of a block are delivered.
+func (t *LiveTracer) subscribe(ch chan<- *blockTraceResult) event.Subscription {
+	sub := &liveSubscriber{ch: ch, dropped: make(chan struct{})}
+
+	t.subsLock.Lock()
+	t.subs[sub] = struct{}{}
+	t.subsLock.Unlock()
+
+	return t.scope.Track(event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case <-quit:
+			t.subsLock.Lock()
+			delete(t.subs, sub)
+			t.subsLock.Unlock()
+			return nil
+		case <-sub.dropped:
+			return errLiveSubscriberLagging
+		}
+	}))
+}
+
+// Traces creates a subscription streaming the traces of the blocks as they are
+// imported into the canonical chain, produced by the live tracer configured on
+// the node via --vmtrace. Blocks whose traces are unavailable are streamed with
+// an error instead. Subscriptions not keeping up with the chain stop being
+// notified.
+//
+// As with all subscriptions of the RPC server, the method is invoked through the
+// subscribe method of its namespace as debug_subscribe("traces"), rather than as
+// a standalone debug_subscribeTraces method.
+func (api *API) Traces(ctx context.Context) (*rpc.Subscription, error) {
+	if api.live == nil {
+		return &rpc.Subscription{}, errLiveTracingDisabled
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		sub     = notifier.CreateSubscription()
+		results = make(chan *blockTraceResult, liveChainEventSize)
+		feed    = api.live.subscribe(results)
+	)
+	go func() {
+		defer feed.Unsubscribe()
+		for {
+			select {
+			case result := <-results:
+				notifier.Notify(sub.ID, result)
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case err := <-feed.Err():
+				if err != nil {
+					log.Debug("Live trace subscription dropped", "id", sub.ID, "err", err)
+				}
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
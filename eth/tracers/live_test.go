@@ -0,0 +1,234 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the live tracer traces the blocks during import, and streams the
+// traces of the canonical blocks to the subscribers.
+func TestLiveTracer(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		engine = ethash.NewFaker()
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 5, func(i int, b *core.BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+			nonce++
+		}
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	live, err := NewLiveTracer(chain, flatCallTracerName, nil)
+	if err != nil {
+		t.Fatalf("failed to create live tracer: %v", err)
+	}
+	chain.SetBlockTracer(live)
+	if err := live.Start(); err != nil {
+		t.Fatalf("failed to start live tracer: %v", err)
+	}
+	defer live.Stop()
+
+	results := make(chan *blockTraceResult, len(blocks))
+	sub := live.subscribe(results)
+	defer sub.Unsubscribe()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		var result *blockTraceResult
+		select {
+		case result = <-results:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: trace not delivered", block.NumberU64())
+		}
+		if result.Hash != block.Hash() || uint64(result.Block) != block.NumberU64() {
+			t.Fatalf("block #%d: trace mismatch: have #%d %x, want %x", block.NumberU64(), result.Block, result.Hash, block.Hash())
+		}
+		if len(result.Traces) != len(block.Transactions()) {
+			t.Fatalf("block #%d: trace count mismatch: have %d, want %d", block.NumberU64(), len(result.Traces), len(block.Transactions()))
+		}
+		for i, tx := range block.Transactions() {
+			if result.Traces[i].Error != "" {
+				t.Fatalf("block #%d tx %d: trace failed: %v", block.NumberU64(), i, result.Traces[i].Error)
+			}
+			var traces []testTrace
+			if err := json.Unmarshal(result.Traces[i].Result.(json.RawMessage), &traces); err != nil {
+				t.Fatalf("block #%d tx %d: failed to decode trace: %v", block.NumberU64(), i, err)
+			}
+			if len(traces) != 1 || traces[0].TransactionHash == nil || *traces[0].TransactionHash != tx.Hash() {
+				t.Fatalf("block #%d tx %d: trace mismatch: %v", block.NumberU64(), i, traces)
+			}
+			if traces[0].Action.From != accounts[0].addr || traces[0].Action.To != accounts[1].addr {
+				t.Fatalf("block #%d tx %d: call mismatch: have %x -> %x", block.NumberU64(), i, traces[0].Action.From, traces[0].Action.To)
+			}
+		}
+	}
+}
+
+// Tests that subscribers not keeping up with the chain are dropped instead of
+// blocking the delivery of the traces, and with it the block import.
+func TestLiveTracerLaggingSubscriber(t *testing.T) {
+	t.Parallel()
+
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 3*liveChainEventSize, nil)
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	live, err := NewLiveTracer(chain, flatCallTracerName, nil)
+	if err != nil {
+		t.Fatalf("failed to create live tracer: %v", err)
+	}
+	chain.SetBlockTracer(live)
+	if err := live.Start(); err != nil {
+		t.Fatalf("failed to start live tracer: %v", err)
+	}
+	defer live.Stop()
+
+	// Subscribe once without ever reading, and once with room for all traces
+	lagging := live.subscribe(make(chan *blockTraceResult))
+	defer lagging.Unsubscribe()
+
+	results := make(chan *blockTraceResult, len(blocks))
+	sub := live.subscribe(results)
+	defer sub.Unsubscribe()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		select {
+		case result := <-results:
+			if result.Hash != block.Hash() {
+				t.Fatalf("block #%d: trace mismatch: have %x, want %x", block.NumberU64(), result.Hash, block.Hash())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: trace not delivered", block.NumberU64())
+		}
+	}
+	select {
+	case err := <-lagging.Err():
+		if err != errLiveSubscriberLagging {
+			t.Fatalf("lagging subscription error mismatch: have %v, want %v", err, errLiveSubscriberLagging)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lagging subscription not dropped")
+	}
+}
+
+// Tests that the traces of all the blocks becoming canonical at once are streamed
+// in order, the missing ones being reported rather than skipped.
+func TestLiveTracerSetCanonical(t *testing.T) {
+	t.Parallel()
+
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 5, nil)
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	live, err := NewLiveTracer(chain, flatCallTracerName, nil)
+	if err != nil {
+		t.Fatalf("failed to create live tracer: %v", err)
+	}
+	chain.SetBlockTracer(live)
+	if err := live.Start(); err != nil {
+		t.Fatalf("failed to start live tracer: %v", err)
+	}
+	defer live.Stop()
+
+	results := make(chan *blockTraceResult, len(blocks))
+	sub := live.subscribe(results)
+	defer sub.Unsubscribe()
+
+	// Import the first block normally, and the rest without setting the head,
+	// dropping the traces of one of them
+	if n, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks[1:] {
+		if err := chain.InsertBlockWithoutSetHead(block); err != nil {
+			t.Fatalf("block #%d: failed to insert into chain: %v", block.NumberU64(), err)
+		}
+	}
+	live.pending.Remove(blocks[2].Hash())
+
+	if _, err := chain.SetCanonical(blocks[len(blocks)-1]); err != nil {
+		t.Fatalf("failed to set canonical head: %v", err)
+	}
+	for i, block := range blocks {
+		var result *blockTraceResult
+		select {
+		case result = <-results:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: trace not delivered", block.NumberU64())
+		}
+		if result.Hash != block.Hash() {
+			t.Fatalf("block #%d: trace mismatch: have #%d %x, want %x", block.NumberU64(), result.Block, result.Hash, block.Hash())
+		}
+		if want := i == 2; (result.Error != "") != want {
+			t.Fatalf("block #%d: error mismatch: have %q, want failure %v", block.NumberU64(), result.Error, want)
+		}
+	}
+}
+
+// Tests that the live tracer rejects unknown tracers upfront.
+func TestLiveTracerUnknown(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewLiveTracer(nil, "nonexistentTracer", nil); err == nil {
+		t.Fatal("expected error for unknown tracer")
+	}
+}
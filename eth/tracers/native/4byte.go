@@ -29,6 +29,7 @@ import (
 
 func init() {
 	register("4byteTracer", newFourByteTracer)
+	tracers.RegisterDeterministic("4byteTracer")
 }
 
 // fourByteTracer searches for 4byte-identifiers, and collects them for post-processing.
@@ -0,0 +1,306 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// abiArg is a decoded argument of a method call, return value, error or event.
+// Integers are reported as decimal strings and byte arrays as hex strings.
+type abiArg struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Value   interface{} `json:"value"`
+	Indexed bool        `json:"indexed,omitempty"`
+}
+
+// abiError is a decoded custom error of a reverted call.
+type abiError struct {
+	Signature string   `json:"signature"`
+	Args      []abiArg `json:"args"`
+}
+
+// abiCall is a decoded method call, along with its return values or custom
+// revert error.
+type abiCall struct {
+	Signature string    `json:"signature,omitempty"`
+	Inputs    []abiArg  `json:"inputs,omitempty"`
+	Outputs   []abiArg  `json:"outputs,omitempty"`
+	Error     *abiError `json:"error,omitempty"`
+}
+
+// abiEvent is a decoded event log.
+type abiEvent struct {
+	Signature string   `json:"signature"`
+	Args      []abiArg `json:"args"`
+}
+
+// abiDecoder decodes call data, return data, revert data and event logs using
+// a set of user supplied ABIs. ABIs keyed by contract address are used for that
+// contract only, whereas ABIs keyed by a 4-byte method or error selector, or by
+// a 32-byte event topic, are used for any contract lacking a matching ABI.
+type abiDecoder struct {
+	contracts map[common.Address]*abi.ABI
+	methods   map[[4]byte]*abi.Method
+	errors    map[[4]byte]*abi.Error
+	events    map[common.Hash]*abi.Event
+}
+
+// newABIDecoder creates an ABI decoder from the json ABIs supplied in a tracer
+// config, keyed by contract address, 4-byte selector or 32-byte event topic.
+func newABIDecoder(abis map[string]json.RawMessage) (*abiDecoder, error) {
+	d := &abiDecoder{
+		contracts: make(map[common.Address]*abi.ABI),
+		methods:   make(map[[4]byte]*abi.Method),
+		errors:    make(map[[4]byte]*abi.Error),
+		events:    make(map[common.Hash]*abi.Event),
+	}
+	for key, blob := range abis {
+		parsed := new(abi.ABI)
+		if err := json.Unmarshal(blob, parsed); err != nil {
+			return nil, fmt.Errorf("invalid abi for %s: %v", key, err)
+		}
+		id, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid abi key %s: %v", key, err)
+		}
+		switch len(id) {
+		case common.AddressLength:
+			d.contracts[common.BytesToAddress(id)] = parsed
+
+		case 4:
+			var selector [4]byte
+			copy(selector[:], id)
+			if method, _ := parsed.MethodById(id); method != nil {
+				d.methods[selector] = method
+			}
+			for name := range parsed.Errors {
+				if e := parsed.Errors[name]; bytes.Equal(e.ID[:4], id) {
+					d.errors[selector] = &e
+				}
+			}
+			if d.methods[selector] == nil && d.errors[selector] == nil {
+				return nil, fmt.Errorf("abi for %s has no matching method or error", key)
+			}
+		case common.HashLength:
+			event, err := parsed.EventByID(common.BytesToHash(id))
+			if err != nil {
+				return nil, fmt.Errorf("abi for %s has no matching event", key)
+			}
+			d.events[common.BytesToHash(id)] = event
+
+		default:
+			return nil, fmt.Errorf("invalid abi key %s: neither address, selector nor topic", key)
+		}
+	}
+	return d, nil
+}
+
+// method looks up the ABI of the method called on a contract.
+func (d *abiDecoder) method(addr common.Address, input []byte) *abi.Method {
+	if len(input) < 4 {
+		return nil
+	}
+	if contract := d.contracts[addr]; contract != nil {
+		if method, err := contract.MethodById(input[:4]); err == nil {
+			return method
+		}
+	}
+	var selector [4]byte
+	copy(selector[:], input)
+	return d.methods[selector]
+}
+
+// customError looks up the ABI of the custom error a contract reverted with.
+func (d *abiDecoder) customError(addr common.Address, output []byte) *abi.Error {
+	if len(output) < 4 {
+		return nil
+	}
+	if contract := d.contracts[addr]; contract != nil {
+		for name := range contract.Errors {
+			if e := contract.Errors[name]; bytes.Equal(e.ID[:4], output[:4]) {
+				return &e
+			}
+		}
+	}
+	var selector [4]byte
+	copy(selector[:], output)
+	return d.errors[selector]
+}
+
+// event looks up the ABI of the event a contract emitted.
+func (d *abiDecoder) event(addr common.Address, topics []common.Hash) *abi.Event {
+	if len(topics) == 0 {
+		return nil
+	}
+	if contract := d.contracts[addr]; contract != nil {
+		if event, err := contract.EventByID(topics[0]); err == nil {
+			return event
+		}
+	}
+	return d.events[topics[0]]
+}
+
+// decodeCall decodes the input and output or revert error of a call, returning
+// nil if no matching ABI is known.
+func (d *abiDecoder) decodeCall(typ vm.OpCode, to common.Address, input, output []byte, reverted bool) *abiCall {
+	call := new(abiCall)
+
+	// Creations have the constructor arguments mixed with the code, skip them
+	if typ != vm.CREATE && typ != vm.CREATE2 {
+		if method := d.method(to, input); method != nil {
+			if inputs, err := decodeABIArgs(method.Inputs, input[4:]); err == nil {
+				call.Signature, call.Inputs = method.Sig, inputs
+				if !reverted {
+					call.Outputs, _ = decodeABIArgs(method.Outputs, output)
+				}
+			}
+		}
+	}
+	if reverted {
+		if e := d.customError(to, output); e != nil {
+			if args, err := decodeABIArgs(e.Inputs, output[4:]); err == nil {
+				call.Error = &abiError{Signature: e.Sig, Args: args}
+			}
+		}
+	}
+	if call.Signature == "" && call.Error == nil {
+		return nil
+	}
+	return call
+}
+
+// decodeLog decodes an event log, returning nil if no matching ABI is known.
+func (d *abiDecoder) decodeLog(addr common.Address, topics []common.Hash, data []byte) *abiEvent {
+	event := d.event(addr, topics)
+	if event == nil {
+		return nil
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(topics)-1 != len(indexed) {
+		return nil
+	}
+	values := make(map[string]interface{})
+	if err := abi.ParseTopicsIntoMap(values, indexed, topics[1:]); err != nil {
+		return nil
+	}
+	nonIndexed, err := event.Inputs.NonIndexed().UnpackValues(data)
+	if err != nil {
+		return nil
+	}
+	args := make([]abiArg, 0, len(event.Inputs))
+	for i, arg := range event.Inputs {
+		name := abiArgName(arg, i)
+		if arg.Indexed {
+			args = append(args, abiArg{Name: name, Type: arg.Type.String(), Value: formatABIValue(reflect.ValueOf(values[arg.Name])), Indexed: true})
+		} else {
+			args = append(args, abiArg{Name: name, Type: arg.Type.String(), Value: formatABIValue(reflect.ValueOf(nonIndexed[0]))})
+			nonIndexed = nonIndexed[1:]
+		}
+	}
+	return &abiEvent{Signature: event.Sig, Args: args}
+}
+
+// decodeABIArgs unpacks ABI encoded arguments.
+func decodeABIArgs(arguments abi.Arguments, data []byte) ([]abiArg, error) {
+	values, err := arguments.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]abiArg, len(arguments))
+	for i, arg := range arguments {
+		args[i] = abiArg{Name: abiArgName(arg, i), Type: arg.Type.String(), Value: formatABIValue(reflect.ValueOf(values[i]))}
+	}
+	return args, nil
+}
+
+// abiArgName returns the name of an argument, or a positional one if unnamed.
+func abiArgName(arg abi.Argument, index int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	return arg.Name
+}
+
+// formatABIValue converts a value unpacked by the abi package into its json
+// representation, with integers as decimal strings and byte arrays as hex.
+func formatABIValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch val := v.Interface().(type) {
+	case *big.Int:
+		return val.String()
+	case common.Address:
+		return val
+	case common.Hash:
+		return val
+	case []byte:
+		return hexutil.Bytes(val)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return formatABIValue(v.Elem())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(v.Uint())
+
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			blob := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(blob), v)
+			return hexutil.Bytes(blob)
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = formatABIValue(v.Index(i))
+		}
+		return list
+
+	case reflect.Struct:
+		// Tuples are unpacked into structs with the field names as json tags
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = v.Type().Field(i).Name
+			}
+			fields[name] = formatABIValue(v.Field(i))
+		}
+		return fields
+	}
+	return v.Interface()
+}
@@ -34,12 +34,14 @@ import (
 
 func init() {
 	register("callTracer", newCallTracer)
+	tracers.RegisterDeterministic("callTracer")
 }
 
 type callLog struct {
 	Address common.Address `json:"address"`
 	Topics  []common.Hash  `json:"topics"`
 	Data    hexutil.Bytes  `json:"data"`
+	Decoded *abiEvent      `json:"decoded,omitempty"`
 }
 
 type callFrame struct {
@@ -54,6 +56,7 @@ type callFrame struct {
 	Revertal string         `json:"revertReason,omitempty"`
 	Calls    []callFrame    `json:"calls,omitempty" rlp:"optional"`
 	Logs     []callLog      `json:"logs,omitempty" rlp:"optional"`
+	Decoded  *abiCall       `json:"decoded,omitempty" rlp:"-"`
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
 	Value *big.Int `json:"value,omitempty" rlp:"optional"`
@@ -102,14 +105,16 @@ type callTracer struct {
 	noopTracer
 	callstack []callFrame
 	config    callTracerConfig
+	decoder   *abiDecoder // Decoder of the calls and logs, if any ABIs were supplied
 	gasLimit  uint64
 	interrupt uint32 // Atomic flag to signal execution interruption
 	reason    error  // Textual reason for the interruption
 }
 
 type callTracerConfig struct {
-	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
-	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+	OnlyTopCall bool                       `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
+	WithLog     bool                       `json:"withLog"`     // If true, call tracer will collect event logs
+	ABIs        map[string]json.RawMessage `json:"abis"`        // ABIs to decode calls and logs with, by address, selector or topic
 }
 
 // newCallTracer returns a native go tracer which tracks
@@ -123,7 +128,15 @@ func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, e
 	}
 	// First callframe contains tx context info
 	// and is populated on start and end.
-	return &callTracer{callstack: make([]callFrame, 1), config: config}, nil
+	tracer := &callTracer{callstack: make([]callFrame, 1), config: config}
+	if len(config.ABIs) > 0 {
+		decoder, err := newABIDecoder(config.ABIs)
+		if err != nil {
+			return nil, err
+		}
+		tracer.decoder = decoder
+	}
+	return tracer, nil
 }
 
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
@@ -241,6 +254,9 @@ func (t *callTracer) GetResult() (json.RawMessage, error) {
 	if len(t.callstack) != 1 {
 		return nil, errors.New("incorrect number of top-level calls")
 	}
+	if t.decoder != nil {
+		decodeCallFrame(t.decoder, &t.callstack[0])
+	}
 	res, err := json.Marshal(t.callstack[0])
 	if err != nil {
 		return nil, err
@@ -266,3 +282,15 @@ func clearFailedLogs(cf *callFrame, parentFailed bool) {
 		clearFailedLogs(&cf.Calls[i], failed)
 	}
 }
+
+// decodeCallFrame decodes the calls and logs of a callframe and all its children
+// using the supplied ABIs.
+func decodeCallFrame(decoder *abiDecoder, cf *callFrame) {
+	cf.Decoded = decoder.decodeCall(cf.Type, cf.To, cf.Input, cf.Output, cf.Error == vm.ErrExecutionReverted.Error())
+	for i, log := range cf.Logs {
+		cf.Logs[i].Decoded = decoder.decodeLog(log.Address, log.Topics, log.Data)
+	}
+	for i := range cf.Calls {
+		decodeCallFrame(decoder, &cf.Calls[i])
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("flatCallTracer", newFlatCallTracer)
+	tracers.RegisterDeterministic("flatCallTracer")
+	tracers.RegisterRewards("flatCallTracer", flatRewardTraces)
+}
+
+// parityErrorMapping maps the EVM errors to the error strings of Parity traces.
+var parityErrorMapping = map[string]string{
+	"contract creation code storage out of gas": "Out of gas",
+	"out of gas":                      "Out of gas",
+	"gas uint64 overflow":             "Out of gas",
+	"max code size exceeded":          "Out of gas",
+	"invalid jump destination":        "Bad jump destination",
+	"execution reverted":              "Reverted",
+	"return data out of bounds":       "Out of bounds",
+	"stack limit reached 1024 (1023)": "Out of stack",
+	"precompiled failed":              "Built-in failed",
+	"invalid input length":            "Built-in failed",
+}
+
+// parityErrorMappingStartingWith maps the EVM error prefixes to the error strings
+// of Parity traces.
+var parityErrorMappingStartingWith = map[string]string{
+	"invalid opcode:": "Bad instruction",
+	"stack underflow": "Stack underflow",
+}
+
+// flatCallFrame is a single entry of the Parity style flat trace format.
+type flatCallFrame struct {
+	Action              flatCallAction  `json:"action"`
+	BlockHash           *common.Hash    `json:"blockHash"`
+	BlockNumber         uint64          `json:"blockNumber"`
+	Error               string          `json:"error,omitempty"`
+	Result              *flatCallResult `json:"result,omitempty"`
+	Subtraces           int             `json:"subtraces"`
+	TraceAddress        []int           `json:"traceAddress"`
+	TransactionHash     *common.Hash    `json:"transactionHash"`
+	TransactionPosition *uint64         `json:"transactionPosition"`
+	Type                string          `json:"type"`
+}
+
+// flatCallAction is the action of a flat trace entry, the fields of which are
+// set depending on the entry type (call, create, suicide or reward).
+type flatCallAction struct {
+	Author         *common.Address `json:"author,omitempty"`
+	RewardType     string          `json:"rewardType,omitempty"`
+	SelfDestructed *common.Address `json:"address,omitempty"`
+	Balance        *hexutil.Big    `json:"balance,omitempty"`
+	CallType       string          `json:"callType,omitempty"`
+	From           *common.Address `json:"from,omitempty"`
+	Gas            *hexutil.Uint64 `json:"gas,omitempty"`
+	Init           *hexutil.Bytes  `json:"init,omitempty"`
+	Input          *hexutil.Bytes  `json:"input,omitempty"`
+	RefundAddress  *common.Address `json:"refundAddress,omitempty"`
+	To             *common.Address `json:"to,omitempty"`
+	Value          *hexutil.Big    `json:"value,omitempty"`
+}
+
+// flatCallResult is the result of a successful call or create trace entry.
+type flatCallResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+}
+
+// flatCallTracer reports the call frames of a transaction in the flat format of
+// the Parity/OpenEthereum trace_* APIs. It wraps the call tracer, flattening its
+// nested frames once the transaction is done. The mining rewards of a block are
+// reported by the trace_* APIs, after the frames of all its transactions.
+type flatCallTracer struct {
+	tracer      *callTracer
+	config      flatCallTracerConfig
+	ctx         *tracers.Context
+	blockNumber uint64
+	gasUsed     uint64 // Execution gas of the top call, excluding the intrinsic gas
+	precompiles []common.Address
+}
+
+type flatCallTracerConfig struct {
+	ConvertParityErrors bool `json:"convertParityErrors"` // If true, EVM errors are converted to the Parity format
+	IncludePrecompiles  bool `json:"includePrecompiles"`  // If true, calls to precompiles are reported too
+}
+
+// newFlatCallTracer returns a new flat call tracer.
+func newFlatCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config flatCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	tracer, err := newCallTracer(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &flatCallTracer{tracer: tracer.(*callTracer), config: config, ctx: ctx}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *flatCallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureStart(env, from, to, create, input, gas, value)
+
+	t.blockNumber = env.Context.BlockNumber.Uint64()
+	t.precompiles = vm.ActivePrecompiles(env.ChainConfig().Rules(env.Context.BlockNumber, env.Context.Random != nil))
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *flatCallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	t.tracer.CaptureEnd(output, gasUsed, d, err)
+	t.gasUsed = gasUsed
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *flatCallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	t.tracer.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *flatCallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	t.tracer.CaptureFault(pc, op, gas, cost, scope, depth, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *flatCallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureEnter(typ, from, to, input, gas, value)
+
+	// Child calls must have a value, even if it's zero. Practically speaking,
+	// only STATICCALL has nil value, set it to zero.
+	if call := &t.tracer.callstack[len(t.tracer.callstack)-1]; call.Value == nil {
+		call.Value = new(big.Int)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *flatCallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureExit(output, gasUsed, err)
+
+	// Parity traces don't include plain calls to precompiles, drop them unless
+	// explicitly requested
+	if t.config.IncludePrecompiles {
+		return
+	}
+	parent := &t.tracer.callstack[len(t.tracer.callstack)-1]
+	if len(parent.Calls) == 0 {
+		return
+	}
+	call := parent.Calls[len(parent.Calls)-1]
+	if (call.Type == vm.CALL || call.Type == vm.STATICCALL) && t.isPrecompiled(call.To) {
+		parent.Calls = parent.Calls[:len(parent.Calls)-1]
+	}
+}
+
+// CaptureTxStart implements the EVMLogger interface to initialize the tracing
+// of a transaction.
+func (t *flatCallTracer) CaptureTxStart(gasLimit uint64) {
+	t.tracer.CaptureTxStart(gasLimit)
+}
+
+// CaptureTxEnd implements the EVMLogger interface to finalize the tracing of a
+// transaction.
+func (t *flatCallTracer) CaptureTxEnd(restGas uint64) {
+	t.tracer.CaptureTxEnd(restGas)
+
+	// Parity reports the gas used by the execution only, not by the transaction
+	t.tracer.callstack[0].GasUsed = t.gasUsed
+}
+
+// GetResult returns the json-encoded flat list of call traces, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	if len(t.tracer.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	frames, err := flatFromNested(&t.tracer.callstack[0], []int{}, t.config.ConvertParityErrors)
+	if err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		t.fillContext(&frames[i])
+	}
+	res, err := json.Marshal(frames)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.tracer.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *flatCallTracer) Stop(err error) {
+	t.tracer.Stop(err)
+}
+
+// isPrecompiled returns whether the address is a precompile active at the block
+// being traced.
+func (t *flatCallTracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.precompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// fillContext sets the block and transaction details of a flat trace entry.
+func (t *flatCallTracer) fillContext(frame *flatCallFrame) {
+	frame.BlockNumber = t.blockNumber
+	if t.ctx == nil {
+		return
+	}
+	if t.ctx.BlockHash != (common.Hash{}) {
+		hash := t.ctx.BlockHash
+		frame.BlockHash = &hash
+	}
+	if t.ctx.TxHash != (common.Hash{}) {
+		hash, index := t.ctx.TxHash, uint64(t.ctx.TxIndex)
+		frame.TransactionHash = &hash
+		frame.TransactionPosition = &index
+	}
+}
+
+// flatFromNested converts a nested call frame and all its children into a list
+// of flat trace entries, in depth first order.
+func flatFromNested(input *callFrame, traceAddress []int, convertErrs bool) ([]flatCallFrame, error) {
+	var frame *flatCallFrame
+	switch input.Type {
+	case vm.CREATE, vm.CREATE2:
+		frame = newFlatCreate(input)
+	case vm.SELFDESTRUCT:
+		frame = newFlatSuicide(input)
+	case vm.CALL, vm.STATICCALL, vm.CALLCODE, vm.DELEGATECALL:
+		frame = newFlatCall(input)
+	default:
+		return nil, fmt.Errorf("unrecognized call frame type: %s", input.Type)
+	}
+	frame.Subtraces = len(input.Calls)
+	frame.TraceAddress = traceAddress
+
+	if input.Error != "" {
+		frame.Error = input.Error
+		frame.Result = nil
+		if convertErrs {
+			frame.Error = convertErrorToParity(frame.Error)
+		}
+	}
+	output := []flatCallFrame{*frame}
+	for i := range input.Calls {
+		// Allocate a fresh slice for each child to avoid sharing the backing array
+		childAddress := make([]int, len(traceAddress)+1)
+		copy(childAddress, traceAddress)
+		childAddress[len(traceAddress)] = i
+
+		children, err := flatFromNested(&input.Calls[i], childAddress, convertErrs)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, children...)
+	}
+	return output, nil
+}
+
+// newFlatCreate converts a contract creation call frame into a flat trace entry.
+func newFlatCreate(input *callFrame) *flatCallFrame {
+	var (
+		from    = input.From
+		to      = input.To
+		gas     = hexutil.Uint64(input.Gas)
+		gasUsed = hexutil.Uint64(input.GasUsed)
+		init    = hexutil.Bytes(common.CopyBytes(input.Input))
+		code    = hexutil.Bytes(common.CopyBytes(input.Output))
+	)
+	return &flatCallFrame{
+		Type: "create",
+		Action: flatCallAction{
+			From:  &from,
+			Gas:   &gas,
+			Init:  &init,
+			Value: (*hexutil.Big)(valueOrZero(input.Value)),
+		},
+		Result: &flatCallResult{
+			Address: &to,
+			Code:    &code,
+			GasUsed: &gasUsed,
+		},
+	}
+}
+
+// newFlatCall converts a message call frame into a flat trace entry.
+func newFlatCall(input *callFrame) *flatCallFrame {
+	var (
+		from    = input.From
+		to      = input.To
+		gas     = hexutil.Uint64(input.Gas)
+		gasUsed = hexutil.Uint64(input.GasUsed)
+		data    = hexutil.Bytes(common.CopyBytes(input.Input))
+		output  = hexutil.Bytes(common.CopyBytes(input.Output))
+	)
+	return &flatCallFrame{
+		Type: "call",
+		Action: flatCallAction{
+			CallType: strings.ToLower(input.Type.String()),
+			From:     &from,
+			Gas:      &gas,
+			Input:    &data,
+			To:       &to,
+			Value:    (*hexutil.Big)(valueOrZero(input.Value)),
+		},
+		Result: &flatCallResult{
+			GasUsed: &gasUsed,
+			Output:  &output,
+		},
+	}
+}
+
+// newFlatSuicide converts a self-destruct call frame into a flat trace entry.
+func newFlatSuicide(input *callFrame) *flatCallFrame {
+	var (
+		address = input.From
+		refund  = input.To
+	)
+	return &flatCallFrame{
+		Type: "suicide",
+		Action: flatCallAction{
+			SelfDestructed: &address,
+			Balance:        (*hexutil.Big)(valueOrZero(input.Value)),
+			RefundAddress:  &refund,
+		},
+	}
+}
+
+// flatRewardTraces returns the json-encoded Parity style reward entries of a
+// block, crediting its coinbase with the given reward and the coinbases of its
+// uncles with the given uncle rewards.
+func flatRewardTraces(header *types.Header, uncles []*types.Header, reward *big.Int, uncleRewards []*big.Int) (json.RawMessage, error) {
+	var (
+		hash   = header.Hash()
+		frames = make([]flatCallFrame, 0, len(uncles)+1)
+	)
+	newReward := func(author common.Address, kind string, value *big.Int) flatCallFrame {
+		return flatCallFrame{
+			Type:         "reward",
+			BlockHash:    &hash,
+			BlockNumber:  header.Number.Uint64(),
+			TraceAddress: []int{},
+			Action: flatCallAction{
+				Author:     &author,
+				RewardType: kind,
+				Value:      (*hexutil.Big)(value),
+			},
+		}
+	}
+	frames = append(frames, newReward(header.Coinbase, "block", reward))
+	for i, uncle := range uncles {
+		frames = append(frames, newReward(uncle.Coinbase, "uncle", uncleRewards[i]))
+	}
+	return json.Marshal(frames)
+}
+
+// convertErrorToParity converts an EVM error message to the Parity format.
+func convertErrorToParity(err string) string {
+	if mapped, ok := parityErrorMapping[err]; ok {
+		return mapped
+	}
+	for prefix, mapped := range parityErrorMappingStartingWith {
+		if strings.HasPrefix(err, prefix) {
+			return mapped
+		}
+	}
+	return err
+}
+
+// valueOrZero returns the given value, or zero if it's nil.
+func valueOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
@@ -27,6 +27,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 		Revertal   string         `json:"revertReason,omitempty"`
 		Calls      []callFrame    `json:"calls,omitempty" rlp:"optional"`
 		Logs       []callLog      `json:"logs,omitempty" rlp:"optional"`
+		Decoded    *abiCall       `json:"decoded,omitempty" rlp:"-"`
 		Value      *hexutil.Big   `json:"value,omitempty" rlp:"optional"`
 		TypeString string         `json:"type"`
 	}
@@ -42,6 +43,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.Revertal = c.Revertal
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
+	enc.Decoded = c.Decoded
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
 	return json.Marshal(&enc)
@@ -61,6 +63,7 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 		Revertal *string         `json:"revertReason,omitempty"`
 		Calls    []callFrame     `json:"calls,omitempty" rlp:"optional"`
 		Logs     []callLog       `json:"logs,omitempty" rlp:"optional"`
+		Decoded  *abiCall        `json:"decoded,omitempty" rlp:"-"`
 		Value    *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
@@ -100,6 +103,9 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
+	if dec.Decoded != nil {
+		c.Decoded = dec.Decoded
+	}
 	if dec.Value != nil {
 		c.Value = (*big.Int)(dec.Value)
 	}
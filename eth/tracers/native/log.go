@@ -0,0 +1,144 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("logTracer", newLogTracer)
+	tracers.RegisterDeterministic("logTracer")
+}
+
+// logTracer collects the event logs emitted by a transaction in order, dropping
+// the ones emitted by reverted calls, and decodes them with the supplied ABIs.
+type logTracer struct {
+	noopTracer
+	logs      [][]callLog // Logs emitted by each call frame in progress
+	decoder   *abiDecoder // Decoder of the logs, if any ABIs were supplied
+	interrupt uint32      // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+type logTracerConfig struct {
+	ABIs map[string]json.RawMessage `json:"abis"` // ABIs to decode logs with, by address or topic
+}
+
+// newLogTracer returns a new log collecting tracer.
+func newLogTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config logTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	tracer := &logTracer{logs: make([][]callLog, 1)}
+	if len(config.ABIs) > 0 {
+		decoder, err := newABIDecoder(config.ABIs)
+		if err != nil {
+			return nil, err
+		}
+		tracer.decoder = decoder
+	}
+	return tracer, nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *logTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if err != nil {
+		t.logs[0] = nil
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *logTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	if op < vm.LOG0 || op > vm.LOG4 {
+		return
+	}
+	var (
+		size      = int(op - vm.LOG0)
+		stackData = scope.Stack.Data()
+		mStart    = stackData[len(stackData)-1]
+		mSize     = stackData[len(stackData)-2]
+		topics    = make([]common.Hash, size)
+	)
+	for i := 0; i < size; i++ {
+		topics[i] = common.Hash(stackData[len(stackData)-2-(i+1)].Bytes32())
+	}
+	log := callLog{
+		Address: scope.Contract.Address(),
+		Topics:  topics,
+		Data:    hexutil.Bytes(scope.Memory.GetCopy(int64(mStart.Uint64()), int64(mSize.Uint64()))),
+	}
+	t.logs[len(t.logs)-1] = append(t.logs[len(t.logs)-1], log)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *logTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.logs = append(t.logs, nil)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *logTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(t.logs) <= 1 {
+		return
+	}
+	// Logs of failed calls are discarded, the others are merged into the caller
+	logs := t.logs[len(t.logs)-1]
+	t.logs = t.logs[:len(t.logs)-1]
+	if err == nil {
+		t.logs[len(t.logs)-1] = append(t.logs[len(t.logs)-1], logs...)
+	}
+}
+
+// GetResult returns the json-encoded list of logs, and any error arising from
+// the encoding or forceful termination (via `Stop`).
+func (t *logTracer) GetResult() (json.RawMessage, error) {
+	logs := t.logs[0]
+	if logs == nil {
+		logs = []callLog{}
+	}
+	if t.decoder != nil {
+		for i, log := range logs {
+			logs[i].Decoded = t.decoder.decodeLog(log.Address, log.Topics, log.Data)
+		}
+	}
+	res, err := json.Marshal(logs)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *logTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -102,6 +102,26 @@ func (t *muxTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	}
 }
 
+// CapturePrecompile implements the vm.PrecompileLogger interface, forwarding the
+// invocation to the tracers interested in precompiles.
+func (t *muxTracer) CapturePrecompile(addr common.Address, name string, input []byte, output []byte, gas uint64, err error) {
+	for _, t := range t.tracers {
+		if t, ok := t.(vm.PrecompileLogger); ok {
+			t.CapturePrecompile(addr, name, input, output, gas, err)
+		}
+	}
+}
+
+// CaptureTransfer implements the vm.TransferLogger interface, forwarding the
+// transfer to the tracers interested in value transfers.
+func (t *muxTracer) CaptureTransfer(from common.Address, to common.Address, value *big.Int, depth int) {
+	for _, t := range t.tracers {
+		if t, ok := t.(vm.TransferLogger); ok {
+			t.CaptureTransfer(from, to, value, depth)
+		}
+	}
+}
+
 func (t *muxTracer) CaptureTxStart(gasLimit uint64) {
 	for _, t := range t.tracers {
 		t.CaptureTxStart(gasLimit)
@@ -28,6 +28,7 @@ import (
 
 func init() {
 	register("noopTracer", newNoopTracer)
+	tracers.RegisterDeterministic("noopTracer")
 }
 
 // noopTracer is a go implementation of the Tracer interface which
@@ -0,0 +1,286 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("opProfileTracer", newOpProfileTracer)
+	tracers.RegisterAggregator("opProfileTracer", newOpProfileAggregator)
+}
+
+// opProfile is the aggregated execution statistics of an opcode.
+type opProfile struct {
+	Count       uint64 `json:"count"`
+	Gas         uint64 `json:"gas"`         // Gas charged, excluding the gas used by any called frames
+	Nanoseconds uint64 `json:"nanoseconds"` // Wall-clock time spent, excluding any called frames
+	MemoryGas   uint64 `json:"memoryGas"`   // Gas charged for memory expansion
+}
+
+// add sums up the statistics of another profile into this one.
+func (p *opProfile) add(other *opProfile) {
+	p.Count += other.Count
+	p.Gas += other.Gas
+	p.Nanoseconds += other.Nanoseconds
+	p.MemoryGas += other.MemoryGas
+}
+
+// opProfileResult is the result of the opcode profiler tracer.
+type opProfileResult struct {
+	Opcodes   map[string]*opProfile                    `json:"opcodes"`
+	Contracts map[common.Address]map[string]*opProfile `json:"contracts"`
+}
+
+// opProfileStep is an executed opcode, the gas, time and memory expansion of
+// which are accounted once the next opcode of its frame executes.
+type opProfileStep struct {
+	profiles [2]*opProfile // Global and per contract profiles of the opcode
+	start    time.Time     // Time the execution of the opcode (re)started
+	gas      uint64        // Gas available before the opcode
+	cost     uint64        // Gas cost reported by the interpreter
+	memory   uint64        // Memory size before the opcode
+	children uint64        // Gas used by the frames called by the opcode
+}
+
+// opProfileFrame is a call frame being profiled.
+type opProfileFrame struct {
+	memory *vm.Memory
+	step   *opProfileStep // Last executed opcode of the frame, if any
+}
+
+// opProfileTracer measures the count, gas, wall-clock time and memory expansion
+// cost of every opcode executed by a transaction, aggregated per opcode and per
+// contract executing the code. The profiles of multiple transactions can be
+// merged to profile a block or a traceChain range (see debug_aggregateChain).
+type opProfileTracer struct {
+	noopTracer
+	costs     vm.GasCosts // Gas costs charged by the EVM, to derive the memory expansion cost
+	opcodes   map[string]*opProfile
+	contracts map[common.Address]map[string]*opProfile
+	frames    []*opProfileFrame
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newOpProfileTracer returns a new opcode profiler tracer.
+func newOpProfileTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &opProfileTracer{
+		opcodes:   make(map[string]*opProfile),
+		contracts: make(map[common.Address]map[string]*opProfile),
+	}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *opProfileTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.costs = env.GasCosts()
+	t.frames = append(t.frames, new(opProfileFrame))
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *opProfileTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	t.exit()
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *opProfileTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) == 0 {
+		return
+	}
+	now := time.Now()
+
+	// Account the previous opcode of the frame now that its execution is done
+	frame := t.frames[len(t.frames)-1]
+	frame.memory = scope.Memory
+	if frame.step != nil {
+		used := frame.step.gas - gas
+		if used < frame.step.children {
+			used = 0
+		} else {
+			used -= frame.step.children
+		}
+		t.account(frame, used, now)
+	}
+	// Start measuring the current opcode
+	addr := scope.Contract.Address()
+	if scope.Contract.CodeAddr != nil {
+		addr = *scope.Contract.CodeAddr
+	}
+	contract := t.contracts[addr]
+	if contract == nil {
+		contract = make(map[string]*opProfile)
+		t.contracts[addr] = contract
+	}
+	name := op.String()
+	if t.opcodes[name] == nil {
+		t.opcodes[name] = new(opProfile)
+	}
+	if contract[name] == nil {
+		contract[name] = new(opProfile)
+	}
+	frame.step = &opProfileStep{
+		profiles: [2]*opProfile{t.opcodes[name], contract[name]},
+		gas:      gas,
+		cost:     cost,
+		memory:   uint64(scope.Memory.Len()),
+	}
+	for _, profile := range frame.step.profiles {
+		profile.Count++
+	}
+	frame.step.start = time.Now()
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *opProfileTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Pause the timer of the calling opcode until the call returns
+	if len(t.frames) > 0 {
+		if step := t.frames[len(t.frames)-1].step; step != nil {
+			step.pause(time.Now())
+		}
+	}
+	t.frames = append(t.frames, new(opProfileFrame))
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *opProfileTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit()
+
+	// Resume the timer of the calling opcode, excluding the gas used by the call
+	if len(t.frames) > 0 {
+		if step := t.frames[len(t.frames)-1].step; step != nil {
+			step.children += gasUsed
+			step.start = time.Now()
+		}
+	}
+}
+
+// exit accounts the last opcode of the current frame and leaves it. Its gas cost
+// is taken as reported, as there is no subsequent opcode to measure against.
+func (t *opProfileTracer) exit() {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if frame.step != nil {
+		t.account(frame, frame.step.cost, time.Now())
+	}
+}
+
+// account adds the gas, time and memory expansion cost of the last opcode of a
+// frame to its profiles.
+func (t *opProfileTracer) account(frame *opProfileFrame, gas uint64, now time.Time) {
+	step := frame.step
+	step.pause(now)
+
+	var memory uint64
+	if size := uint64(frame.memory.Len()); size > step.memory {
+		memory = memoryFee(t.costs, size) - memoryFee(t.costs, step.memory)
+	}
+	for _, profile := range step.profiles {
+		profile.Gas += gas
+		profile.MemoryGas += memory
+	}
+	frame.step = nil
+}
+
+// pause adds the time elapsed since the opcode (re)started to its profiles.
+func (s *opProfileStep) pause(now time.Time) {
+	elapsed := uint64(now.Sub(s.start))
+	for _, profile := range s.profiles {
+		profile.Nanoseconds += elapsed
+	}
+	s.start = now
+}
+
+// GetResult returns the json-encoded opcode profiles, both in total and per
+// contract, and any error arising from the encoding or forceful termination
+// (via `Stop`).
+func (t *opProfileTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(&opProfileResult{t.opcodes, t.contracts})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *opProfileTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// opProfileAggregator aggregates opcode profiles over multiple transactions,
+// summing up the statistics of each opcode, both in total and per contract.
+type opProfileAggregator struct {
+	merged opProfileResult
+}
+
+func newOpProfileAggregator() tracers.Aggregator {
+	return &opProfileAggregator{
+		merged: opProfileResult{
+			Opcodes:   make(map[string]*opProfile),
+			Contracts: make(map[common.Address]map[string]*opProfile),
+		},
+	}
+}
+
+// Add implements tracers.Aggregator, merging the profile of a transaction.
+func (a *opProfileAggregator) Add(blob json.RawMessage) error {
+	var res opProfileResult
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return err
+	}
+	mergeOpcodes := func(dst, src map[string]*opProfile) {
+		for name, profile := range src {
+			if dst[name] == nil {
+				dst[name] = new(opProfile)
+			}
+			dst[name].add(profile)
+		}
+	}
+	mergeOpcodes(a.merged.Opcodes, res.Opcodes)
+	for addr, opcodes := range res.Contracts {
+		if a.merged.Contracts[addr] == nil {
+			a.merged.Contracts[addr] = make(map[string]*opProfile)
+		}
+		mergeOpcodes(a.merged.Contracts[addr], opcodes)
+	}
+	return nil
+}
+
+// Result implements tracers.Aggregator, encoding the merged profile.
+func (a *opProfileAggregator) Result() (json.RawMessage, error) {
+	return json.Marshal(&a.merged)
+}
+
+// memoryFee returns the total gas charged for expanding the memory to the given
+// size, as calculated by memoryGasCost in core/vm.
+func memoryFee(costs vm.GasCosts, size uint64) uint64 {
+	words := (size + 31) / 32
+	return words*costs.Memory + words*words/costs.QuadCoeffDiv
+}
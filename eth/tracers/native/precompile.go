@@ -0,0 +1,240 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package native
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("precompileTracer", newPrecompileTracer)
+	tracers.RegisterDeterministic("precompileTracer")
+	tracers.RegisterAggregator("precompileTracer", newPrecompileAggregator)
+}
+
+// precompileStats is the aggregated usage of a precompiled contract.
+type precompileStats struct {
+	Address     common.Address `json:"address"`
+	Calls       uint64         `json:"calls"`
+	Failed      uint64         `json:"failed"`      // Calls failing, e.g. due to invalid input or lack of gas
+	Gas         uint64         `json:"gas"`         // Total gas price of the calls
+	InputBytes  uint64         `json:"inputBytes"`  // Total size of the inputs
+	OutputBytes uint64         `json:"outputBytes"` // Total size of the outputs
+}
+
+// precompileCall is a single invocation of a precompiled contract. Calls from
+// frames reverted later on are included, as their gas was spent nonetheless.
+type precompileCall struct {
+	Name       string         `json:"name"`
+	Address    common.Address `json:"address"`
+	Gas        uint64         `json:"gas"` // Gas price of the call, as charged by the precompile
+	InputSize  int            `json:"inputSize"`
+	OutputSize int            `json:"outputSize"`
+	Input      hexutil.Bytes  `json:"input,omitempty"`
+	Output     hexutil.Bytes  `json:"output,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Modexp     *modexpInput   `json:"modexp,omitempty"` // Decoded input of modexp calls
+}
+
+// modexpInput is the decoded input of a modexp call, as far as it affects the gas
+// price. Lengths not fitting into 64 bits are capped at the maximum value.
+type modexpInput struct {
+	BaseLen uint64        `json:"baseLen"`
+	ExpLen  uint64        `json:"expLen"`
+	ModLen  uint64        `json:"modLen"`
+	ExpHead hexutil.Bytes `json:"expHead"` // Leading (up to) 32 bytes of the exponent
+}
+
+// precompileResult is the result of the precompile tracer.
+type precompileResult struct {
+	Precompiles map[string]*precompileStats `json:"precompiles"`
+	Calls       []*precompileCall           `json:"calls"`
+}
+
+// precompileTracer records the invocations of precompiled contracts, along with
+// their gas prices and input sizes, and aggregates them per precompile. Merging
+// the results of the transactions of a block (see debug_aggregateBlockByNumber)
+// yields the usage of the block.
+type precompileTracer struct {
+	noopTracer
+	config      precompileTracerConfig
+	precompiles map[string]*precompileStats
+	calls       []*precompileCall
+	interrupt   uint32 // Atomic flag to signal execution interruption
+	reason      error  // Textual reason for the interruption
+}
+
+type precompileTracerConfig struct {
+	WithInput bool `json:"withInput"` // If true, the raw inputs and outputs of the calls are reported
+}
+
+// newPrecompileTracer returns a new precompile tracer.
+func newPrecompileTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config precompileTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &precompileTracer{
+		config:      config,
+		precompiles: make(map[string]*precompileStats),
+		calls:       []*precompileCall{},
+	}, nil
+}
+
+// CapturePrecompile implements the vm.PrecompileLogger interface to trace the
+// invocation of a precompiled contract.
+func (t *precompileTracer) CapturePrecompile(addr common.Address, name string, input []byte, output []byte, gas uint64, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	call := &precompileCall{
+		Name:       name,
+		Address:    addr,
+		Gas:        gas,
+		InputSize:  len(input),
+		OutputSize: len(output),
+	}
+	if t.config.WithInput {
+		call.Input = common.CopyBytes(input)
+		call.Output = common.CopyBytes(output)
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	if name == "modexp" {
+		call.Modexp = decodeModexpInput(input)
+	}
+	t.calls = append(t.calls, call)
+
+	stats := t.precompiles[name]
+	if stats == nil {
+		stats = &precompileStats{Address: addr}
+		t.precompiles[name] = stats
+	}
+	stats.Calls++
+	if err != nil {
+		stats.Failed++
+	}
+	stats.Gas += gas
+	stats.InputBytes += uint64(len(input))
+	stats.OutputBytes += uint64(len(output))
+}
+
+// GetResult returns the json-encoded precompile calls and the per precompile
+// statistics, and any error arising from the encoding or forceful termination
+// (via `Stop`).
+func (t *precompileTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(&precompileResult{t.precompiles, t.calls})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *precompileTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// precompileAggregator aggregates precompile results over multiple transactions,
+// concatenating their calls and summing up the statistics of each precompile.
+type precompileAggregator struct {
+	merged precompileResult
+}
+
+func newPrecompileAggregator() tracers.Aggregator {
+	return &precompileAggregator{
+		merged: precompileResult{
+			Precompiles: make(map[string]*precompileStats),
+			Calls:       []*precompileCall{},
+		},
+	}
+}
+
+// Add implements tracers.Aggregator, merging the calls of a transaction.
+func (a *precompileAggregator) Add(blob json.RawMessage) error {
+	var res precompileResult
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return err
+	}
+	a.merged.Calls = append(a.merged.Calls, res.Calls...)
+	for name, stats := range res.Precompiles {
+		total := a.merged.Precompiles[name]
+		if total == nil {
+			total = &precompileStats{Address: stats.Address}
+			a.merged.Precompiles[name] = total
+		}
+		total.Calls += stats.Calls
+		total.Failed += stats.Failed
+		total.Gas += stats.Gas
+		total.InputBytes += stats.InputBytes
+		total.OutputBytes += stats.OutputBytes
+	}
+	return nil
+}
+
+// Result implements tracers.Aggregator, encoding the merged calls.
+func (a *precompileAggregator) Result() (json.RawMessage, error) {
+	return json.Marshal(&a.merged)
+}
+
+// decodeModexpInput decodes the header of a modexp input and the head of the
+// exponent, padding the input with zeroes as the precompile does.
+func decodeModexpInput(input []byte) *modexpInput {
+	length := func(offset uint64) uint64 {
+		n := new(big.Int).SetBytes(common.RightPadBytes(boundedSlice(input, offset, 32), 32))
+		if !n.IsUint64() {
+			return math.MaxUint64
+		}
+		return n.Uint64()
+	}
+	decoded := &modexpInput{
+		BaseLen: length(0),
+		ExpLen:  length(32),
+		ModLen:  length(64),
+	}
+	head := decoded.ExpLen
+	if head > 32 {
+		head = 32
+	}
+	if decoded.BaseLen <= math.MaxUint64-96 {
+		decoded.ExpHead = common.RightPadBytes(boundedSlice(input, 96+decoded.BaseLen, head), int(head))
+	}
+	return decoded
+}
+
+// boundedSlice returns the part of data starting at offset, at most size bytes
+// long. Out of bounds offsets result in an empty slice.
+func boundedSlice(data []byte, offset, size uint64) []byte {
+	if offset >= uint64(len(data)) {
+		return nil
+	}
+	end := offset + size
+	if end > uint64(len(data)) || end < offset {
+		end = uint64(len(data))
+	}
+	return data[offset:end]
+}
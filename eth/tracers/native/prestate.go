@@ -34,6 +34,7 @@ import (
 
 func init() {
 	register("prestateTracer", newPrestateTracer)
+	tracers.RegisterDeterministic("prestateTracer")
 }
 
 type state = map[common.Address]*account
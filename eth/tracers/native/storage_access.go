@@ -0,0 +1,283 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	register("storageAccessTracer", newStorageAccessTracer)
+	tracers.RegisterDeterministic("storageAccessTracer")
+	tracers.RegisterAggregator("storageAccessTracer", newStorageAccessAggregator)
+}
+
+// storageAccess is a single SLOAD or SSTORE executed by a transaction.
+type storageAccess struct {
+	Address  common.Address `json:"address"`
+	Slot     common.Hash    `json:"slot"`
+	Op       string         `json:"op"`
+	Pc       uint64         `json:"pc"`
+	Depth    int            `json:"depth"`
+	Cold     bool           `json:"cold"`          // Whether the slot was cold under EIP-2929 (always false before Berlin)
+	Gas      uint64         `json:"gas"`           // Gas charged for the access
+	Refund   int64          `json:"refund"`        // Change of the refund counter caused by the access
+	Original common.Hash    `json:"original"`      // Value of the slot before the transaction
+	Current  common.Hash    `json:"current"`       // Value of the slot before the access
+	New      *common.Hash   `json:"new,omitempty"` // Value written by an SSTORE
+}
+
+// storageContract is the aggregated storage access statistics of a contract.
+type storageContract struct {
+	Sloads      uint64 `json:"sloads"`
+	Sstores     uint64 `json:"sstores"`
+	ColdSloads  uint64 `json:"coldSloads"`
+	ColdSstores uint64 `json:"coldSstores"`
+	Gas         uint64 `json:"gas"`         // Total gas charged for storage accesses
+	Refund      int64  `json:"refund"`      // Total change of the refund counter
+	Slots       uint64 `json:"slots"`       // Number of distinct slots accessed, counted per transaction
+	Reuses      uint64 `json:"reuses"`      // Accesses to slots already accessed by the same transaction
+	NoopWrites  uint64 `json:"noopWrites"`  // Writes of the current value of a slot
+	ResetWrites uint64 `json:"resetWrites"` // Writes restoring a modified slot to its original value
+}
+
+// storageAccessResult is the result of the storage access tracer.
+type storageAccessResult struct {
+	Accesses  []*storageAccess                    `json:"accesses,omitempty"`
+	Contracts map[common.Address]*storageContract `json:"contracts"`
+}
+
+// storageAccessTracer records the SLOAD and SSTORE operations of a transaction,
+// along with their EIP-2929 warm/cold status, gas and refund accounting, and
+// aggregates them per contract. Its results are mergeable, so the statistics can
+// be aggregated over a block or a range of blocks via debug_aggregateChain.
+type storageAccessTracer struct {
+	noopTracer
+	env       *vm.EVM
+	config    storageAccessTracerConfig
+	accesses  []*storageAccess
+	contracts map[common.Address]*storageContract
+	touched   map[common.Address]map[common.Hash]struct{}
+	refund    uint64 // Refund counter as of the last traced step
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+type storageAccessTracerConfig struct {
+	OnlyContracts bool `json:"onlyContracts"` // If true, only the per contract statistics are reported
+}
+
+// newStorageAccessTracer returns a new storage access tracer.
+func newStorageAccessTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config storageAccessTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &storageAccessTracer{
+		config:    config,
+		accesses:  []*storageAccess{},
+		contracts: make(map[common.Address]*storageContract),
+		touched:   make(map[common.Address]map[common.Hash]struct{}),
+	}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *storageAccessTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.refund = env.StateDB.GetRefund()
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *storageAccessTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	// The dynamic gas of storage operations is charged, and any refunds granted,
+	// before the step is traced, so measure the refund against the previous step
+	refund := t.env.StateDB.GetRefund()
+	delta := int64(refund) - int64(t.refund)
+	t.refund = refund
+
+	if op != vm.SLOAD && op != vm.SSTORE {
+		return
+	}
+	stack := scope.Stack.Data()
+	if len(stack) < 1 || (op == vm.SSTORE && len(stack) < 2) {
+		return
+	}
+	var (
+		addr   = scope.Contract.Address()
+		slot   = common.Hash(stack[len(stack)-1].Bytes32())
+		rules  = t.env.ChainConfig().Rules(t.env.Context.BlockNumber, t.env.Context.Random != nil)
+		costs  = t.env.GasCosts()
+		access = &storageAccess{
+			Address:  addr,
+			Slot:     slot,
+			Op:       op.String(),
+			Pc:       pc,
+			Depth:    depth,
+			Gas:      cost,
+			Refund:   delta,
+			Original: t.env.StateDB.GetCommittedState(addr, slot),
+			Current:  t.env.StateDB.GetState(addr, slot),
+		}
+	)
+	// The slot is already added to the access list by the time the step is traced,
+	// so whether it was cold is derived from the charged gas instead
+	if op == vm.SLOAD {
+		access.Cold = rules.IsBerlin && cost == costs.ColdSload
+	} else {
+		value := common.Hash(stack[len(stack)-2].Bytes32())
+		access.New = &value
+		access.Cold = rules.IsBerlin && cost == warmSstoreCost(costs, access.Original, access.Current, value)+costs.ColdSload
+	}
+	t.record(access)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *storageAccessTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	// Refunds of reverted scopes are rolled back, don't attribute to the next step
+	t.refund = t.env.StateDB.GetRefund()
+}
+
+// record adds a storage access to the trace and the statistics of its contract.
+func (t *storageAccessTracer) record(access *storageAccess) {
+	if !t.config.OnlyContracts {
+		t.accesses = append(t.accesses, access)
+	}
+	contract := t.contracts[access.Address]
+	if contract == nil {
+		contract = new(storageContract)
+		t.contracts[access.Address] = contract
+	}
+	if access.New == nil {
+		contract.Sloads++
+		if access.Cold {
+			contract.ColdSloads++
+		}
+	} else {
+		contract.Sstores++
+		if access.Cold {
+			contract.ColdSstores++
+		}
+		if *access.New == access.Current {
+			contract.NoopWrites++
+		} else if *access.New == access.Original {
+			contract.ResetWrites++
+		}
+	}
+	contract.Gas += access.Gas
+	contract.Refund += access.Refund
+
+	slots := t.touched[access.Address]
+	if slots == nil {
+		slots = make(map[common.Hash]struct{})
+		t.touched[access.Address] = slots
+	}
+	if _, ok := slots[access.Slot]; ok {
+		contract.Reuses++
+	} else {
+		slots[access.Slot] = struct{}{}
+		contract.Slots++
+	}
+}
+
+// GetResult returns the json-encoded storage accesses and the per contract
+// statistics, and any error arising from the encoding or forceful termination
+// (via `Stop`).
+func (t *storageAccessTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(&storageAccessResult{t.accesses, t.contracts})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *storageAccessTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// storageAccessAggregator aggregates storage access results over multiple
+// transactions, concatenating their accesses and summing up the statistics of
+// each contract.
+type storageAccessAggregator struct {
+	merged storageAccessResult
+}
+
+func newStorageAccessAggregator() tracers.Aggregator {
+	return &storageAccessAggregator{
+		merged: storageAccessResult{
+			Contracts: make(map[common.Address]*storageContract),
+		},
+	}
+}
+
+// Add implements tracers.Aggregator, merging the accesses of a transaction.
+func (a *storageAccessAggregator) Add(blob json.RawMessage) error {
+	var res storageAccessResult
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return err
+	}
+	a.merged.Accesses = append(a.merged.Accesses, res.Accesses...)
+	for addr, stats := range res.Contracts {
+		contract := a.merged.Contracts[addr]
+		if contract == nil {
+			contract = new(storageContract)
+			a.merged.Contracts[addr] = contract
+		}
+		contract.Sloads += stats.Sloads
+		contract.Sstores += stats.Sstores
+		contract.ColdSloads += stats.ColdSloads
+		contract.ColdSstores += stats.ColdSstores
+		contract.Gas += stats.Gas
+		contract.Refund += stats.Refund
+		contract.Slots += stats.Slots
+		contract.Reuses += stats.Reuses
+		contract.NoopWrites += stats.NoopWrites
+		contract.ResetWrites += stats.ResetWrites
+	}
+	return nil
+}
+
+// Result implements tracers.Aggregator, encoding the merged accesses.
+func (a *storageAccessAggregator) Result() (json.RawMessage, error) {
+	return json.Marshal(&a.merged)
+}
+
+// warmSstoreCost returns the gas charged by an SSTORE to a warm slot under the
+// EIP-2929 rules, as implemented by gasSStoreEIP2929 in core/vm.
+func warmSstoreCost(costs vm.GasCosts, original, current, value common.Hash) uint64 {
+	if current == value || original != current {
+		return costs.WarmStorageRead
+	}
+	if original == (common.Hash{}) {
+		return params.SstoreSetGasEIP2200
+	}
+	return params.SstoreResetGasEIP2200 - costs.ColdSload
+}
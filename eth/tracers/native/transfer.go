@@ -0,0 +1,118 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("transferTracer", newTransferTracer)
+	tracers.RegisterDeterministic("transferTracer")
+	tracers.RegisterAggregator("transferTracer", newTransferAggregator)
+}
+
+// valueTransfer is a single internal value transfer, made by a call, a creation
+// or a self-destruct of a contract.
+type valueTransfer struct {
+	TxHash common.Hash    `json:"txHash"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Depth  int            `json:"depth"`
+}
+
+// transferTracer records the internal value transfers of a transaction, i.e.
+// all the transfers but the one of the transaction itself. Transfers of frames
+// reverted later on are included.
+type transferTracer struct {
+	noopTracer
+	ctx       *tracers.Context
+	transfers []*valueTransfer
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newTransferTracer returns a new internal value transfer tracer.
+func newTransferTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	if ctx == nil {
+		ctx = new(tracers.Context)
+	}
+	return &transferTracer{ctx: ctx, transfers: []*valueTransfer{}}, nil
+}
+
+// CaptureTransfer implements the vm.TransferLogger interface to trace a value
+// transfer.
+func (t *transferTracer) CaptureTransfer(from common.Address, to common.Address, value *big.Int, depth int) {
+	if depth == 0 || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	t.transfers = append(t.transfers, &valueTransfer{
+		TxHash: t.ctx.TxHash,
+		From:   from,
+		To:     to,
+		Value:  (*hexutil.Big)(new(big.Int).Set(value)),
+		Depth:  depth,
+	})
+}
+
+// GetResult returns the json-encoded internal value transfers, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *transferTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.transfers)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *transferTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// transferAggregator aggregates the internal value transfers of multiple
+// transactions by concatenating them.
+type transferAggregator struct {
+	merged []*valueTransfer
+}
+
+func newTransferAggregator() tracers.Aggregator {
+	return &transferAggregator{merged: []*valueTransfer{}}
+}
+
+// Add implements tracers.Aggregator, appending the transfers of a transaction.
+func (a *transferAggregator) Add(blob json.RawMessage) error {
+	var transfers []*valueTransfer
+	if err := json.Unmarshal(blob, &transfers); err != nil {
+		return err
+	}
+	a.merged = append(a.merged, transfers...)
+	return nil
+}
+
+// Result implements tracers.Aggregator, encoding the merged transfers.
+func (a *transferAggregator) Result() (json.RawMessage, error) {
+	return json.Marshal(a.merged)
+}
@@ -20,8 +20,11 @@ package tracers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
@@ -44,8 +47,28 @@ type Tracer interface {
 
 type lookupFunc func(string, *Context, json.RawMessage) (Tracer, error)
 
+// rewardFunc formats the mining rewards of a block, crediting its coinbase with
+// the given reward and the coinbases of its uncles with the given uncle rewards.
+type rewardFunc func(header *types.Header, uncles []*types.Header, reward *big.Int, uncleRewards []*big.Int) (json.RawMessage, error)
+
+// Aggregator accumulates the results of a tracer over multiple transactions in
+// memory, to be encoded once into a single result of the same format.
+type Aggregator interface {
+	// Add merges the result of a transaction into the aggregate.
+	Add(result json.RawMessage) error
+
+	// Result encodes the aggregate of the results added so far.
+	Result() (json.RawMessage, error)
+}
+
+// aggregatorFunc creates an empty aggregator of the results of a tracer.
+type aggregatorFunc func() Aggregator
+
 var (
-	lookups []lookupFunc
+	lookups       []lookupFunc
+	rewards       = make(map[string]rewardFunc)
+	aggregators   = make(map[string]aggregatorFunc)
+	deterministic = make(map[string]struct{})
 )
 
 // RegisterLookup registers a method as a lookup for tracers, meaning that
@@ -70,3 +93,72 @@ func New(code string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
 	}
 	return nil, errors.New("tracer not found")
 }
+
+// RegisterRewards registers a method formatting the mining rewards of a block
+// for a named tracer. The trace_* APIs report the formatted rewards after the
+// traces of the transactions of each block.
+func RegisterRewards(name string, format rewardFunc) {
+	rewards[name] = format
+}
+
+// RewardTraces formats the mining rewards of a block with the method registered
+// for the named tracer, returning nil if the tracer doesn't report rewards.
+func RewardTraces(name string, header *types.Header, uncles []*types.Header, reward *big.Int, uncleRewards []*big.Int) (json.RawMessage, error) {
+	format, ok := rewards[name]
+	if !ok {
+		return nil, nil
+	}
+	return format(header, uncles, reward, uncleRewards)
+}
+
+// RegisterAggregator registers a method creating aggregators of the results of
+// a named tracer, which allows merging them over a block or a range of blocks.
+func RegisterAggregator(name string, aggregator aggregatorFunc) {
+	aggregators[name] = aggregator
+}
+
+// Mergeable returns whether the results of the named tracer can be merged.
+func Mergeable(name string) bool {
+	_, ok := aggregators[name]
+	return ok
+}
+
+// NewAggregator creates an empty aggregator of the results of the named tracer.
+func NewAggregator(name string) (Aggregator, error) {
+	aggregator, ok := aggregators[name]
+	if !ok {
+		return nil, fmt.Errorf("tracer %s does not support aggregation", name)
+	}
+	return aggregator(), nil
+}
+
+// Merge merges the results of the named tracer into a single result.
+func Merge(name string, results []json.RawMessage) (json.RawMessage, error) {
+	aggregator, err := NewAggregator(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if err := aggregator.Add(result); err != nil {
+			return nil, err
+		}
+	}
+	return aggregator.Result()
+}
+
+// RegisterDeterministic declares that the results of a named tracer only depend
+// on the traced execution, allowing them to be cached. Tracers reporting timings
+// or running user-supplied code must not be declared deterministic.
+func RegisterDeterministic(name string) {
+	deterministic[name] = struct{}{}
+}
+
+// Deterministic returns whether the results of the named tracer only depend on
+// the traced execution. The default struct logger (empty name) is deterministic.
+func Deterministic(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, ok := deterministic[name]
+	return ok
+}
@@ -19,11 +19,13 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -1100,6 +1102,41 @@ func (b *Block) EstimateGas(ctx context.Context, args struct {
 
 type Pending struct {
 	r *Resolver
+
+	pages    map[string]*txPoolPage // Pool queries resolved by this object, by filter
+	pagesMtx sync.Mutex
+}
+
+// txPoolPage is the result of a pool query, shared by the resolvers of the
+// same filter.
+type txPoolPage struct {
+	entries []*ethapi.TxPoolEntry
+	cursor  hexutil.Bytes
+}
+
+// queryPool retrieves the pool transactions matching the filter, resolving the
+// query only once for all the fields requesting the same filter.
+func (p *Pending) queryPool(ctx context.Context, filter ethapi.TxPoolQueryArgs) (*txPoolPage, error) {
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	p.pagesMtx.Lock()
+	defer p.pagesMtx.Unlock()
+
+	if page, ok := p.pages[string(key)]; ok {
+		return page, nil
+	}
+	entries, cursor, err := ethapi.QueryTxPool(ctx, p.r.backend, filter)
+	if err != nil {
+		return nil, err
+	}
+	if p.pages == nil {
+		p.pages = make(map[string]*txPoolPage)
+	}
+	page := &txPoolPage{entries: entries, cursor: cursor}
+	p.pages[string(key)] = page
+	return page, nil
 }
 
 func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
@@ -1107,7 +1144,25 @@ func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
 	return int32(len(txs)), err
 }
 
-func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
+func (p *Pending) Transactions(ctx context.Context, args struct {
+	Filter *ethapi.TxPoolQueryArgs
+}) (*[]*Transaction, error) {
+	if args.Filter != nil {
+		page, err := p.queryPool(ctx, *args.Filter)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]*Transaction, 0, len(page.entries))
+		for i, entry := range page.entries {
+			ret = append(ret, &Transaction{
+				r:     p.r,
+				hash:  entry.Tx.Hash(),
+				tx:    entry.Tx,
+				index: uint64(i),
+			})
+		}
+		return &ret, nil
+	}
 	txs, err := p.r.backend.GetPoolTransactions()
 	if err != nil {
 		return nil, err
@@ -1124,6 +1179,16 @@ func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
 	return &ret, nil
 }
 
+func (p *Pending) NextCursor(ctx context.Context, args struct {
+	Filter ethapi.TxPoolQueryArgs
+}) (*hexutil.Bytes, error) {
+	page, err := p.queryPool(ctx, args.Filter)
+	if page == nil || page.cursor == nil {
+		return nil, err
+	}
+	return &page.cursor, nil
+}
+
 func (p *Pending) Account(ctx context.Context, args struct {
 	Address common.Address
 }) *Account {
@@ -1247,7 +1312,7 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 }
 
 func (r *Resolver) Pending(ctx context.Context) *Pending {
-	return &Pending{r}
+	return &Pending{r: r}
 }
 
 func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -365,3 +366,100 @@ func newGQLService(t *testing.T, stack *node.Node, gspec *core.Genesis, genBlock
 	}
 	return handler
 }
+
+// Tests that the pool transactions can be filtered and paginated, with the cursor
+// of each page leading to the next one.
+func TestGraphQLPendingTransactionsFilter(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dad     = common.HexToAddress("0x0000000000000000000000000000000000000dad")
+		genesis = &core.Genesis{
+			Config:     params.AllEthashProtocolChanges,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+
+	handler := newGQLService(t, stack, genesis, 0, func(i int, gen *core.BlockGen) {})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	// Submit three executable transactions and a gapped one
+	var hashes []common.Hash
+	for _, nonce := range []uint64{0, 1, 2, 4} {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Nonce: nonce, Gas: 21000, GasPrice: big.NewInt(params.InitialBaseFee)})
+		raw, _ := tx.MarshalBinary()
+
+		query := fmt.Sprintf(`mutation { sendRawTransaction(data: "%s") }`, hexutil.Encode(raw))
+		if res := handler.Schema.Exec(context.Background(), query, "", nil); res.Errors != nil {
+			t.Fatalf("failed to send transaction %d: %v", nonce, res.Errors)
+		}
+		hashes = append(hashes, tx.Hash())
+	}
+	type page struct {
+		Pending struct {
+			Transactions []struct {
+				Hash  common.Hash
+				Nonce hexutil.Uint64
+			}
+			NextCursor *hexutil.Bytes
+		}
+	}
+	exec := func(filter string) *page {
+		t.Helper()
+
+		query := fmt.Sprintf(`{ pending { transactions(filter: %s) { hash nonce } nextCursor(filter: %s) } }`, filter, filter)
+		res := handler.Schema.Exec(context.Background(), query, "", nil)
+		if res.Errors != nil {
+			t.Fatalf("graphql query failed: %v", res.Errors)
+		}
+		result := new(page)
+		if err := json.Unmarshal(res.Data, result); err != nil {
+			t.Fatalf("failed to decode graphql response: %v", err)
+		}
+		return result
+	}
+	// Page through all the transactions by nonce
+	var (
+		have   []common.Hash
+		cursor *hexutil.Bytes
+	)
+	for i := 0; i < 3; i++ {
+		filter := `{limit: 3}`
+		if cursor != nil {
+			filter = fmt.Sprintf(`{limit: 3, cursor: "%s"}`, cursor)
+		}
+		result := exec(filter)
+		for _, tx := range result.Pending.Transactions {
+			have = append(have, tx.Hash)
+		}
+		if cursor = result.Pending.NextCursor; cursor == nil {
+			break
+		}
+	}
+	if cursor != nil {
+		t.Fatalf("pagination did not terminate")
+	}
+	assert.Equal(t, hashes, have)
+
+	// Filter the gapped transaction
+	result := exec(`{gapped: true}`)
+	if len(result.Pending.Transactions) != 1 || result.Pending.Transactions[0].Hash != hashes[3] {
+		t.Errorf("gapped transactions mismatch: have %v, want %x", result.Pending.Transactions, hashes[3])
+	}
+	if result.Pending.NextCursor != nil {
+		t.Errorf("unexpected cursor for the last page: %v", result.Pending.NextCursor)
+	}
+	// Ensure invalid filters are rejected
+	query := `{ pending { transactions(filter: {sort: "random"}) { hash } } }`
+	if res := handler.Schema.Exec(context.Background(), query, "", nil); res.Errors == nil {
+		t.Errorf("invalid sort order accepted")
+	}
+}
@@ -314,12 +314,44 @@ const schema string = `
         highestBlock: Long!
     }
 
+    # TxPoolFilter encapsulates the criteria for filtering, ordering and paginating
+    # the transactions of the pool.
+    input TxPoolFilter {
+        # From restricts the results to transactions sent by the given account.
+        from: Address
+        # To restricts the results to transactions sent to the given account.
+        to: Address
+        # MinTip restricts the results to transactions paying at least the given
+        # effective tip at the current base fee.
+        minTip: BigInt
+        # Status restricts the results to either "pending" (executable) or
+        # "queued" (non-executable) transactions.
+        status: String
+        # Gapped restricts the results to transactions that are (or are not)
+        # blocked by a missing nonce of their sender.
+        gapped: Boolean
+        # Sort is the order of the results, either "nonce" (by sender and nonce,
+        # the default), "tip" (highest effective tip first) or "arrival" (oldest
+        # first).
+        sort: String
+        # Cursor is the position after which to continue the results, as returned
+        # by nextCursor for the same filter.
+        cursor: Bytes
+        # Limit is the maximum number of transactions to return, 100 by default.
+        limit: Long
+    }
+
     # Pending represents the current pending state.
     type Pending {
       # TransactionCount is the number of transactions in the pending state.
       transactionCount: Int!
-      # Transactions is a list of transactions in the current pending state.
-      transactions: [Transaction!]
+      # Transactions is a list of transactions in the current pending state. If
+      # a filter is supplied, the queued transactions of the pool are included
+      # as well unless the filter restricts the status.
+      transactions(filter: TxPoolFilter): [Transaction!]
+      # NextCursor is the cursor to retrieve the page of transactions following
+      # the one returned for the same filter, or null if there are no more.
+      nextCursor(filter: TxPoolFilter!): Bytes
       # Account fetches an Ethereum account for the pending state.
       account(address: Address!): Account!
       # Call executes a local call operation for the pending state.
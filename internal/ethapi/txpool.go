@@ -0,0 +1,237 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultTxPoolQueryLimit is the number of transactions returned by a pool
+	// query if no explicit limit is requested.
+	defaultTxPoolQueryLimit = 100
+
+	// maxTxPoolQueryLimit is the maximum number of transactions returned by a
+	// single pool query.
+	maxTxPoolQueryLimit = 1000
+)
+
+// Statuses of the transactions within the pool.
+const (
+	TxPoolStatusPending = "pending" // Executable transaction
+	TxPoolStatusQueued  = "queued"  // Non-executable transaction
+)
+
+// Sort orders of the pool queries.
+const (
+	TxPoolSortNonce   = "nonce"   // By sender and nonce (default)
+	TxPoolSortTip     = "tip"     // By effective tip, highest first
+	TxPoolSortArrival = "arrival" // By arrival time, oldest first
+)
+
+// TxPoolQueryArgs represents the arguments to filter, sort and paginate the
+// transactions of the pool with. All filters are optional.
+type TxPoolQueryArgs struct {
+	From   *common.Address `json:"from"`   // Sender of the transactions
+	To     *common.Address `json:"to"`     // Recipient of the transactions
+	MinTip *hexutil.Big    `json:"minTip"` // Minimum effective tip at the current base fee
+	Status *string         `json:"status"` // Either "pending" or "queued"
+	Gapped *bool           `json:"gapped"` // Whether the transactions are blocked by a nonce gap
+	Sort   *string         `json:"sort"`   // Either "nonce", "tip" or "arrival"
+	Cursor *hexutil.Bytes  `json:"cursor"` // Cursor returned by the previous page
+	Limit  *hexutil.Uint64 `json:"limit"`  // Maximum number of transactions to return
+}
+
+// TxPoolEntry is a single transaction of the pool matching a query.
+type TxPoolEntry struct {
+	Tx     *types.Transaction
+	Status string // Either "pending" or "queued"
+	Gapped bool   // Whether the transaction is blocked by a nonce gap
+
+	key []byte // Position of the transaction in the requested sort order
+}
+
+// QueryTxPool retrieves a page of the pool transactions matching the query, along
+// with the cursor to retrieve the next page with (nil if there are no more).
+func QueryTxPool(ctx context.Context, b Backend, args TxPoolQueryArgs) ([]*TxPoolEntry, hexutil.Bytes, error) {
+	// Sanitize the query arguments
+	order := TxPoolSortNonce
+	if args.Sort != nil {
+		order = *args.Sort
+	}
+	if order != TxPoolSortNonce && order != TxPoolSortTip && order != TxPoolSortArrival {
+		return nil, nil, fmt.Errorf("invalid sort order %q", order)
+	}
+	if args.Status != nil && *args.Status != TxPoolStatusPending && *args.Status != TxPoolStatusQueued {
+		return nil, nil, fmt.Errorf("invalid transaction status %q", *args.Status)
+	}
+	limit := uint64(defaultTxPoolQueryLimit)
+	if args.Limit != nil {
+		limit = uint64(*args.Limit)
+	}
+	if limit == 0 || limit > maxTxPoolQueryLimit {
+		return nil, nil, fmt.Errorf("invalid limit %d, must be within [1, %d]", limit, maxTxPoolQueryLimit)
+	}
+	// Gather the pool content, restricting to the sender if requested
+	var pending, queued map[common.Address]types.Transactions
+	if args.From != nil {
+		p, q := b.TxPoolContentFrom(*args.From)
+		pending = map[common.Address]types.Transactions{*args.From: p}
+		queued = map[common.Address]types.Transactions{*args.From: q}
+	} else {
+		pending, queued = b.TxPoolContent()
+	}
+	state, head, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, nil, err
+	}
+	// Filter the transactions and position them in the requested order
+	var entries []*TxPoolEntry
+	for addr, txs := range pending {
+		for _, tx := range txs {
+			entry := &TxPoolEntry{Tx: tx, Status: TxPoolStatusPending}
+			if matchTxPoolEntry(entry, args, head.BaseFee) {
+				entry.key = txPoolSortKey(addr, tx, order, head.BaseFee, b.TxPoolArrival)
+				entries = append(entries, entry)
+			}
+		}
+	}
+	for addr, txs := range queued {
+		// Any queued transaction beyond the first missing nonce is gapped
+		next := state.GetNonce(addr)
+		if txs := pending[addr]; len(txs) > 0 {
+			next = txs[len(txs)-1].Nonce() + 1
+		}
+		for _, tx := range txs {
+			if tx.Nonce() == next {
+				next++
+			}
+			entry := &TxPoolEntry{Tx: tx, Status: TxPoolStatusQueued, Gapped: tx.Nonce() >= next}
+			if matchTxPoolEntry(entry, args, head.BaseFee) {
+				entry.key = txPoolSortKey(addr, tx, order, head.BaseFee, b.TxPoolArrival)
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	// Skip anything up to the cursor and cut the page
+	if args.Cursor != nil {
+		entries = entries[sort.Search(len(entries), func(i int) bool {
+			return bytes.Compare(entries[i].key, *args.Cursor) > 0
+		}):]
+	}
+	if uint64(len(entries)) <= limit {
+		return entries, nil, nil
+	}
+	entries = entries[:limit]
+	return entries, entries[limit-1].key, nil
+}
+
+// matchTxPoolEntry checks whether a pool transaction matches the query filters.
+func matchTxPoolEntry(entry *TxPoolEntry, args TxPoolQueryArgs, baseFee *big.Int) bool {
+	if args.Status != nil && entry.Status != *args.Status {
+		return false
+	}
+	if args.Gapped != nil && entry.Gapped != *args.Gapped {
+		return false
+	}
+	if args.To != nil && (entry.Tx.To() == nil || *entry.Tx.To() != *args.To) {
+		return false
+	}
+	if args.MinTip != nil && entry.Tx.EffectiveGasTipIntCmp(args.MinTip.ToInt(), baseFee) < 0 {
+		return false
+	}
+	return true
+}
+
+// txPoolSortKey generates a binary key for a pool transaction, the lexicographic
+// order of which matches the requested sort order. The keys are unique as they
+// all end with the transaction hash, and are used as the pagination cursors too.
+func txPoolSortKey(from common.Address, tx *types.Transaction, order string, baseFee *big.Int, arrival func(common.Hash) time.Time) []byte {
+	var key []byte
+	switch order {
+	case TxPoolSortNonce:
+		key = append(key, from.Bytes()...)
+		key = binary.BigEndian.AppendUint64(key, tx.Nonce())
+
+	case TxPoolSortTip:
+		// Invert the tip to order the highest first, clamping negative ones
+		tip := tx.EffectiveGasTipValue(baseFee)
+		if tip.Sign() < 0 {
+			tip = new(big.Int)
+		}
+		if tip.BitLen() > 256 {
+			tip = math.MaxBig256
+		}
+		key = append(key, math.U256Bytes(new(big.Int).Sub(math.MaxBig256, tip))...)
+
+	case TxPoolSortArrival:
+		key = binary.BigEndian.AppendUint64(key, uint64(arrival(tx.Hash()).UnixNano()))
+	}
+	return append(key, tx.Hash().Bytes()...)
+}
+
+// RPCTxPoolTransaction is a pool transaction returned by a pool query.
+type RPCTxPoolTransaction struct {
+	*RPCTransaction
+	Status string `json:"status"`
+	Gapped bool   `json:"gapped"`
+}
+
+// RPCTxPoolPage is a single page of the pool transactions matching a query.
+type RPCTxPoolPage struct {
+	Transactions []*RPCTxPoolTransaction `json:"transactions"`
+	Cursor       hexutil.Bytes           `json:"cursor"` // Cursor of the next page, nil if there are no more
+}
+
+// Query retrieves a page of the pool transactions matching the given filters in
+// the requested order. Further pages can be retrieved by passing the returned
+// cursor back along the same filters.
+func (s *TxPoolAPI) Query(ctx context.Context, args TxPoolQueryArgs) (*RPCTxPoolPage, error) {
+	entries, cursor, err := QueryTxPool(ctx, s.b, args)
+	if err != nil {
+		return nil, err
+	}
+	curHeader := s.b.CurrentHeader()
+
+	page := &RPCTxPoolPage{
+		Transactions: make([]*RPCTxPoolTransaction, 0, len(entries)),
+		Cursor:       cursor,
+	}
+	for _, entry := range entries {
+		page.Transactions = append(page.Transactions, &RPCTxPoolTransaction{
+			RPCTransaction: NewRPCPendingTransaction(entry.Tx, curHeader, s.b.ChainConfig()),
+			Status:         entry.Status,
+			Gapped:         entry.Gapped,
+		})
+	}
+	return page, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// txPoolBackendMock is a backend mock serving a fixed pool content.
+type txPoolBackendMock struct {
+	*backendMock
+	state    *state.StateDB
+	pending  map[common.Address]types.Transactions
+	queued   map[common.Address]types.Transactions
+	arrivals map[common.Hash]time.Time
+}
+
+func (b *txPoolBackendMock) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.state, b.current, nil
+}
+func (b *txPoolBackendMock) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return b.pending, b.queued
+}
+func (b *txPoolBackendMock) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return b.pending[addr], b.queued[addr]
+}
+func (b *txPoolBackendMock) TxPoolArrival(hash common.Hash) time.Time {
+	return b.arrivals[hash]
+}
+
+// Tests that pool queries filter, order and paginate the pool content.
+func TestQueryTxPool(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		target  = common.Address{0x01}
+	)
+	backend := &txPoolBackendMock{backendMock: newBackendMock()}
+	backend.state, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	backend.state.SetNonce(addr2, 1)
+
+	signer := types.LatestSigner(backend.config)
+	sign := func(key *ecdsa.PrivateKey, nonce uint64, tip int64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   backend.config.ChainID,
+			Nonce:     nonce,
+			To:        &target,
+			Gas:       21000,
+			GasFeeCap: big.NewInt(1000),
+			GasTipCap: big.NewInt(tip),
+		})
+	}
+	backend.pending = map[common.Address]types.Transactions{
+		addr1: {sign(key1, 0, 5), sign(key1, 1, 3)},
+		addr2: {sign(key2, 1, 7)},
+	}
+	backend.queued = map[common.Address]types.Transactions{
+		addr1: {sign(key1, 3, 1)},                   // gapped, nonce 2 missing
+		addr2: {sign(key2, 2, 9), sign(key2, 4, 2)}, // first consecutive, second gapped
+	}
+	query := func(args TxPoolQueryArgs) ([]*TxPoolEntry, hexutil.Bytes) {
+		t.Helper()
+		entries, cursor, err := QueryTxPool(context.Background(), backend, args)
+		if err != nil {
+			t.Fatalf("failed to query pool: %v", err)
+		}
+		return entries, cursor
+	}
+	nonces := func(entries []*TxPoolEntry) []uint64 {
+		var nonces []uint64
+		for _, entry := range entries {
+			nonces = append(nonces, entry.Tx.Nonce())
+		}
+		return nonces
+	}
+	// Filter by sender, status and gap state
+	if entries, _ := query(TxPoolQueryArgs{From: &addr1}); len(entries) != 3 {
+		t.Errorf("sender filter mismatch: have %d txs, want %d", len(entries), 3)
+	}
+	status := TxPoolStatusQueued
+	if entries, _ := query(TxPoolQueryArgs{Status: &status}); len(entries) != 3 {
+		t.Errorf("status filter mismatch: have %d txs, want %d", len(entries), 3)
+	}
+	gapped := true
+	entries, _ := query(TxPoolQueryArgs{Gapped: &gapped})
+	if len(entries) != 2 {
+		t.Fatalf("gap filter mismatch: have %d txs, want %d", len(entries), 2)
+	}
+	for _, entry := range entries {
+		if entry.Status != TxPoolStatusQueued || (entry.Tx.Nonce() != 3 && entry.Tx.Nonce() != 4) {
+			t.Errorf("unexpected gapped tx: status %s, nonce %d", entry.Status, entry.Tx.Nonce())
+		}
+	}
+	// Filter by tip and sort by it, paginating through the results
+	var (
+		order  = TxPoolSortTip
+		minTip = (*hexutil.Big)(big.NewInt(3))
+		limit  = hexutil.Uint64(2)
+		cursor *hexutil.Bytes
+		tips   []int64
+	)
+	for {
+		entries, next := query(TxPoolQueryArgs{MinTip: minTip, Sort: &order, Cursor: cursor, Limit: &limit})
+		for _, entry := range entries {
+			tips = append(tips, entry.Tx.GasTipCap().Int64())
+		}
+		if next == nil {
+			break
+		}
+		cursor = &next
+	}
+	if want := []int64{9, 7, 5, 3}; !reflect.DeepEqual(tips, want) {
+		t.Errorf("tip pagination mismatch: have %v, want %v", tips, want)
+	}
+	// Default nonce ordering keeps the transactions of a sender together
+	entries, _ = query(TxPoolQueryArgs{From: &addr2})
+	if have, want := nonces(entries), []uint64{1, 2, 4}; !reflect.DeepEqual(have, want) {
+		t.Errorf("nonce order mismatch: have %v, want %v", have, want)
+	}
+	// Arrival ordering uses the arrival times tracked by the pool
+	backend.arrivals = make(map[common.Hash]time.Time)
+	for i, tx := range append(backend.queued[addr2], backend.pending[addr2]...) {
+		backend.arrivals[tx.Hash()] = time.Unix(int64(100-i), 0)
+	}
+	order = TxPoolSortArrival
+	entries, _ = query(TxPoolQueryArgs{From: &addr2, Sort: &order})
+	if have, want := nonces(entries), []uint64{1, 4, 2}; !reflect.DeepEqual(have, want) {
+		t.Errorf("arrival order mismatch: have %v, want %v", have, want)
+	}
+	// Invalid arguments are rejected
+	bad := "fee"
+	if _, _, err := QueryTxPool(context.Background(), backend, TxPoolQueryArgs{Sort: &bad}); err == nil {
+		t.Errorf("invalid sort order accepted")
+	}
+}
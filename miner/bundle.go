@@ -0,0 +1,179 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxBundles is the maximum number of bundles tracked by the miner at once.
+const maxBundles = 1024
+
+var (
+	// errBundleEmpty is returned if a bundle without any transactions is submitted.
+	errBundleEmpty = errors.New("empty bundle")
+
+	// errBundleKnown is returned if a bundle is submitted for the second time.
+	errBundleKnown = errors.New("bundle already known")
+
+	// errBundlePoolFull is returned if too many bundles await inclusion already.
+	errBundlePoolFull = errors.New("too many pending bundles")
+
+	// errBundleReverted is returned if a transaction of a bundle is included but
+	// its execution fails.
+	errBundleReverted = errors.New("bundle transaction reverted")
+)
+
+// Bundle is an ordered batch of transactions targeting a specific block, which
+// are either all included at the top of the block successfully, or not at all.
+type Bundle struct {
+	Txs         types.Transactions // Transactions to include, in order
+	BlockNumber uint64             // Number of the block the bundle targets
+}
+
+// Hash returns the identifier of the bundle, the hash of its transaction hashes.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// bundlePool tracks the bundles submitted to the miner until their target block
+// is built past.
+type bundlePool struct {
+	bundles []*Bundle // Bundles in order of submission
+	lock    sync.Mutex
+}
+
+// add inserts a bundle into the pool, unless it's already known.
+func (p *bundlePool) add(bundle *Bundle) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(bundle.Txs) == 0 {
+		return errBundleEmpty
+	}
+	hash := bundle.Hash()
+	for _, b := range p.bundles {
+		if b.BlockNumber == bundle.BlockNumber && b.Hash() == hash {
+			return errBundleKnown
+		}
+	}
+	if len(p.bundles) >= maxBundles {
+		return errBundlePoolFull
+	}
+	p.bundles = append(p.bundles, bundle)
+	return nil
+}
+
+// pending discards all bundles targeting blocks below the given number and
+// returns the ones targeting it.
+func (p *bundlePool) pending(number uint64) []*Bundle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var (
+		live    = p.bundles[:0]
+		pending []*Bundle
+	)
+	for _, bundle := range p.bundles {
+		if bundle.BlockNumber < number {
+			continue
+		}
+		live = append(live, bundle)
+		if bundle.BlockNumber == number {
+			pending = append(pending, bundle)
+		}
+	}
+	for i := len(live); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	p.bundles = live
+	return pending
+}
+
+// remove discards a bundle from the pool.
+func (p *bundlePool) remove(bundle *Bundle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i, b := range p.bundles {
+		if b == bundle {
+			p.bundles = append(p.bundles[:i], p.bundles[i+1:]...)
+			return
+		}
+	}
+}
+
+// commitBundles includes all the bundles targeting the sealing block in order of
+// submission, dropping the ones which cannot be included in full.
+func (w *worker) commitBundles(env *environment, interrupt *int32) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	number := env.header.Number.Uint64()
+	for _, bundle := range w.bundles.pending(number) {
+		// Check interruption signal and abort building if it's fired.
+		if interrupt != nil {
+			if signal := atomic.LoadInt32(interrupt); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		// Execute the bundle on a copy of the environment, since the state can
+		// not be reverted across the intermediate transaction finalisations, and
+		// only adopt the copy if the whole bundle succeeded
+		work := env.copy()
+		if err := w.commitBundle(work, bundle); err != nil {
+			work.discard()
+			log.Debug("Dropping failed bundle", "hash", bundle.Hash(), "number", number, "err", err)
+			w.bundles.remove(bundle)
+			continue
+		}
+		env.discard()
+		*env = *work
+		log.Debug("Committed bundle", "hash", bundle.Hash(), "number", number, "txs", len(bundle.Txs))
+	}
+	return nil
+}
+
+// commitBundle executes the transactions of a bundle in order, failing if any of
+// them cannot be included or if its execution reverts.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) error {
+	for _, tx := range bundle.Txs {
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			return fmt.Errorf("transaction %x: %w", tx.Hash(), err)
+		}
+		env.tcount++
+
+		if receipt := env.receipts[len(env.receipts)-1]; receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("%w: %x", errBundleReverted, tx.Hash())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that bundles are included atomically at the top of their target block,
+// and that failing bundles are dropped without leaving any transactions behind.
+func TestBundleInclusion(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	signer := types.LatestSigner(ethashChainConfig)
+	transfer := func(nonce uint64, to common.Address, value int64, bank bool) *types.Transaction {
+		key := testUserKey
+		if bank {
+			key = testBankKey
+		}
+		return types.MustSignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    big.NewInt(value),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(2 * params.InitialBaseFee),
+		})
+	}
+	// Fund the unfunded user and spend the funds within the same bundle, then
+	// submit a second bundle failing on its last transaction
+	good := types.Transactions{
+		transfer(0, testUserAddress, 1e15, true),
+		transfer(0, testBankAddress, 1000, false),
+	}
+	bad := types.Transactions{
+		transfer(1, testUserAddress, 1000, true),
+		transfer(5, testBankAddress, 1000, false),
+	}
+	for _, txs := range []types.Transactions{good, bad} {
+		if _, err := (&Miner{worker: w}).SendBundle(txs, 1); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+	}
+	if _, err := (&Miner{worker: w}).SendBundle(good, 0); err == nil {
+		t.Fatalf("bundle targeting the past accepted")
+	}
+	block, _, err := w.getSealingBlock(b.chain.CurrentBlock().Hash(), uint64(time.Now().Unix()), testBankAddress, common.Hash{}, false)
+	if err != nil {
+		t.Fatalf("failed to build block: %v", err)
+	}
+	// The pooled transaction of the bank is superseded by the bundle
+	txs := block.Transactions()
+	if len(txs) != len(good) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(good))
+	}
+	for i, tx := range good {
+		if txs[i].Hash() != tx.Hash() {
+			t.Errorf("transaction %d: hash mismatch: have %x, want %x", i, txs[i].Hash(), tx.Hash())
+		}
+	}
+	if pending := w.bundles.pending(1); len(pending) != 1 || pending[0].Hash() != (&Bundle{Txs: good}).Hash() {
+		t.Errorf("failed bundle not dropped: %d bundles pending", len(pending))
+	}
+	// Interrupted block building must not include any further bundles
+	env, err := w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), coinbase: testBankAddress})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	defer env.discard()
+
+	interrupt := new(int32)
+	atomic.StoreInt32(interrupt, commitInterruptNewHead)
+	if err := w.commitBundles(env, interrupt); err != errBlockInterruptedByNewHead {
+		t.Errorf("interrupt error mismatch: have %v, want %v", err, errBlockInterruptedByNewHead)
+	}
+	if len(env.txs) != 0 {
+		t.Errorf("interrupted bundles included: %d transactions", len(env.txs))
+	}
+	// Ensure bundles are discarded once their block is passed
+	if pending := w.bundles.pending(2); len(pending) != 0 || len(w.bundles.bundles) != 0 {
+		t.Errorf("stale bundles retained: %d tracked", len(w.bundles.bundles))
+	}
+}
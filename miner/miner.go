@@ -56,6 +56,9 @@ type Config struct {
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
+
+	Ordering     string // Transaction ordering policy used to fill blocks (price, fifo, random, localfirst)
+	OrderingSeed int64  // Seed of the random transaction ordering policy
 }
 
 // DefaultConfig contains default settings for miner.
@@ -69,6 +72,8 @@ var DefaultConfig = Config{
 	// run 3 rounds.
 	Recommit:          2 * time.Second,
 	NewPayloadTimeout: 2 * time.Second,
+
+	Ordering: OrderingPrice,
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -198,6 +203,26 @@ func (miner *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetOrderingPolicy sets the policy used to order the transactions of the
+// blocks being built. It takes effect from the next sealing work on.
+func (miner *Miner) SetOrderingPolicy(policy TxOrderingPolicy) {
+	miner.worker.setOrderingPolicy(policy)
+}
+
+// SendBundle submits an ordered batch of transactions to be included atomically
+// at the top of the block with the given number. The bundle is dropped if any of
+// its transactions fails or if the block is built without it.
+func (miner *Miner) SendBundle(txs types.Transactions, number uint64) (common.Hash, error) {
+	if head := miner.worker.chain.CurrentBlock().NumberU64(); number <= head {
+		return common.Hash{}, fmt.Errorf("bundle targets past block %d, head is %d", number, head)
+	}
+	bundle := &Bundle{Txs: txs, BlockNumber: number}
+	if err := miner.worker.bundles.add(bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
 // SetRecommitInterval sets the interval for sealing work resubmitting.
 func (miner *Miner) SetRecommitInterval(interval time.Duration) {
 	miner.worker.setRecommitInterval(interval)
@@ -0,0 +1,289 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// OrderingPrice fills the block with the local transactions first and the
+	// remote ones afterwards, both sorted by effective miner tip. This is the
+	// default ordering policy.
+	OrderingPrice = "price"
+
+	// OrderingFIFO fills the block with the transactions in the order they were
+	// first seen by the node, irrespective of their price and origin.
+	OrderingFIFO = "fifo"
+
+	// OrderingRandom fills the block with the transactions in a pseudo-random
+	// order, derived from the configured seed and the block number.
+	OrderingRandom = "random"
+
+	// OrderingLocalFirst fills the block with the local transactions first and
+	// the remote ones afterwards, both in the order they were first seen.
+	OrderingLocalFirst = "localfirst"
+)
+
+// OrderingPolicies is the list of built-in transaction ordering policies.
+var OrderingPolicies = []string{OrderingPrice, OrderingFIFO, OrderingRandom, OrderingLocalFirst}
+
+// TransactionSet is an iterator over the transactions of multiple accounts,
+// which hands out the transactions of every single account in nonce order.
+type TransactionSet interface {
+	// Peek returns the next transaction to be included, or nil if the set
+	// is exhausted.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one from the same
+	// account.
+	Shift()
+
+	// Pop removes the current transaction, *not* replacing it with the next
+	// one from the same account. It is used when a transaction cannot be
+	// executed and hence all subsequent ones of the account are discarded.
+	Pop()
+}
+
+// ArrivalFunc returns the time a pending transaction was first seen by the node.
+type ArrivalFunc func(hash common.Hash) time.Time
+
+// TxOrderingPolicy decides in which order the pending transactions of the pool
+// are offered to the block builder.
+type TxOrderingPolicy interface {
+	// Order assembles the transaction sets the block will be filled from. The
+	// sets are committed one after the other, each one until it's exhausted
+	// or the block runs out of gas. The arrival times of the transactions are
+	// looked up with the given function, as tracked by the pool.
+	//
+	// Note, the input maps are reowned so the implementation is free to modify
+	// them and the caller should not interact any more with them.
+	Order(header *types.Header, signer types.Signer, arrival ArrivalFunc, locals, remotes map[common.Address]types.Transactions) []TransactionSet
+}
+
+// NewTxOrderingPolicy creates one of the built-in transaction ordering policies
+// by name. The seed is only used by the random policy.
+func NewTxOrderingPolicy(name string, seed int64) (TxOrderingPolicy, error) {
+	switch name {
+	case OrderingPrice, "":
+		return new(priceOrdering), nil
+	case OrderingFIFO:
+		return new(fifoOrdering), nil
+	case OrderingRandom:
+		return &randomOrdering{seed: seed}, nil
+	case OrderingLocalFirst:
+		return new(localFirstOrdering), nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering policy %q", name)
+	}
+}
+
+// priceOrdering is the default ordering policy, sorting the local and the remote
+// transactions by their effective miner tip.
+type priceOrdering struct{}
+
+// Order implements TxOrderingPolicy.
+func (o *priceOrdering) Order(header *types.Header, signer types.Signer, arrival ArrivalFunc, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	var sets []TransactionSet
+	if len(locals) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(signer, locals, header.BaseFee))
+	}
+	if len(remotes) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(signer, remotes, header.BaseFee))
+	}
+	return sets
+}
+
+// fifoOrdering sorts all transactions by the time they were first seen.
+type fifoOrdering struct{}
+
+// Order implements TxOrderingPolicy.
+func (o *fifoOrdering) Order(header *types.Header, signer types.Signer, arrival ArrivalFunc, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	txs := mergeTransactions(locals, remotes)
+	if len(txs) == 0 {
+		return nil
+	}
+	return []TransactionSet{newTransactionsByKey(signer, txs, header.BaseFee, arrivalKey(arrival))}
+}
+
+// randomOrdering shuffles all transactions. The order is deterministic for a
+// given seed, block number and set of pending transactions.
+type randomOrdering struct {
+	seed int64
+}
+
+// Order implements TxOrderingPolicy.
+func (o *randomOrdering) Order(header *types.Header, signer types.Signer, arrival ArrivalFunc, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	txs := mergeTransactions(locals, remotes)
+	if len(txs) == 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(o.seed + header.Number.Int64()))
+	key := func(tx *types.Transaction) int64 {
+		return rng.Int63()
+	}
+	return []TransactionSet{newTransactionsByKey(signer, txs, header.BaseFee, key)}
+}
+
+// localFirstOrdering sorts the local and the remote transactions by the time
+// they were first seen.
+type localFirstOrdering struct{}
+
+// Order implements TxOrderingPolicy.
+func (o *localFirstOrdering) Order(header *types.Header, signer types.Signer, arrival ArrivalFunc, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	var sets []TransactionSet
+	if len(locals) > 0 {
+		sets = append(sets, newTransactionsByKey(signer, locals, header.BaseFee, arrivalKey(arrival)))
+	}
+	if len(remotes) > 0 {
+		sets = append(sets, newTransactionsByKey(signer, remotes, header.BaseFee, arrivalKey(arrival)))
+	}
+	return sets
+}
+
+// arrivalKey orders the transactions by the time they were first seen.
+func arrivalKey(arrival ArrivalFunc) func(tx *types.Transaction) int64 {
+	return func(tx *types.Transaction) int64 {
+		return arrival(tx.Hash()).UnixNano()
+	}
+}
+
+// mergeTransactions folds the local transactions into the remote set.
+func mergeTransactions(locals, remotes map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	if remotes == nil {
+		remotes = make(map[common.Address]types.Transactions, len(locals))
+	}
+	for addr, txs := range locals {
+		remotes[addr] = txs
+	}
+	return remotes
+}
+
+// keyedTx is an account head transaction with its ordering key.
+type keyedTx struct {
+	tx  *types.Transaction
+	key int64
+}
+
+// txsByKey implements the heap interface, ordering transactions by ascending
+// key and falling back to the hash for deterministic sorting.
+type txsByKey []*keyedTx
+
+func (s txsByKey) Len() int { return len(s) }
+func (s txsByKey) Less(i, j int) bool {
+	if s[i].key != s[j].key {
+		return s[i].key < s[j].key
+	}
+	return bytes.Compare(s[i].tx.Hash().Bytes(), s[j].tx.Hash().Bytes()) < 0
+}
+func (s txsByKey) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *txsByKey) Push(x interface{}) {
+	*s = append(*s, x.(*keyedTx))
+}
+
+func (s *txsByKey) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// transactionsByKey is a TransactionSet returning the account heads in the
+// order defined by an arbitrary key function, while honouring the nonces.
+type transactionsByKey struct {
+	txs     map[common.Address]types.Transactions // Per account nonce-sorted list of transactions
+	heads   txsByKey                              // Next transaction for each unique account (key heap)
+	signer  types.Signer                          // Signer for the set of transactions
+	baseFee *big.Int                              // Current base fee
+	key     func(tx *types.Transaction) int64     // Ordering key of a transaction
+}
+
+// newTransactionsByKey creates a transaction set that can retrieve key sorted
+// transactions in a nonce-honouring way. Transactions not paying the base fee
+// are dropped, the same way the price sorted set does it.
+func newTransactionsByKey(signer types.Signer, txs map[common.Address]types.Transactions, baseFee *big.Int, key func(tx *types.Transaction) int64) *transactionsByKey {
+	// Iterate the accounts in a stable order, the key function might be stateful
+	addrs := make([]common.Address, 0, len(txs))
+	for addr := range txs {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	heads := make(txsByKey, 0, len(txs))
+	for _, from := range addrs {
+		accTxs := txs[from]
+		acc, _ := types.Sender(signer, accTxs[0])
+		if acc != from || !paysBaseFee(accTxs[0], baseFee) {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, &keyedTx{tx: accTxs[0], key: key(accTxs[0])})
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &transactionsByKey{
+		txs:     txs,
+		heads:   heads,
+		signer:  signer,
+		baseFee: baseFee,
+		key:     key,
+	}
+}
+
+// Peek returns the next transaction by key.
+func (t *transactionsByKey) Peek() *types.Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0].tx
+}
+
+// Shift replaces the current head with the next one from the same account.
+func (t *transactionsByKey) Shift() {
+	acc, _ := types.Sender(t.signer, t.heads[0].tx)
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 && paysBaseFee(txs[0], t.baseFee) {
+		t.heads[0], t.txs[acc] = &keyedTx{tx: txs[0], key: t.key(txs[0])}, txs[1:]
+		heap.Fix(&t.heads, 0)
+		return
+	}
+	heap.Pop(&t.heads)
+}
+
+// Pop removes the current head, *not* replacing it with the next one from the
+// same account.
+func (t *transactionsByKey) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// paysBaseFee reports whether the transaction's fee cap covers the base fee.
+func paysBaseFee(tx *types.Transaction, baseFee *big.Int) bool {
+	_, err := tx.EffectiveGasTip(baseFee)
+	return err == nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// makeOrderingTxs creates a number of accounts with a number of nonce-sorted
+// transactions each, returning them grouped by sender.
+func makeOrderingTxs(t *testing.T, accounts, count int) (types.Signer, map[common.Address]types.Transactions) {
+	signer := types.LatestSigner(params.TestChainConfig)
+	txs := make(map[common.Address]types.Transactions)
+	for i := 0; i < accounts; i++ {
+		key, _ := crypto.GenerateKey()
+		txs[crypto.PubkeyToAddress(key.PublicKey)] = makeAccountTxs(t, signer, key, count)
+	}
+	return signer, txs
+}
+
+func makeAccountTxs(t *testing.T, signer types.Signer, key *ecdsa.PrivateKey, count int) types.Transactions {
+	var txs types.Transactions
+	for nonce := 0; nonce < count; nonce++ {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    uint64(nonce),
+			To:       &common.Address{},
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// testArrival is an arrival time lookup for tests not depending on the arrival
+// order of the transactions.
+func testArrival(hash common.Hash) time.Time {
+	return time.Time{}
+}
+
+// drainSets iterates over the transaction sets, returning all transactions.
+func drainSets(sets []TransactionSet) types.Transactions {
+	var txs types.Transactions
+	for _, set := range sets {
+		for tx := set.Peek(); tx != nil; tx = set.Peek() {
+			txs = append(txs, tx)
+			set.Shift()
+		}
+	}
+	return txs
+}
+
+// checkNonceOrder verifies that the transactions of every account are returned
+// in increasing nonce order and none of them is lost.
+func checkNonceOrder(t *testing.T, signer types.Signer, txs types.Transactions, total int) {
+	t.Helper()
+
+	if len(txs) != total {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), total)
+	}
+	nonces := make(map[common.Address]uint64)
+	for i, tx := range txs {
+		from, _ := types.Sender(signer, tx)
+		if tx.Nonce() != nonces[from] {
+			t.Fatalf("tx #%d: nonce mismatch: have %d, want %d", i, tx.Nonce(), nonces[from])
+		}
+		nonces[from]++
+	}
+}
+
+func TestOrderingPolicyByName(t *testing.T) {
+	for _, name := range OrderingPolicies {
+		if _, err := NewTxOrderingPolicy(name, 0); err != nil {
+			t.Errorf("policy %q: failed to create: %v", name, err)
+		}
+	}
+	if _, err := NewTxOrderingPolicy("lifo", 0); err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+}
+
+// Tests that the key sorted transaction set returns the account heads in key
+// order while honouring the account nonces.
+func TestTransactionsByKey(t *testing.T) {
+	signer, txs := makeOrderingTxs(t, 5, 5)
+
+	// Assign decreasing keys in creation order and compute the expected order
+	// by always picking the account head with the lowest key
+	var (
+		keys  = make(map[common.Hash]int64)
+		next  = int64(1000)
+		heads = make(map[common.Address]types.Transactions)
+		want  types.Transactions
+	)
+	for addr, accTxs := range txs {
+		for _, tx := range accTxs {
+			keys[tx.Hash()] = next
+			next--
+		}
+		heads[addr] = accTxs
+	}
+	for len(heads) > 0 {
+		var best common.Address
+		for addr, accTxs := range heads {
+			if cur, ok := heads[best]; !ok || keys[accTxs[0].Hash()] < keys[cur[0].Hash()] {
+				best = addr
+			}
+		}
+		want = append(want, heads[best][0])
+		if heads[best] = heads[best][1:]; len(heads[best]) == 0 {
+			delete(heads, best)
+		}
+	}
+	set := newTransactionsByKey(signer, txs, nil, func(tx *types.Transaction) int64 { return keys[tx.Hash()] })
+	sorted := drainSets([]TransactionSet{set})
+	checkNonceOrder(t, signer, sorted, 25)
+
+	for i := range want {
+		if sorted[i].Hash() != want[i].Hash() {
+			t.Errorf("tx #%d: order mismatch: have %x, want %x", i, sorted[i].Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that the random ordering policy is deterministic for the same seed and
+// block number, but differs across seeds.
+func TestRandomOrdering(t *testing.T) {
+	signer, txs := makeOrderingTxs(t, 10, 3)
+	header := &types.Header{Number: big.NewInt(1)}
+
+	order := func(seed int64) types.Transactions {
+		remotes := make(map[common.Address]types.Transactions)
+		for addr, accTxs := range txs {
+			remotes[addr] = accTxs
+		}
+		policy, _ := NewTxOrderingPolicy(OrderingRandom, seed)
+		return drainSets(policy.Order(header, signer, testArrival, nil, remotes))
+	}
+	first, second, other := order(1), order(1), order(2)
+
+	checkNonceOrder(t, signer, first, 30)
+	checkNonceOrder(t, signer, other, 30)
+	for i := range first {
+		if first[i].Hash() != second[i].Hash() {
+			t.Fatalf("tx #%d: order mismatch for same seed: have %x, want %x", i, second[i].Hash(), first[i].Hash())
+		}
+	}
+	same := true
+	for i := range first {
+		if first[i].Hash() != other[i].Hash() {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Errorf("different seeds produced the same order")
+	}
+}
+
+// Tests that the FIFO ordering policy sorts the transactions by the arrival times
+// tracked by the pool, rather than by the creation times of the transactions.
+func TestFIFOOrdering(t *testing.T) {
+	signer, txs := makeOrderingTxs(t, 3, 2)
+
+	// Assign arrival times in the reverse order of account iteration
+	var (
+		arrivals = make(map[common.Hash]time.Time)
+		want     types.Transactions
+		account  int
+	)
+	for _, accTxs := range txs {
+		for nonce, tx := range accTxs {
+			arrivals[tx.Hash()] = time.Unix(int64(100*nonce-account), 0)
+			want = append(want, tx)
+		}
+		account++
+	}
+	sort.Slice(want, func(i, j int) bool {
+		return arrivals[want[i].Hash()].Before(arrivals[want[j].Hash()])
+	})
+	policy, _ := NewTxOrderingPolicy(OrderingFIFO, 0)
+	arrival := func(hash common.Hash) time.Time { return arrivals[hash] }
+	sorted := drainSets(policy.Order(&types.Header{Number: big.NewInt(1)}, signer, arrival, nil, txs))
+	checkNonceOrder(t, signer, sorted, 6)
+
+	for i, tx := range sorted {
+		if tx.Hash() != want[i].Hash() {
+			t.Errorf("tx #%d: order mismatch: have %x, want %x", i, tx.Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that the local-first ordering policy returns all local transactions
+// before any remote one.
+func TestLocalFirstOrdering(t *testing.T) {
+	signer, locals := makeOrderingTxs(t, 3, 2)
+	_, remotes := makeOrderingTxs(t, 3, 2)
+
+	isLocal := make(map[common.Address]bool)
+	for addr := range locals {
+		isLocal[addr] = true
+	}
+	policy, _ := NewTxOrderingPolicy(OrderingLocalFirst, 0)
+	sorted := drainSets(policy.Order(&types.Header{Number: big.NewInt(1)}, signer, testArrival, locals, remotes))
+	checkNonceOrder(t, signer, sorted, 12)
+
+	for i, tx := range sorted {
+		from, _ := types.Sender(signer, tx)
+		if isLocal[from] != (i < 6) {
+			t.Errorf("tx #%d: locality mismatch: local %v", i, isLocal[from])
+		}
+	}
+}
+
+// Tests that transactions not paying the base fee are skipped along with all
+// subsequent transactions of the same account.
+func TestOrderingBaseFeeFilter(t *testing.T) {
+	signer, txs := makeOrderingTxs(t, 2, 2)
+
+	policy, _ := NewTxOrderingPolicy(OrderingFIFO, 0)
+	header := &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.InitialBaseFee + 1)}
+	if sorted := drainSets(policy.Order(header, signer, testArrival, nil, txs)); len(sorted) != 0 {
+		t.Errorf("underpriced transactions returned: %d", len(sorted))
+	}
+}
@@ -225,10 +225,12 @@ type worker struct {
 	localUncles  map[common.Hash]*types.Block // A set of side blocks generated locally as the possible uncle blocks.
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
+	bundles      *bundlePool                  // A set of bundles to include atomically at the top of the blocks.
 
-	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
+	mu       sync.RWMutex // The lock used to protect the coinbase, extra and ordering fields
 	coinbase common.Address
 	extra    []byte
+	ordering TxOrderingPolicy
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
@@ -282,6 +284,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), sealingLogAtDepth),
+		bundles:            new(bundlePool),
 		pendingTasks:       make(map[common.Hash]*task),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
@@ -320,6 +323,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	}
 	worker.newpayloadTimeout = newpayloadTimeout
 
+	// Sanitize the transaction ordering policy.
+	ordering, err := NewTxOrderingPolicy(worker.config.Ordering, worker.config.OrderingSeed)
+	if err != nil {
+		log.Warn("Sanitizing transaction ordering policy to default", "provided", worker.config.Ordering, "updated", OrderingPrice, "err", err)
+		ordering, _ = NewTxOrderingPolicy(OrderingPrice, 0)
+	}
+	worker.ordering = ordering
+
 	worker.wg.Add(4)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
@@ -353,6 +364,20 @@ func (w *worker) setExtra(extra []byte) {
 	w.extra = extra
 }
 
+// setOrderingPolicy sets the policy used to order the transactions of new blocks.
+func (w *worker) setOrderingPolicy(policy TxOrderingPolicy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ordering = policy
+}
+
+// orderingPolicy returns the policy used to order the transactions of new blocks.
+func (w *worker) orderingPolicy() TxOrderingPolicy {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.ordering
+}
+
 // setRecommitInterval updates the interval for miner sealing work recommitting.
 func (w *worker) setRecommitInterval(interval time.Duration) {
 	select {
@@ -624,9 +649,10 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
 				tcount := w.current.tcount
-				w.commitTransactions(w.current, txset, nil)
+				for _, txset := range w.orderTransactions(w.current, txs) {
+					w.commitTransactions(w.current, txset, nil)
+				}
 
 				// Only update the snapshot if any new transactions were added
 				// to the pending block
@@ -865,7 +891,7 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, interrupt *int32) error {
+func (w *worker) commitTransactions(env *environment, txs TransactionSet, interrupt *int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
@@ -1051,13 +1077,9 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	return env, nil
 }
 
-// fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
-func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
-	// Split the pending transactions into locals and remotes
-	// Fill the block with all available pending transactions.
-	pending := w.eth.TxPool().Pending(true)
+// orderTransactions splits the given transactions into locals and remotes and
+// orders them with the configured transaction ordering policy.
+func (w *worker) orderTransactions(env *environment, pending map[common.Address]types.Transactions) []TransactionSet {
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
 		if txs := remoteTxs[account]; len(txs) > 0 {
@@ -1065,14 +1087,21 @@ func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
 			localTxs[account] = txs
 		}
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
-		if err := w.commitTransactions(env, txs, interrupt); err != nil {
-			return err
-		}
+	return w.orderingPolicy().Order(env.header, env.signer, w.eth.TxPool().Arrival, localTxs, remoteTxs)
+}
+
+// fillTransactions retrieves the pending transactions from the txpool and fills them
+// into the given sealing block, after any bundles targeting it. The transaction
+// selection and ordering strategy is defined by the configured TxOrderingPolicy.
+func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
+	// Place the bundles at the top of the block, then fill it with all available
+	// pending transactions.
+	if err := w.commitBundles(env, interrupt); err != nil {
+		return err
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+
+	pending := w.eth.TxPool().Pending(true)
+	for _, txs := range w.orderTransactions(env, pending) {
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
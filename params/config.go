@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
@@ -270,17 +271,17 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, false, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, false, nil, nil, new(EthashConfig), nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, false, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, false, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig    = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, false, new(EthashConfig), nil}
-	NonActivatedConfig = &ChainConfig{big.NewInt(1), nil, nil, false, nil, common.Hash{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, false, new(EthashConfig), nil}
+	TestChainConfig    = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, false, nil, nil, new(EthashConfig), nil}
+	NonActivatedConfig = &ChainConfig{big.NewInt(1), nil, nil, false, nil, common.Hash{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, false, nil, nil, new(EthashConfig), nil}
 	TestRules          = TestChainConfig.Rules(new(big.Int), false)
 )
 
@@ -373,6 +374,7 @@ type ChainConfig struct {
 	MergeNetsplitBlock  *big.Int `json:"mergeNetsplitBlock,omitempty"`  // Virtual fork after The Merge to use as a network splitter
 	ShanghaiBlock       *big.Int `json:"shanghaiBlock,omitempty"`       // Shanghai switch block (nil = no fork, 0 = already on shanghai)
 	CancunBlock         *big.Int `json:"cancunBlock,omitempty"`         // Cancun switch block (nil = no fork, 0 = already on cancun)
+	EOFBlock            *big.Int `json:"eofBlock,omitempty"`            // EVM Object Format switch block (nil = no fork, 0 = already on eof)
 
 	// TerminalTotalDifficulty is the amount of total difficulty reached by
 	// the network that triggers the consensus upgrade.
@@ -383,6 +385,15 @@ type ChainConfig struct {
 	// even without having seen the TTD locally (safer long term).
 	TerminalTotalDifficultyPassed bool `json:"terminalTotalDifficultyPassed,omitempty"`
 
+	// Experimental maps the names of the experimental VM extensions registered
+	// in core/vm to their activation block (nil = no fork, 0 = from genesis).
+	// It is meant for prototyping protocol changes on private networks.
+	Experimental map[string]*big.Int `json:"experimental,omitempty"`
+
+	// GasSchedule overrides gas costs of the protocol from genesis, for private
+	// networks measuring repricing proposals (nil = builtin costs).
+	GasSchedule *GasSchedule `json:"gasSchedule,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -471,6 +482,9 @@ func (c *ChainConfig) Description() string {
 	if c.CancunBlock != nil {
 		banner += fmt.Sprintf(" - Cancun:                      %-8v\n", c.CancunBlock)
 	}
+	if c.EOFBlock != nil {
+		banner += fmt.Sprintf(" - EOF:                         %-8v\n", c.EOFBlock)
+	}
 	banner += "\n"
 
 	// Add a special section for the merge as it's non-obvious
@@ -484,6 +498,18 @@ func (c *ChainConfig) Description() string {
 		banner += fmt.Sprintf(" - Total terminal difficulty:  %v\n", c.TerminalTotalDifficulty)
 		banner += fmt.Sprintf(" - Merge netsplit block:       %-8v", c.MergeNetsplitBlock)
 	}
+	if len(c.Experimental) > 0 {
+		names := make([]string, 0, len(c.Experimental))
+		for name := range c.Experimental {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		banner += "\n\nExperimental VM extensions:\n"
+		for _, name := range names {
+			banner += fmt.Sprintf(" - %-28v %-8v\n", name+":", c.Experimental[name])
+		}
+	}
 	return banner
 }
 
@@ -577,6 +603,11 @@ func (c *ChainConfig) IsCancun(num *big.Int) bool {
 	return isForked(c.CancunBlock, num)
 }
 
+// IsEOF returns whether num is either equal to the EOF fork block or greater.
+func (c *ChainConfig) IsEOF(num *big.Int) bool {
+	return isForked(c.EOFBlock, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -641,6 +672,21 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// EOF relies on the 0xEF prefix being reserved by London (EIP-3541), and is
+	// independent of the later forks
+	if c.EOFBlock != nil {
+		if c.LondonBlock == nil {
+			return fmt.Errorf("unsupported fork ordering: londonBlock not enabled, but eofBlock enabled at %v", c.EOFBlock)
+		}
+		if c.LondonBlock.Cmp(c.EOFBlock) > 0 {
+			return fmt.Errorf("unsupported fork ordering: londonBlock enabled at %v, but eofBlock enabled at %v", c.LondonBlock, c.EOFBlock)
+		}
+	}
+	if c.GasSchedule != nil {
+		if err := c.GasSchedule.Validate(); err != nil {
+			return fmt.Errorf("invalid gas schedule: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -706,6 +752,25 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.CancunBlock, newcfg.CancunBlock, head) {
 		return newCompatError("Cancun fork block", c.CancunBlock, newcfg.CancunBlock)
 	}
+	if isForkIncompatible(c.EOFBlock, newcfg.EOFBlock, head) {
+		return newCompatError("EOF fork block", c.EOFBlock, newcfg.EOFBlock)
+	}
+	// Experimental extensions are forks of their own, check them in name order
+	var names []string
+	for name := range c.Experimental {
+		names = append(names, name)
+	}
+	for name := range newcfg.Experimental {
+		if _, ok := c.Experimental[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if isForkIncompatible(c.Experimental[name], newcfg.Experimental[name], head) {
+			return newCompatError("experimental extension "+name+" block", c.Experimental[name], newcfg.Experimental[name])
+		}
+	}
 	return nil
 }
 
@@ -784,7 +849,8 @@ type Rules struct {
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon                                      bool
-	IsMerge, IsShanghai, isCancun                           bool
+	IsMerge, IsShanghai, isCancun, IsEOF                    bool
+	Experimental                                            []string // Sorted names of the active experimental VM extensions
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -808,5 +874,20 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool) Rules {
 		IsMerge:          isMerge,
 		IsShanghai:       c.IsShanghai(num),
 		isCancun:         c.IsCancun(num),
+		IsEOF:            c.IsEOF(num),
+		Experimental:     c.ActiveExperimental(num),
+	}
+}
+
+// ActiveExperimental returns the sorted names of the experimental VM extensions
+// active at the given block.
+func (c *ChainConfig) ActiveExperimental(num *big.Int) []string {
+	var names []string
+	for name, block := range c.Experimental {
+		if isForked(block, num) {
+			names = append(names, name)
+		}
 	}
+	sort.Strings(names)
+	return names
 }
@@ -87,6 +87,33 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored: &ChainConfig{LondonBlock: big.NewInt(10), EOFBlock: big.NewInt(20)},
+			new:    &ChainConfig{LondonBlock: big.NewInt(10), EOFBlock: big.NewInt(30)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "EOF fork block",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Experimental: map[string]*big.Int{"a": big.NewInt(10)}},
+			new:    &ChainConfig{Experimental: map[string]*big.Int{"a": big.NewInt(10), "b": big.NewInt(20)}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "experimental extension b block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Experimental: map[string]*big.Int{"a": big.NewInt(10)}},
+			new:    &ChainConfig{Experimental: map[string]*big.Int{"a": big.NewInt(10), "b": big.NewInt(30)}},
+			head:   25,
+		},
 	}
 
 	for _, test := range tests {
@@ -96,3 +123,24 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckConfigForkOrderEOF(t *testing.T) {
+	for i, test := range []struct {
+		london, eof *big.Int
+		fail        bool
+	}{
+		{nil, nil, false},
+		{big.NewInt(10), nil, false},
+		{big.NewInt(10), big.NewInt(10), false},
+		{big.NewInt(10), big.NewInt(20), false},
+		{nil, big.NewInt(10), true},
+		{big.NewInt(20), big.NewInt(10), true},
+	} {
+		config := *AllEthashProtocolChanges
+		config.ArrowGlacierBlock, config.GrayGlacierBlock = nil, nil
+		config.LondonBlock, config.EOFBlock = test.london, test.eof
+		if err := config.CheckConfigForkOrder(); (err != nil) != test.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, test.fail)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GasSchedule overrides gas costs of the protocol, to measure the effect of
+// repricing proposals without modifying the constants. Unset fields keep the
+// costs of the active forks.
+type GasSchedule struct {
+	// Opcodes overrides the constant gas of opcodes, by name. The dynamic part
+	// of the cost (memory expansion, cold accesses, etc) is charged on top.
+	Opcodes map[string]uint64 `json:"opcodes,omitempty"`
+
+	MemoryGas    *uint64 `json:"memoryGas,omitempty"`    // Linear coefficient of the memory expansion cost
+	QuadCoeffDiv *uint64 `json:"quadCoeffDiv,omitempty"` // Divisor of the quadratic memory expansion cost
+	CopyGas      *uint64 `json:"copyGas,omitempty"`      // Per word cost of the *COPY opcodes
+
+	// Access costs of EIP-2929, applied from Berlin. The warm cost is also the
+	// constant gas of the account accessing opcodes.
+	ColdAccountAccessCost *uint64 `json:"coldAccountAccessCost,omitempty"`
+	ColdSloadCost         *uint64 `json:"coldSloadCost,omitempty"`
+	WarmStorageReadCost   *uint64 `json:"warmStorageReadCost,omitempty"`
+
+	// Precompiles overrides the pricing of precompiled contracts, by address.
+	Precompiles map[common.Address]*PrecompileGas `json:"precompiles,omitempty"`
+
+	// Intrinsic costs of transactions.
+	TxGas                     *uint64 `json:"txGas,omitempty"`
+	TxGasContractCreation     *uint64 `json:"txGasContractCreation,omitempty"`
+	TxDataZeroGas             *uint64 `json:"txDataZeroGas,omitempty"`
+	TxDataNonZeroGas          *uint64 `json:"txDataNonZeroGas,omitempty"` // Replaces the cost of the active fork (EIP-2028 or earlier)
+	TxAccessListAddressGas    *uint64 `json:"txAccessListAddressGas,omitempty"`
+	TxAccessListStorageKeyGas *uint64 `json:"txAccessListStorageKeyGas,omitempty"`
+}
+
+// PrecompileGas is the linear pricing of a precompiled contract, replacing its
+// builtin pricing formula: base + word * ceil(len(input) / 32).
+type PrecompileGas struct {
+	Base uint64 `json:"base"`
+	Word uint64 `json:"word"`
+}
+
+// Validate checks that the overridden costs are consistent with each other.
+func (s *GasSchedule) Validate() error {
+	if s.QuadCoeffDiv != nil && *s.QuadCoeffDiv == 0 {
+		return errors.New("zero quadCoeffDiv")
+	}
+	var (
+		coldAccount = ColdAccountAccessCostEIP2929
+		coldSload   = ColdSloadCostEIP2929
+		warm        = WarmStorageReadCostEIP2929
+	)
+	if s.ColdAccountAccessCost != nil {
+		coldAccount = *s.ColdAccountAccessCost
+	}
+	if s.ColdSloadCost != nil {
+		coldSload = *s.ColdSloadCost
+	}
+	if s.WarmStorageReadCost != nil {
+		warm = *s.WarmStorageReadCost
+	}
+	if warm > coldAccount {
+		return fmt.Errorf("warmStorageReadCost %d above coldAccountAccessCost %d", warm, coldAccount)
+	}
+	if coldSload+warm > SstoreResetGasEIP2200 {
+		return fmt.Errorf("coldSloadCost %d plus warmStorageReadCost %d above sstore reset cost %d", coldSload, warm, SstoreResetGasEIP2200)
+	}
+	for addr, gas := range s.Precompiles {
+		if gas == nil {
+			return fmt.Errorf("missing pricing of precompile %v", addr)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether two gas schedules override the same costs. A nil gas
+// schedule is equal to an empty one.
+func (s *GasSchedule) Equal(other *GasSchedule) bool {
+	if s == nil {
+		s = new(GasSchedule)
+	}
+	if other == nil {
+		other = new(GasSchedule)
+	}
+	a, _ := json.Marshal(s)
+	b, _ := json.Marshal(other)
+	return bytes.Equal(a, b)
+}
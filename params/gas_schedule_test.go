@@ -0,0 +1,46 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGasScheduleValidate(t *testing.T) {
+	tests := []struct {
+		schedule string
+		valid    bool
+	}{
+		{`{}`, true},
+		{`{"opcodes": {"ADD": 5}, "memoryGas": 2, "copyGas": 1}`, true},
+		{`{"quadCoeffDiv": 0}`, false},
+		{`{"warmStorageReadCost": 3000}`, false},
+		{`{"coldSloadCost": 5000}`, false},
+		{`{"precompiles": {"0x0000000000000000000000000000000000000004": {"base": 1, "word": 1}}}`, true},
+		{`{"precompiles": {"0x0000000000000000000000000000000000000004": null}}`, false},
+	}
+	for i, tt := range tests {
+		var schedule GasSchedule
+		if err := json.Unmarshal([]byte(tt.schedule), &schedule); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if err := schedule.Validate(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}